@@ -0,0 +1,134 @@
+package Model
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+type CORSRule struct {
+	Id             string   `json:"id,omitempty"`
+	AllowedOrigins []string `json:"allowedOrigins"`
+	AllowedMethods []string `json:"allowedMethods"`
+	AllowedHeaders []string `json:"allowedHeaders,omitempty"`
+	ExposeHeaders  []string `json:"exposeHeaders,omitempty"`
+	MaxAgeSeconds  int      `json:"maxAgeSeconds,omitempty"`
+}
+
+type CORSConfig struct {
+	Rules []CORSRule `json:"rules"`
+}
+
+func NewCORSConfig(jsonValue []byte) (*CORSConfig, error) {
+	var corsConfig CORSConfig
+	if len(jsonValue) == 0 {
+		return &corsConfig, nil
+	}
+	err := json.Unmarshal(jsonValue, &corsConfig)
+	if err != nil {
+		return nil, NewFDSError(err.Error(), -1)
+	}
+	return &corsConfig, nil
+}
+
+var corsMethods = map[string]bool{"GET": true, "PUT": true, "POST": true, "DELETE": true, "HEAD": true}
+
+// Validate 检查每条规则至少有一个origin和method，method只能是GET/PUT/POST/DELETE/HEAD，
+// origin和header中最多只能有一个*，MaxAgeSeconds不能为负
+func (c *CORSConfig) Validate() error {
+	for i, r := range c.Rules {
+		name := r.Id
+		if len(name) == 0 {
+			name = "#" + strconv.Itoa(i)
+		}
+		if len(r.AllowedOrigins) == 0 || len(r.AllowedMethods) == 0 {
+			return NewFDSError("cors rule "+name+" requires allowed origins and methods", -1)
+		}
+		for _, m := range r.AllowedMethods {
+			if !corsMethods[m] {
+				return NewFDSError("cors rule "+name+": invalid method "+m, -1)
+			}
+		}
+		for _, p := range append(append([]string(nil), r.AllowedOrigins...), r.AllowedHeaders...) {
+			if strings.Count(p, "*") > 1 {
+				return NewFDSError("cors rule "+name+": at most one * is allowed in "+p, -1)
+			}
+		}
+		if r.MaxAgeSeconds < 0 {
+			return NewFDSError("cors rule "+name+": negative max age", -1)
+		}
+	}
+	return nil
+}
+
+// corsMatch 匹配可以包含一个*通配符的模式
+func corsMatch(pattern, s string) bool {
+	i := strings.Index(pattern, "*")
+	if i < 0 {
+		return pattern == s
+	}
+	return len(s) >= len(pattern)-1 && strings.HasPrefix(s, pattern[:i]) && strings.HasSuffix(s, pattern[i+1:])
+}
+
+func corsMatchAny(patterns []string, s string, fold bool) bool {
+	for _, p := range patterns {
+		if fold {
+			p, s = strings.ToLower(p), strings.ToLower(s)
+		}
+		if corsMatch(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows 判断规则是否允许来自origin、使用method并带有requestHeaders的请求，header比较不区分大小写
+func (r *CORSRule) Allows(origin, method string, requestHeaders []string) bool {
+	if !corsMatchAny(r.AllowedOrigins, origin, false) {
+		return false
+	}
+	allowed := false
+	for _, m := range r.AllowedMethods {
+		allowed = allowed || m == strings.ToUpper(method)
+	}
+	if !allowed {
+		return false
+	}
+	for _, h := range requestHeaders {
+		if h = strings.TrimSpace(h); len(h) > 0 && !corsMatchAny(r.AllowedHeaders, h, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate 按顺序返回第一条允许该预检请求的规则，requestHeaders为Access-Control-Request-Headers中的header
+func (c *CORSConfig) Evaluate(origin, method string, requestHeaders []string) (*CORSRule, bool) {
+	for i := range c.Rules {
+		if c.Rules[i].Allows(origin, method, requestHeaders) {
+			return &c.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// ResponseHeaders 返回规则允许请求时应该返回的Access-Control-*头
+func (r *CORSRule) ResponseHeaders(origin string, requestHeaders []string) map[string]string {
+	headers := map[string]string{
+		"Access-Control-Allow-Origin":  origin,
+		"Access-Control-Allow-Methods": strings.Join(r.AllowedMethods, ", "),
+	}
+	if len(r.AllowedOrigins) == 1 && r.AllowedOrigins[0] == "*" {
+		headers["Access-Control-Allow-Origin"] = "*"
+	}
+	if len(requestHeaders) > 0 {
+		headers["Access-Control-Allow-Headers"] = strings.Join(requestHeaders, ", ")
+	}
+	if len(r.ExposeHeaders) > 0 {
+		headers["Access-Control-Expose-Headers"] = strings.Join(r.ExposeHeaders, ", ")
+	}
+	if r.MaxAgeSeconds > 0 {
+		headers["Access-Control-Max-Age"] = strconv.Itoa(r.MaxAgeSeconds)
+	}
+	return headers
+}
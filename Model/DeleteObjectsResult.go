@@ -0,0 +1,23 @@
+package Model
+
+import "encoding/json"
+
+// DeleteObjectError 批量删除中删除失败的object
+type DeleteObjectError struct {
+	ObjectName       string `json:"object_name"`
+	ErrorCode        int    `json:"error_code"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// NewDeleteObjectErrors 解析批量删除的返回，全部删除成功时返回空列表
+func NewDeleteObjectErrors(jsonValue []byte) ([]DeleteObjectError, error) {
+	var deleteObjectErrors []DeleteObjectError
+	if len(jsonValue) == 0 {
+		return deleteObjectErrors, nil
+	}
+	err := json.Unmarshal(jsonValue, &deleteObjectErrors)
+	if err != nil {
+		return nil, NewFDSError(err.Error(), -1)
+	}
+	return deleteObjectErrors, nil
+}
@@ -16,6 +16,8 @@ const (
 	LastChecked           = "last-checked"
 	UploadTime            = "upload-time"
 	ContentMetadataLength = "x-xiaomi-meta-content-length"
+	VersionId             = "x-xiaomi-version-id"
+	UncompressedLength    = "x-xiaomi-meta-uncompressed-length"
 )
 
 type FDSMetaData struct {
@@ -114,3 +116,41 @@ func (d *FDSMetaData) GetMetadataContentLength() (int64, error) {
 	}
 	return strconv.ParseInt(s, 10, 64)
 }
+
+func (d *FDSMetaData) GetVersionId() (string, error) {
+	return d.GetKey(VersionId)
+}
+
+// GetUncompressedLength 返回Put_Object_Compressed等压缩上传时记录的原始长度
+func (d *FDSMetaData) GetUncompressedLength() (int64, error) {
+	s, err := d.GetKey(UncompressedLength)
+	if err != nil {
+		return 0, NewFDSError(err.Error(), -1)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// GetServerSideEncryption 返回服务端加密的算法，使用客户密钥加密时返回客户密钥的算法
+func (d *FDSMetaData) GetServerSideEncryption() (string, error) {
+	if s, err := d.GetKey(ServerSideEncryption); err == nil && len(s) > 0 {
+		return s, nil
+	}
+	return d.GetKey(SSECustomerAlgorithm)
+}
+
+// IsServerSideEncrypted 判断object是否使用了服务端加密
+func (d *FDSMetaData) IsServerSideEncrypted() bool {
+	s, _ := d.GetServerSideEncryption()
+	return len(s) > 0
+}
+
+// IsSSECustomerKey 判断object是否使用客户提供的密钥加密，读取时需要提供相同的密钥
+func (d *FDSMetaData) IsSSECustomerKey() bool {
+	s, _ := d.GetKey(SSECustomerAlgorithm)
+	return len(s) > 0
+}
+
+// GetSSECustomerKeyMD5 返回加密object使用的客户密钥的md5(base64编码)，可以用来判断应该使用哪个密钥
+func (d *FDSMetaData) GetSSECustomerKeyMD5() (string, error) {
+	return d.GetKey(SSECustomerKeyMD5)
+}
@@ -0,0 +1,58 @@
+package Model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// bucket多版本状态
+const (
+	VERSIONING_ENABLED   = "Enabled"
+	VERSIONING_SUSPENDED = "Suspended"
+)
+
+type BucketVersioning struct {
+	Status string `json:"status"`
+}
+
+type FDSObjectVersionSummary struct {
+	Etag           string    `json:"etag"`
+	ObjectName     string    `json:"name"`
+	VersionId      string    `json:"versionId"`
+	IsLatest       bool      `json:"isLatest"`
+	IsDeleteMarker bool      `json:"isDeleteMarker"`
+	Owner          Owner     `json:"owner"`
+	Size           int64     `json:"size"`
+	LastModified   time.Time `json:"lastModified"`
+}
+
+type FDSObjectVersionListing struct {
+	BucketName          string `json:"name"`
+	Prefix              string
+	Delimiter           string
+	KeyMarker           string
+	VersionIdMarker     string
+	NextKeyMarker       string
+	NextVersionIdMarker string
+	MaxKeys             int
+	Truncated           bool
+	Versions            []FDSObjectVersionSummary `json:"versions"`
+	CommonPrefixes      []string
+}
+
+func NewFDSObjectVersionListing(jsonValue []byte) (*FDSObjectVersionListing, error) {
+	var listing FDSObjectVersionListing
+	if len(jsonValue) == 0 {
+		return &listing, nil
+	}
+	// 与FDSObjectListing相同，删除无法转换为时间类型的"lastModified":null
+	if strings.Contains(string(jsonValue), "\"lastModified\":null,") {
+		jsonValue = []byte(strings.Replace(string(jsonValue), "\"lastModified\":null,", "", -1))
+	}
+	err := json.Unmarshal(jsonValue, &listing)
+	if err != nil {
+		return nil, NewFDSError(err.Error(), -1)
+	}
+	return &listing, nil
+}
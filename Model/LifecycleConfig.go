@@ -0,0 +1,143 @@
+package Model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type LifecycleDays struct {
+	Days int `json:"days"`
+}
+
+// LifecycleActions 规则匹配的object在指定天数后执行的操作，没有设置的操作为nil
+type LifecycleActions struct {
+	// Expiration 最后修改时间超过指定天数的object会被删除
+	Expiration *LifecycleDays `json:"expiration,omitempty"`
+	// AbortIncompleteMultipartUpload 超过指定天数仍未完成的分片上传会被abort
+	AbortIncompleteMultipartUpload *LifecycleDays `json:"abortIncompleteMultipartUpload,omitempty"`
+	// MoveToTrash 最后修改时间超过指定天数的object会被移到回收站
+	MoveToTrash *LifecycleDays `json:"moveToTrash,omitempty"`
+}
+
+type LifecycleRule struct {
+	Id      string           `json:"id"`
+	Prefix  string           `json:"prefix"`
+	Enabled bool             `json:"enabled"`
+	Actions LifecycleActions `json:"actions"`
+}
+
+type LifecycleConfig struct {
+	Rules []LifecycleRule `json:"rules"`
+}
+
+func NewLifecycleConfig(jsonValue []byte) (*LifecycleConfig, error) {
+	var lifecycleConfig LifecycleConfig
+	if len(jsonValue) == 0 {
+		return &lifecycleConfig, nil
+	}
+	err := json.Unmarshal(jsonValue, &lifecycleConfig)
+	if err != nil {
+		return nil, NewFDSError(err.Error(), -1)
+	}
+	return &lifecycleConfig, nil
+}
+
+// GetRule 返回指定id的规则
+func (c *LifecycleConfig) GetRule(id string) (*LifecycleRule, bool) {
+	for i := range c.Rules {
+		if c.Rules[i].Id == id {
+			return &c.Rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// SetRule 添加规则，id已经存在时覆盖
+func (c *LifecycleConfig) SetRule(rule LifecycleRule) {
+	if r, ok := c.GetRule(rule.Id); ok {
+		*r = rule
+		return
+	}
+	c.Rules = append(c.Rules, rule)
+}
+
+// RemoveRule 删除指定id的规则，返回是否存在
+func (c *LifecycleConfig) RemoveRule(id string) bool {
+	for i := range c.Rules {
+		if c.Rules[i].Id == id {
+			c.Rules = append(c.Rules[:i], c.Rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (a *LifecycleActions) each(fn func(name string, days *LifecycleDays)) {
+	fn("expiration", a.Expiration)
+	fn("abortIncompleteMultipartUpload", a.AbortIncompleteMultipartUpload)
+	fn("moveToTrash", a.MoveToTrash)
+}
+
+// Validate 检查规则本身是否合法以及规则之间是否冲突：id不能为空或重复，每条规则至少有一个操作且天数大于0，
+// moveToTrash必须早于expiration；启用的规则中，前缀有包含关系的两条规则不能设置同一种操作
+func (c *LifecycleConfig) Validate() error {
+	ids := map[string]bool{}
+	for _, r := range c.Rules {
+		if len(r.Id) == 0 {
+			return NewFDSError("lifecycle rule id is required", -1)
+		}
+		if ids[r.Id] {
+			return NewFDSError("duplicate lifecycle rule id: "+r.Id, -1)
+		}
+		ids[r.Id] = true
+		actions := 0
+		var err error
+		r.Actions.each(func(name string, days *LifecycleDays) {
+			if days == nil {
+				return
+			}
+			actions++
+			if days.Days <= 0 && err == nil {
+				err = NewFDSError(fmt.Sprintf("lifecycle rule %s: %s days must be positive", r.Id, name), -1)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if actions == 0 {
+			return NewFDSError("lifecycle rule "+r.Id+" has no action", -1)
+		}
+		if r.Actions.Expiration != nil && r.Actions.MoveToTrash != nil &&
+			r.Actions.MoveToTrash.Days >= r.Actions.Expiration.Days {
+			return NewFDSError("lifecycle rule "+r.Id+": moveToTrash must happen before expiration", -1)
+		}
+	}
+
+	for i, a := range c.Rules {
+		for _, b := range c.Rules[i+1:] {
+			if !a.Enabled || !b.Enabled {
+				continue
+			}
+			if !strings.HasPrefix(a.Prefix, b.Prefix) && !strings.HasPrefix(b.Prefix, a.Prefix) {
+				continue
+			}
+			var conflict string
+			a.Actions.each(func(name string, days *LifecycleDays) {
+				if days == nil || len(conflict) > 0 {
+					return
+				}
+				b.Actions.each(func(other string, otherDays *LifecycleDays) {
+					if other == name && otherDays != nil {
+						conflict = name
+					}
+				})
+			})
+			if len(conflict) > 0 {
+				return NewFDSError(fmt.Sprintf("lifecycle rules %s and %s overlap on prefix and both set %s",
+					a.Id, b.Id, conflict), -1)
+			}
+		}
+	}
+	return nil
+}
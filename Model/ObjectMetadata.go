@@ -0,0 +1,185 @@
+package Model
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ContentDisposition   = "content-disposition"
+	Expires              = "expires"
+	UserMetadataPrefix   = "x-xiaomi-meta-"
+	MaxUserMetadataBytes = 2048
+)
+
+// ObjectMetadata 上传object时设置的metadata，用于Put_Object_With_Metadata、Init_MultiPart_Upload_With_Metadata
+// 和Set_Object_Metadata。Set*方法返回自身，可以链式调用：
+//
+//	meta := Model.NewObjectMetadata().SetContentType("text/html").SetUserMetadata("owner", "web")
+type ObjectMetadata struct {
+	headers map[string]string
+}
+
+func NewObjectMetadata() *ObjectMetadata {
+	return &ObjectMetadata{headers: map[string]string{}}
+}
+
+// NewObjectMetadataFromFDSMetaData 从Get_Object_Meta的结果中取出可以设置的metadata，用于修改后再写回
+func NewObjectMetadataFromFDSMetaData(meta *FDSMetaData) *ObjectMetadata {
+	m := NewObjectMetadata()
+	for k, v := range meta.GetRawMetadata() {
+		if len(v) == 0 || k == ContentMetadataLength {
+			continue
+		}
+		if settableMetadata[k] || strings.HasPrefix(k, UserMetadataPrefix) {
+			m.headers[k] = v[0]
+		}
+	}
+	return m
+}
+
+// Clone 返回metadata的副本
+func (m *ObjectMetadata) Clone() *ObjectMetadata {
+	c := NewObjectMetadata()
+	for k, v := range m.headers {
+		c.headers[k] = v
+	}
+	return c
+}
+
+var settableMetadata = map[string]bool{
+	ContentType:        true,
+	CacheControl:       true,
+	ContentEncoding:    true,
+	ContentDisposition: true,
+	Expires:            true,
+}
+
+func (m *ObjectMetadata) set(k, v string) *ObjectMetadata {
+	if len(v) == 0 {
+		delete(m.headers, k)
+	} else {
+		m.headers[k] = v
+	}
+	return m
+}
+
+// SetContentType 设置content-type，值为空时删除，下同
+func (m *ObjectMetadata) SetContentType(v string) *ObjectMetadata {
+	return m.set(ContentType, v)
+}
+
+func (m *ObjectMetadata) SetCacheControl(v string) *ObjectMetadata {
+	return m.set(CacheControl, v)
+}
+
+func (m *ObjectMetadata) SetContentEncoding(v string) *ObjectMetadata {
+	return m.set(ContentEncoding, v)
+}
+
+func (m *ObjectMetadata) SetContentDisposition(v string) *ObjectMetadata {
+	return m.set(ContentDisposition, v)
+}
+
+// SetExpires 设置expires，t为零值时删除
+func (m *ObjectMetadata) SetExpires(t time.Time) *ObjectMetadata {
+	if t.IsZero() {
+		return m.set(Expires, "")
+	}
+	return m.set(Expires, t.UTC().Format(http.TimeFormat))
+}
+
+// SetUserMetadata 设置用户自定义metadata，key会转换为小写并自动加上x-xiaomi-meta-前缀
+func (m *ObjectMetadata) SetUserMetadata(key, value string) *ObjectMetadata {
+	key = strings.ToLower(key)
+	if !strings.HasPrefix(key, UserMetadataPrefix) {
+		key = UserMetadataPrefix + key
+	}
+	return m.set(key, value)
+}
+
+func (m *ObjectMetadata) GetContentType() string {
+	return m.headers[ContentType]
+}
+
+// GetUserMetadata 返回去掉x-xiaomi-meta-前缀的用户自定义metadata
+func (m *ObjectMetadata) GetUserMetadata() map[string]string {
+	user := map[string]string{}
+	for k, v := range m.headers {
+		if strings.HasPrefix(k, UserMetadataPrefix) {
+			user[strings.TrimPrefix(k, UserMetadataPrefix)] = v
+		}
+	}
+	return user
+}
+
+// Headers 返回除content-type以外需要随请求发送的header，content-type通过单独的参数发送
+func (m *ObjectMetadata) Headers() map[string]string {
+	headers := map[string]string{}
+	for k, v := range m.headers {
+		if k != ContentType {
+			headers[k] = v
+		}
+	}
+	return headers
+}
+
+// ToFDSMetaData 转换为SetObjectMetadata使用的FDSMetaData
+func (m *ObjectMetadata) ToFDSMetaData() *FDSMetaData {
+	raw := map[string][]string{}
+	for k, v := range m.headers {
+		raw[k] = []string{v}
+	}
+	return NewFDSMetaData(raw)
+}
+
+func isHeaderToken(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Validate 检查header名字只包含小写字母、数字和-_.，值只包含可打印的ASCII字符，
+// expires为HTTP日期格式，用户自定义metadata的名字和值总长度不超过MaxUserMetadataBytes
+func (m *ObjectMetadata) Validate() error {
+	keys := make([]string, 0, len(m.headers))
+	for k := range m.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	userBytes := 0
+	for _, k := range keys {
+		v := m.headers[k]
+		if strings.HasPrefix(k, UserMetadataPrefix) {
+			if !isHeaderToken(strings.TrimPrefix(k, UserMetadataPrefix)) {
+				return NewFDSError("invalid user metadata name: "+k, -1)
+			}
+			userBytes += len(k) + len(v)
+		} else if !settableMetadata[k] {
+			return NewFDSError("metadata can not be set: "+k, -1)
+		}
+		for _, r := range v {
+			if r < ' ' && r != '\t' || r > '~' {
+				return NewFDSError("invalid character in metadata "+k+", non-ASCII values should be encoded", -1)
+			}
+		}
+		if k == Expires {
+			if _, err := http.ParseTime(v); err != nil {
+				return NewFDSError("invalid expires: "+v, -1)
+			}
+		}
+	}
+	if userBytes > MaxUserMetadataBytes {
+		return NewFDSError("user metadata is too large", -1)
+	}
+	return nil
+}
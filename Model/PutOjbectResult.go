@@ -10,6 +10,7 @@ type PutObjectResult struct {
 	AccessKeyId  string
 	Signature    string
 	Expires      int64
+	VersionId    string
 	rawJsonValue []byte
 }
 
@@ -0,0 +1,85 @@
+package Model
+
+import (
+	"encoding/json"
+)
+
+// quota类型
+const (
+	QUOTA_TYPE_QPS     = "QPS"     // 每秒请求数，Action为限制的操作
+	QUOTA_TYPE_SPACE   = "SPACE"   // 使用空间，单位字节
+	QUOTA_TYPE_OBJECTS = "OBJECTS" // object数量
+)
+
+// QPS quota限制的操作
+const (
+	QUOTA_ACTION_GET    = "GET"
+	QUOTA_ACTION_PUT    = "PUT"
+	QUOTA_ACTION_DELETE = "DELETE"
+	QUOTA_ACTION_LIST   = "LIST"
+)
+
+type Quota struct {
+	Type   string `json:"type"`
+	Action string `json:"action,omitempty"`
+	Value  int64  `json:"value"`
+}
+
+type QuotaPolicy struct {
+	Quotas []Quota `json:"quotas"`
+}
+
+func NewQuotaPolicy(jsonValue []byte) (*QuotaPolicy, error) {
+	var quotaPolicy QuotaPolicy
+	if len(jsonValue) == 0 {
+		return &quotaPolicy, nil
+	}
+	err := json.Unmarshal(jsonValue, &quotaPolicy)
+	if err != nil {
+		return nil, NewFDSError(err.Error(), -1)
+	}
+	return &quotaPolicy, nil
+}
+
+// GetQuota 返回指定类型和操作的quota，SPACE和OBJECTS的action为空
+func (p *QuotaPolicy) GetQuota(quotaType, action string) (int64, bool) {
+	for _, q := range p.Quotas {
+		if q.Type == quotaType && q.Action == action {
+			return q.Value, true
+		}
+	}
+	return 0, false
+}
+
+// SetQuota 设置指定类型和操作的quota，已经存在时覆盖
+func (p *QuotaPolicy) SetQuota(quotaType, action string, value int64) {
+	for i, q := range p.Quotas {
+		if q.Type == quotaType && q.Action == action {
+			p.Quotas[i].Value = value
+			return
+		}
+	}
+	p.Quotas = append(p.Quotas, Quota{Type: quotaType, Action: action, Value: value})
+}
+
+// Validate 检查quota的值不能为负，同一类型和操作不能重复，QPS必须指定操作
+func (p *QuotaPolicy) Validate() error {
+	seen := map[string]bool{}
+	for _, q := range p.Quotas {
+		if len(q.Type) == 0 {
+			return NewFDSError("quota type is required", -1)
+		}
+		if q.Value < 0 {
+			return NewFDSError("negative quota value for "+q.Type, -1)
+		}
+		if q.Type == QUOTA_TYPE_QPS && len(q.Action) == 0 {
+			return NewFDSError("QPS quota requires an action", -1)
+		}
+		key := q.Type + ":" + q.Action
+		if seen[key] {
+			return NewFDSError("duplicate quota "+key, -1)
+		}
+		seen[key] = true
+	}
+	return nil
+}
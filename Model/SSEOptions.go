@@ -0,0 +1,88 @@
+package Model
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+)
+
+const (
+	ServerSideEncryption           = "x-xiaomi-server-side-encryption"
+	SSECustomerAlgorithm           = "x-xiaomi-server-side-encryption-customer-algorithm"
+	SSECustomerKey                 = "x-xiaomi-server-side-encryption-customer-key"
+	SSECustomerKeyMD5              = "x-xiaomi-server-side-encryption-customer-key-md5"
+	CopySourceSSECustomerAlgorithm = "x-xiaomi-copy-source-server-side-encryption-customer-algorithm"
+	CopySourceSSECustomerKey       = "x-xiaomi-copy-source-server-side-encryption-customer-key"
+	CopySourceSSECustomerKeyMD5    = "x-xiaomi-copy-source-server-side-encryption-customer-key-md5"
+	SSE_AES256                     = "AES256"
+	SSE_CUSTOMER_KEY_SIZE          = 32
+)
+
+// SSEOptions 服务端加密的参数。CustomerKey为空时使用服务端管理的密钥，
+// 否则使用客户提供的AES-256密钥，服务端不保存该密钥，之后读取object时必须提供相同的密钥
+type SSEOptions struct {
+	Algorithm   string
+	CustomerKey []byte
+}
+
+// NewSSEOptions 使用服务端管理的密钥加密
+func NewSSEOptions() *SSEOptions {
+	return &SSEOptions{Algorithm: SSE_AES256}
+}
+
+// NewSSECustomerOptions 使用客户提供的32字节密钥加密
+func NewSSECustomerOptions(key []byte) (*SSEOptions, error) {
+	sse := &SSEOptions{Algorithm: SSE_AES256, CustomerKey: key}
+	if err := sse.Validate(); err != nil {
+		return nil, err
+	}
+	return sse, nil
+}
+
+func (s *SSEOptions) IsCustomerKey() bool {
+	return len(s.CustomerKey) > 0
+}
+
+func (s *SSEOptions) Validate() error {
+	if s.Algorithm != SSE_AES256 {
+		return NewFDSError("unsupported server side encryption algorithm: "+s.Algorithm, -1)
+	}
+	if s.IsCustomerKey() && len(s.CustomerKey) != SSE_CUSTOMER_KEY_SIZE {
+		return NewFDSError("customer key must be 32 bytes", -1)
+	}
+	return nil
+}
+
+// customerHeaders 返回客户密钥的算法、base64编码的密钥及其md5，使用服务端管理的密钥时为空
+func (s *SSEOptions) customerHeaders(algorithm, key, keyMD5 string) map[string]string {
+	headers := map[string]string{}
+	if s == nil || !s.IsCustomerKey() {
+		return headers
+	}
+	sum := md5.Sum(s.CustomerKey)
+	headers[algorithm] = s.Algorithm
+	headers[key] = base64.StdEncoding.EncodeToString(s.CustomerKey)
+	headers[keyMD5] = base64.StdEncoding.EncodeToString(sum[:])
+	return headers
+}
+
+// Headers 返回写入object(上传、初始化分片上传、复制的目标)时发送的header，s为nil时为空
+func (s *SSEOptions) Headers() map[string]string {
+	if s == nil {
+		return map[string]string{}
+	}
+	if s.IsCustomerKey() {
+		return s.ReadHeaders()
+	}
+	return map[string]string{ServerSideEncryption: s.Algorithm}
+}
+
+// ReadHeaders 返回读取使用客户密钥加密的object以及上传分片时需要的header，
+// 使用服务端管理的密钥或s为nil时为空
+func (s *SSEOptions) ReadHeaders() map[string]string {
+	return s.customerHeaders(SSECustomerAlgorithm, SSECustomerKey, SSECustomerKeyMD5)
+}
+
+// CopySourceHeaders 返回复制使用客户密钥加密的源object时需要的header
+func (s *SSEOptions) CopySourceHeaders() map[string]string {
+	return s.customerHeaders(CopySourceSSECustomerAlgorithm, CopySourceSSECustomerKey, CopySourceSSECustomerKeyMD5)
+}
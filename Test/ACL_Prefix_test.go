@@ -0,0 +1,61 @@
+package Test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/acl"
+)
+
+func Test_ACL_Apply_To_Prefix(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	for _, name := range []string{"p/1", "p/2", "p/3", "p/4", "q/5"} {
+		s.put(BUCKET_NAME+"/"+name, []byte(name), nil)
+	}
+	public := acl.Grant(acl.GROUP, acl.AllUsers, acl.READ)
+	acl.AddObjectGrants(localClient, BUCKET_NAME, "p/1", public)
+	before := s.count("PUT")
+
+	opts := &acl.PrefixOptions{Grants: []Model.AccessControlList{public}, DryRun: true}
+	res, err := acl.ApplyToPrefix(localClient, BUCKET_NAME, "p/", opts)
+	if err != nil || res.Changed != 3 || res.Unchanged != 1 || len(res.Objects) != 4 {
+		t.Fatal(res, err)
+	}
+	if s.count("PUT") != before {
+		t.Error("dry run should not write ACLs")
+	}
+
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "PUT" && strings.HasSuffix(r.URL.Path, "/p/3") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	})
+	progress := 0
+	opts = &acl.PrefixOptions{Grants: []Model.AccessControlList{public}, UpdateCDN: true, Concurrency: 2,
+		Progress: func(done, total int, r acl.ObjectResult) {
+			progress++
+			if done != progress || total != 4 {
+				t.Error(done, total)
+			}
+		}}
+	res, err = acl.ApplyToPrefix(localClient, BUCKET_NAME, "p/", opts)
+	if err == nil || res == nil {
+		t.Fatal("failed object should be reported", err)
+	}
+	if res.Changed != 2 || res.Unchanged != 1 || len(res.Failed) != 1 || res.Failed[0].Key != "p/3" || progress != 4 {
+		t.Fatal(res.Summary(), res.Failed)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/p/2?prefetch") != 1 || s.count("PUT /"+BUCKET_NAME+"/p/1?prefetch") != 0 {
+		t.Error("only changed objects should be prefetched")
+	}
+	if current, _ := localClient.Get_Object_ACL(BUCKET_NAME, "q/5"); len(current.AccessControlLists) != 0 {
+		t.Error("objects outside the prefix should not change")
+	}
+	if _, err := acl.ApplyToPrefix(localClient, BUCKET_NAME, "p/", nil); err == nil {
+		t.Error("no grants should be rejected")
+	}
+}
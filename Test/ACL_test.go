@@ -0,0 +1,114 @@
+package Test
+
+import (
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/acl"
+)
+
+func Test_ACL_Builder(t *testing.T) {
+	built, err := acl.New().Owner("owner").
+		GrantUser("u1", acl.READ, acl.WRITE).
+		GrantGroup(acl.AllUsers, acl.READ).
+		GrantUser("u1", acl.READ).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if built.Owners.Id != "owner" || len(built.AccessControlLists) != 3 {
+		t.Fatal(built)
+	}
+	if _, err := acl.New().GrantUser("u1", "EXECUTE").GrantUser("u2", acl.READ).Build(); err == nil {
+		t.Error("invalid permission should fail Build")
+	}
+
+	cases := []struct {
+		grant Model.AccessControlList
+		valid bool
+	}{
+		{acl.Grant(acl.USER, "u1", acl.FULL_CONTROL), true},
+		{acl.Grant(acl.GROUP, acl.AuthenticatedUsers, acl.READ_OBJECTS), true},
+		{acl.Grant(acl.USER, "", acl.READ), false},
+		{acl.Grant(acl.GROUP, "SOME_GROUP", acl.READ), false},
+		{acl.Grant("ROLE", "u1", acl.READ), false},
+		{acl.Grant(acl.USER, "u1", "read"), false},
+	}
+	for _, c := range cases {
+		if err := acl.ValidateGrant(c.grant); (err == nil) != c.valid {
+			t.Error(c.grant, err)
+		}
+	}
+}
+
+func Test_ACL_Diff(t *testing.T) {
+	from, _ := acl.New().Owner("a").GrantUser("u1", acl.READ).GrantUser("u2", acl.WRITE).Build()
+	to, _ := acl.New().Owner("b").GrantUser("u2", acl.WRITE).GrantGroup(acl.AllUsers, acl.READ).
+		GrantUser("u1", acl.FULL_CONTROL).Build()
+
+	change := acl.Diff(from, to)
+	if len(change.Added) != 2 || len(change.Removed) != 1 || change.Empty() {
+		t.Fatal(change)
+	}
+	// 按type、id、permission排序
+	if change.Added[0].Type != acl.GROUP || change.Added[1].Grantees.Id != "u1" ||
+		change.Removed[0].Permission != acl.READ {
+		t.Error(change)
+	}
+	if !acl.Diff(from, from).Empty() || !acl.Diff(nil, &Model.ACL{}).Empty() {
+		t.Error("same grants should be empty")
+	}
+	if change := acl.Diff(nil, to); len(change.Added) != 3 || len(change.Removed) != 0 {
+		t.Error(change)
+	}
+}
+
+func Test_ACL_Object_Grants(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/o", []byte("o"), nil)
+	read := acl.Grant(acl.USER, "u1", acl.READ)
+	write := acl.Grant(acl.USER, "u1", acl.WRITE)
+
+	added, err := acl.AddObjectGrants(localClient, BUCKET_NAME, "o", read, write)
+	if err != nil || len(added) != 2 {
+		t.Fatal(added, err)
+	}
+	added, err = acl.AddObjectGrants(localClient, BUCKET_NAME, "o", read)
+	if err != nil || len(added) != 0 || s.count("PUT /"+BUCKET_NAME+"/o?acl") != 1 {
+		t.Error("existing grant should not be written again", added, err)
+	}
+	removed, err := acl.RemoveObjectGrants(localClient, BUCKET_NAME, "o", read, acl.Grant(acl.USER, "u2", acl.READ))
+	if err != nil || len(removed) != 1 || removed[0] != read {
+		t.Fatal(removed, err)
+	}
+	current, _ := localClient.Get_Object_ACL(BUCKET_NAME, "o")
+	if len(current.AccessControlLists) != 1 || current.AccessControlLists[0] != write {
+		t.Error(current)
+	}
+	if _, err := acl.AddObjectGrants(localClient, BUCKET_NAME, "o", acl.Grant(acl.GROUP, "u1", acl.READ)); err == nil {
+		t.Error("invalid grant should be rejected")
+	}
+}
+
+func Test_Set_Private(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/o", []byte("o"), nil)
+	if _, err := acl.AddObjectGrants(localClient, BUCKET_NAME, "o", acl.Grant(acl.GROUP, acl.AllUsers, acl.READ)); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := localClient.Get_Object_ACL(BUCKET_NAME, "o"); len(current.AccessControlLists) != 1 {
+		t.Fatal(current)
+	}
+	if ok, err := localClient.Set_Private(BUCKET_NAME, "o", false); !ok || err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := localClient.Get_Object_ACL(BUCKET_NAME, "o"); len(current.AccessControlLists) != 0 {
+		t.Error(current)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/o?refresh") != 1 {
+		t.Error("Set_Private should refresh CDN")
+	}
+	if _, err := localClient.Set_Private(BUCKET_NAME, "o", true); err != nil || s.count("PUT /"+BUCKET_NAME+"/o?refresh") != 1 {
+		t.Error("disable_refresh should skip refresh", err)
+	}
+}
@@ -0,0 +1,120 @@
+package Test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func Test_Append_Object(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	if position, err := localClient.Get_Append_Position(BUCKET_NAME, "log"); err != nil || position != 0 {
+		t.Fatal(position, err)
+	}
+	// object不存在时从0开始追加
+	if _, err := localClient.Append_Object(BUCKET_NAME, "log", 3, []byte("a")); fdsCode(err) != http.StatusConflict {
+		t.Fatal("new object should start at 0", err)
+	}
+	position, err := localClient.Append_Object(BUCKET_NAME, "log", 0, []byte("abc"))
+	if err != nil || position != 3 || string(s.get(BUCKET_NAME+"/log").data) != "abc" {
+		t.Fatal(position, err)
+	}
+	s.mu.Lock()
+	s.objects[BUCKET_NAME+"/log"].meta = map[string]string{Model.ContentType: "text/plain", "x-xiaomi-meta-owner": "me"}
+	s.mu.Unlock()
+
+	for _, stale := range []int64{0, 2, 4} {
+		if _, err := localClient.Append_Object(BUCKET_NAME, "log", stale, []byte("x")); fdsCode(err) != http.StatusConflict {
+			t.Error(stale, "should conflict", err)
+		}
+	}
+	position, err = localClient.Append_Object(BUCKET_NAME, "log", 3, []byte("de"))
+	if err != nil || position != 5 {
+		t.Fatal(position, err)
+	}
+	if position, err = localClient.Append_Object(BUCKET_NAME, "log", 5, nil); err != nil || position != 5 {
+		t.Error("empty append", position, err)
+	}
+	o := s.get(BUCKET_NAME + "/log")
+	if string(o.data) != "abcde" || o.meta[Model.ContentType] != "text/plain" || o.meta["x-xiaomi-meta-owner"] != "me" {
+		t.Error(string(o.data), o.meta)
+	}
+	if position, _ := localClient.Get_Append_Position(BUCKET_NAME, "log"); position != 5 {
+		t.Error(position)
+	}
+}
+
+func Test_Append_Refused(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/gz", []byte("gz"), map[string]string{Model.ContentEncoding: "gzip"})
+	s.put(BUCKET_NAME+"/enc", []byte("enc"), map[string]string{"x-xiaomi-meta-encryption-algorithm": "AES256-GCM-CHUNKED"})
+	for _, name := range []string{"gz", "enc"} {
+		if _, err := localClient.Append_Object(BUCKET_NAME, name, int64(len(name)), []byte("x")); fdsCode(err) != http.StatusBadRequest {
+			t.Error(name, "should be refused", err)
+		}
+		if string(s.get(BUCKET_NAME+"/"+name).data) != name {
+			t.Error(name, "should not be rewritten")
+		}
+	}
+}
+
+func Test_Append_Modified(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/log", []byte("abc"), nil)
+	// 读取已有内容之前object被其它写入者修改
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "GET" && !r.URL.Query().Has("metadata") {
+			o := s.objects[BUCKET_NAME+"/log"]
+			o.modified = o.modified.Add(time.Second)
+		}
+		return false
+	})
+	if _, err := localClient.Append_Object(BUCKET_NAME, "log", 3, []byte("d")); fdsCode(err) != http.StatusConflict {
+		t.Fatal("concurrent modification should be detected", err)
+	}
+	if string(s.get(BUCKET_NAME+"/log").data) != "abc" || s.count("PUT /"+BUCKET_NAME+"/log") != 0 {
+		t.Error("object should not be rewritten")
+	}
+}
+
+func Test_Append_Writer_Retry(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/log", []byte("0"), nil)
+	w, err := localClient.Append_Writer(BUCKET_NAME, "log", 4)
+	if err != nil || w.Position() != 1 {
+		t.Fatal(err)
+	}
+	if n, err := w.Write([]byte("ab")); n != 2 || err != nil || w.Buffered() != 2 || s.count("PUT") != 0 {
+		t.Fatal("small writes should be buffered", n, err)
+	}
+
+	failing := true
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "PUT" && failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	})
+	// 缓冲满时追加失败，p不保留在缓冲中
+	if n, err := w.Write([]byte("cd")); n != 0 || fdsCode(err) != http.StatusServiceUnavailable || w.Buffered() != 2 {
+		t.Fatal(n, err, w.Buffered())
+	}
+	if err := w.Flush(); err == nil || w.Buffered() != 2 || w.Position() != 1 {
+		t.Fatal("failed flush should keep the buffer", err)
+	}
+	s.mu.Lock()
+	failing = false
+	s.mu.Unlock()
+	if err := w.Flush(); err != nil || w.Buffered() != 0 || w.Position() != 3 {
+		t.Fatal(err, w.Buffered(), w.Position())
+	}
+	if n, err := w.Write([]byte("cdef")); n != 4 || err != nil || w.Position() != 7 {
+		t.Fatal(n, err)
+	}
+	if err := w.Close(); err != nil || string(s.get(BUCKET_NAME+"/log").data) != "0abcdef" {
+		t.Error(err, string(s.get(BUCKET_NAME+"/log").data))
+	}
+}
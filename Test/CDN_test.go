@@ -0,0 +1,70 @@
+package Test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+func Test_CDN_Retry(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/a", []byte("a"), nil)
+	s.put(BUCKET_NAME+"/b", []byte("b"), nil)
+	s.mu.Lock()
+	s.failures["a"] = 2
+	s.mu.Unlock()
+
+	start := time.Now()
+	progress := 0
+	result, err := localClient.Prefetch_Objects(BUCKET_NAME, []string{"a", "b"}, &galaxy_fds_sdk_golang.CDNOptions{
+		Backoff: 20 * time.Millisecond, Progress: func(done, total int, item galaxy_fds_sdk_golang.CDNItem) { progress++ }})
+	if err != nil || result.Submitted != 2 || progress != 2 {
+		t.Fatal(result, err)
+	}
+	// 503后按20ms、40ms退避重试
+	if result.Items[0].Attempts != 3 || result.Items[1].Attempts != 1 || time.Since(start) < 60*time.Millisecond {
+		t.Error(result.Items, time.Since(start))
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/a?prefetch") != 3 {
+		t.Error("prefetch requests", s.count("PUT /"+BUCKET_NAME+"/a?prefetch"))
+	}
+
+	// 4xx不重试，Retries小于0时503也不重试
+	s.mu.Lock()
+	s.failures["b"] = 1
+	s.mu.Unlock()
+	result, err = localClient.Refresh_Objects(BUCKET_NAME, []string{"missing", "b"},
+		&galaxy_fds_sdk_golang.CDNOptions{Retries: -1, Backoff: time.Millisecond})
+	if err == nil || len(result.Failed) != 2 || result.Items[0].Attempts != 1 || result.Items[1].Attempts != 1 {
+		t.Fatal(result, err)
+	}
+	result, err = localClient.Refresh_Objects(BUCKET_NAME, []string{"missing"},
+		&galaxy_fds_sdk_golang.CDNOptions{Backoff: time.Millisecond})
+	if err == nil || result.Items[0].Attempts != 1 || s.count("PUT /"+BUCKET_NAME+"/missing?refresh") != 2 {
+		t.Error("404 should not be retried", result.Items)
+	}
+}
+
+func Test_CDN_Requests_Per_Second(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	for i := 0; i < 30; i++ {
+		s.put(fmt.Sprintf("%s/p/%02d", BUCKET_NAME, i), []byte("x"), nil)
+	}
+
+	start := time.Now()
+	result, err := localClient.Refresh_Prefix(BUCKET_NAME, "p/", nil)
+	if err != nil || result.Submitted != 30 || time.Since(start) > 150*time.Millisecond {
+		t.Fatal(result, err, time.Since(start))
+	}
+	// 允许突发20个请求，剩下10个每个需要等待50ms
+	start = time.Now()
+	result, err = localClient.Prefetch_Prefix(BUCKET_NAME, "p/", &galaxy_fds_sdk_golang.CDNOptions{RequestsPerSecond: 20})
+	if err != nil || result.Submitted != 30 {
+		t.Fatal(result, err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Error("RequestsPerSecond should limit submissions", elapsed)
+	}
+}
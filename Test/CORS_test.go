@@ -0,0 +1,127 @@
+package Test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// corsServer 模拟FDS的bucket CORS接口，预检请求(OPTIONS)按照已设置的规则返回
+type corsServer struct {
+	mu      sync.Mutex
+	configs map[string][]byte
+}
+
+func (s *corsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+	if r.Method == "OPTIONS" {
+		config, _ := Model.NewCORSConfig(s.configs[bucket])
+		origin := r.Header.Get("Origin")
+		var requestHeaders []string
+		if h := r.Header.Get("Access-Control-Request-Headers"); len(h) > 0 {
+			requestHeaders = strings.Split(h, ",")
+		}
+		rule, ok := config.Evaluate(origin, r.Header.Get("Access-Control-Request-Method"), requestHeaders)
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		for k, v := range rule.ResponseHeaders(origin, requestHeaders) {
+			w.Header().Set(k, v)
+		}
+		return
+	}
+
+	if _, err := galaxy_fds_sdk_golang.VerifyRequest(lookupSecret, r, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if _, ok := r.URL.Query()["cors"]; !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		w.Write(s.configs[bucket])
+	case "PUT":
+		s.configs[bucket], _ = ioutil.ReadAll(r.Body)
+	case "DELETE":
+		delete(s.configs, bucket)
+	}
+}
+
+func preflight(t *testing.T, u, origin, method, headers string) *http.Response {
+	req, _ := http.NewRequest("OPTIONS", u, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if len(headers) > 0 {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	return res
+}
+
+func Test_Bucket_CORS(t *testing.T) {
+	ts := httptest.NewServer(&corsServer{configs: map[string][]byte{}})
+	defer ts.Close()
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME,
+		strings.TrimPrefix(ts.URL, "http://"), false, false)
+
+	config := Model.CORSConfig{Rules: []Model.CORSRule{{
+		Id:             "web",
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{"GET", "PUT"},
+		AllowedHeaders: []string{"content-type", "x-xiaomi-meta-*"},
+		ExposeHeaders:  []string{"etag"},
+		MaxAgeSeconds:  600,
+	}}}
+	if _, err := localClient.Set_Bucket_CORS(BUCKET_NAME, config); err != nil {
+		t.Fatal("Fail to set bucket cors", err)
+	}
+	got, err := localClient.Get_Bucket_CORS(BUCKET_NAME)
+	if err != nil || len(got.Rules) != 1 || got.Rules[0].MaxAgeSeconds != 600 {
+		t.Fatal("Fail to get bucket cors", err, got)
+	}
+
+	objectUrl := ts.URL + "/" + BUCKET_NAME + "/a.png"
+	res := preflight(t, objectUrl, "https://app.example.com", "PUT", "Content-Type, X-Xiaomi-Meta-Owner")
+	if res.StatusCode != http.StatusOK || res.Header.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		res.Header.Get("Access-Control-Max-Age") != "600" {
+		t.Error("preflight should be allowed", res.Status, res.Header)
+	}
+	cases := map[string][3]string{
+		"origin": {"https://evil.com", "GET", ""},
+		"method": {"https://app.example.com", "DELETE", ""},
+		"header": {"https://app.example.com", "PUT", "authorization"},
+	}
+	for name, c := range cases {
+		if res := preflight(t, objectUrl, c[0], c[1], c[2]); res.StatusCode != http.StatusForbidden {
+			t.Error(name+": preflight should be rejected", res.Status)
+		}
+	}
+
+	invalid := Model.CORSConfig{Rules: []Model.CORSRule{{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"PATCH"}}}}
+	if _, err := localClient.Set_Bucket_CORS(BUCKET_NAME, invalid); err == nil {
+		t.Error("invalid method should be rejected before sending")
+	}
+
+	if _, err := localClient.Delete_Bucket_CORS(BUCKET_NAME); err != nil {
+		t.Fatal("Fail to delete bucket cors", err)
+	}
+	if res := preflight(t, objectUrl, "https://app.example.com", "GET", ""); res.StatusCode != http.StatusForbidden {
+		t.Error("preflight should be rejected without rules", res.Status)
+	}
+}
@@ -0,0 +1,269 @@
+package Test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdsfs"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdshttp"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/zstd"
+)
+
+func gunzip(t *testing.T, data []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func Test_Compress_Round_Trip(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	data := bytes.Repeat([]byte(`{"level":"info","msg":"hello"}`+"\n"), 100)
+	meta := Model.NewObjectMetadata().SetContentType("application/json")
+	if _, err := localClient.Put_Object_Compressed(BUCKET_NAME, "log.json", data, galaxy_fds_sdk_golang.COMPRESSION_GZIP, meta); err != nil {
+		t.Fatal(err)
+	}
+	stored := s.get(BUCKET_NAME + "/log.json")
+	if len(stored.data) >= len(data) || !bytes.Equal(gunzip(t, stored.data), data) ||
+		stored.meta[Model.ContentEncoding] != "gzip" || stored.meta[Model.ContentType] != "application/json" {
+		t.Fatal(len(stored.data), stored.meta)
+	}
+	fdsMeta, _ := localClient.Get_Object_Meta(BUCKET_NAME, "log.json")
+	if n, err := fdsMeta.GetUncompressedLength(); err != nil || n != int64(len(data)) {
+		t.Error("uncompressed length", n, err)
+	}
+
+	object, err := localClient.Get_Object_Decompressed(BUCKET_NAME, "log.json")
+	if err != nil || !bytes.Equal(object.ObjectContent, data) {
+		t.Fatal(err)
+	}
+	reader, err := localClient.Get_Object_Reader_Decompressed(BUCKET_NAME, "log.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(reader)
+	reader.Close()
+	if !bytes.Equal(got, data) {
+		t.Error("reader should decompress")
+	}
+	// 其它方法返回服务端保存的内容，只按次解压
+	object, err = localClient.Get_Object(BUCKET_NAME, "log.json", 0, -1)
+	if err != nil || !bytes.Equal(object.ObjectContent, stored.data) {
+		t.Error("Get_Object should not decompress", err)
+	}
+
+	dir := t.TempDir()
+	md5sum, err := localClient.Download_Object_Decompressed(BUCKET_NAME, "log.json", filepath.Join(dir, "plain"))
+	if err != nil || *md5sum != fmt.Sprintf("%x", md5.Sum(data)) {
+		t.Fatal("md5 should match the decompressed file", err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, "plain")); !bytes.Equal(b, data) {
+		t.Error("download should decompress")
+	}
+	md5sum, err = localClient.Download_Object(BUCKET_NAME, "log.json", filepath.Join(dir, "raw"))
+	if err != nil || *md5sum != fmt.Sprintf("%x", md5.Sum(stored.data)) {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, "raw")); !bytes.Equal(b, stored.data) {
+		t.Error("Download_Object should not decompress")
+	}
+
+	if _, err := localClient.Put_Object_Compressed(BUCKET_NAME, "o", data, "br", nil); err == nil {
+		t.Error("unregistered encoding should be rejected")
+	}
+}
+
+type deflateCodec struct{}
+
+func (deflateCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.BestSpeed)
+}
+
+func (deflateCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+func Test_Compress_Stream(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	galaxy_fds_sdk_golang.RegisterCompressionCodec("Deflate", deflateCodec{})
+	data := bytes.Repeat([]byte("stream "), 1000)
+
+	// 长度未知时不记录原始长度
+	if err := localClient.Put_Reader_Compressed(BUCKET_NAME, "s", bytes.NewReader(data), -1, "deflate", nil); err != nil {
+		t.Fatal(err)
+	}
+	if m := s.get(BUCKET_NAME + "/s").meta; m[Model.ContentEncoding] != "deflate" || len(m[Model.UncompressedLength]) > 0 {
+		t.Error(m)
+	}
+	object, err := localClient.Get_Object_Decompressed(BUCKET_NAME, "s")
+	if err != nil || !bytes.Equal(object.ObjectContent, data) {
+		t.Fatal(err)
+	}
+
+	// 压缩后超过分片上限时使用分片上传，随机内容基本无法压缩
+	big := make([]byte, galaxy_fds_sdk_golang.MULTIPART_UPLOAD_THRESHOLD+1024)
+	rand.New(rand.NewSource(1)).Read(big)
+	err = localClient.Put_Reader_Compressed(BUCKET_NAME, "big", bytes.NewReader(big), int64(len(big)),
+		galaxy_fds_sdk_golang.COMPRESSION_GZIP, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/big?partNumber") != 2 {
+		t.Error("multipart parts", s.count("PUT /"+BUCKET_NAME+"/big?partNumber"))
+	}
+	stored := s.get(BUCKET_NAME + "/big")
+	if stored.meta[Model.UncompressedLength] != fmt.Sprint(len(big)) || stored.meta[Model.ContentEncoding] != "gzip" {
+		t.Error(stored.meta)
+	}
+	reader, err := localClient.Get_Object_Reader_Decompressed(BUCKET_NAME, "big")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	got, err := ioutil.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, big) {
+		t.Error("multipart round trip", len(got), err)
+	}
+}
+
+func unzstd(t *testing.T, data []byte) []byte {
+	r, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func zstdLogLines() []byte {
+	var b strings.Builder
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, `{"level": "%s", "msg": "request served", "path": "/bucket/object-%d", "status": %d}`+"\n",
+			[]string{"info", "warn", "error"}[i%3], i*7%13, []int{200, 404, 500}[i%3])
+	}
+	return []byte(b.String())
+}
+
+func Test_Compress_Zstd(t *testing.T) {
+	// zstd -19生成的frame，字面量使用Huffman编码，sequence使用压缩的FSE表
+	fixture, _ := hex.DecodeString("28b52ffd64860c5d060022081b1950770e50561a1133d359dfcccce4cc449a0c57918c4c9be1" +
+		"0406bff8e59e4818f9b106bff8e59e4818f9b106bff8e59e4818293eb1395bae3caeb974c0b7cb508974ab4ba52bd4a627061b6a53" +
+		"cdc60c515d32a7b9d91456da9bd613a2568441c49e4b8a508955735d452f31a821ac4c54be863500353148ab011160841501238c08" +
+		"ff3f25f40333d30c7b2ca4cc90a86090a4592a19594484938c82499046417940b4c8110a42a5350749045342c21b488591d246b4ea" +
+		"d3be79da64bddf3f5463873f3f5951151db6263e")
+	if got := unzstd(t, fixture); !bytes.Equal(got, zstdLogLines()) {
+		t.Fatalf("fixture decoded to %q", got)
+	}
+	fixture[len(fixture)-1] ^= 1
+	r, _ := zstd.NewReader(bytes.NewReader(fixture))
+	if _, err := ioutil.ReadAll(r); !errors.Is(err, zstd.ErrChecksum) {
+		t.Error("checksum mismatch should be reported", err)
+	}
+
+	random := make([]byte, 300000)
+	rand.New(rand.NewSource(1)).Read(random)
+	mixed := append(bytes.Repeat(zstdLogLines(), 80), random[:1000]...)
+	for _, data := range [][]byte{nil, []byte("x"), bytes.Repeat([]byte{7}, 200000), zstdLogLines(), mixed, random} {
+		var buf bytes.Buffer
+		w := zstd.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := unzstd(t, buf.Bytes()); !bytes.Equal(got, data) {
+			t.Fatal("round trip", len(data), len(got))
+		}
+		if len(data) > 1000 && len(data) != len(random) && buf.Len() > len(data)/4 {
+			t.Error("compressible data", len(data), buf.Len())
+		}
+	}
+
+	s, localClient := newFDSServer(t)
+	data := bytes.Repeat(zstdLogLines(), 10)
+	if _, err := localClient.Put_Object_Compressed(BUCKET_NAME, "log.zst", data, galaxy_fds_sdk_golang.COMPRESSION_ZSTD, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored := s.get(BUCKET_NAME + "/log.zst")
+	if stored.meta[Model.ContentEncoding] != "zstd" || !bytes.Equal(unzstd(t, stored.data), data) {
+		t.Fatal(stored.meta)
+	}
+	object, err := localClient.Get_Object_Decompressed(BUCKET_NAME, "log.zst")
+	if err != nil || !bytes.Equal(object.ObjectContent, data) {
+		t.Fatal(err)
+	}
+}
+
+func Test_Compress_Auto_Decompress(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	data := bytes.Repeat([]byte("auto decompress "), 100)
+	if _, err := localClient.Put_Object_Compressed(BUCKET_NAME, "www/a.txt", data, galaxy_fds_sdk_golang.COMPRESSION_ZSTD, nil); err != nil {
+		t.Fatal(err)
+	}
+	stored := s.get(BUCKET_NAME + "/www/a.txt").data
+	localClient.AutoDecompress = true
+
+	object, err := localClient.Get_Object(BUCKET_NAME, "www/a.txt", 0, -1)
+	if err != nil || !bytes.Equal(object.ObjectContent, data) {
+		t.Fatal("Get_Object should decompress the whole object", err)
+	}
+	reader, err := localClient.Get_Object_Reader(BUCKET_NAME, "www/a.txt", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(*reader)
+	(*reader).Close()
+	if !bytes.Equal(got, data) {
+		t.Error("Get_Object_Reader should decompress the whole object")
+	}
+	// 读取一部分时返回压缩后的内容
+	object, err = localClient.Get_Object(BUCKET_NAME, "www/a.txt", 4, 8)
+	if err != nil || !bytes.Equal(object.ObjectContent, stored[4:12]) {
+		t.Error("range reads should not decompress", err)
+	}
+	md5sum, err := localClient.Download_Object(BUCKET_NAME, "www/a.txt", filepath.Join(t.TempDir(), "a"))
+	if err != nil || *md5sum != fmt.Sprintf("%x", md5.Sum(data)) {
+		t.Error("Download_Object should decompress", err)
+	}
+
+	// 依赖服务端长度和校验值的读取不受影响
+	raw, _, err := localClient.Get_Object_Reader_With_Metadata(BUCKET_NAME, "www/a.txt", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = ioutil.ReadAll(raw)
+	raw.Close()
+	if !bytes.Equal(got, stored) {
+		t.Error("Get_Object_Reader_With_Metadata should return stored bytes")
+	}
+	if b, err := fdsfs.New(localClient, BUCKET_NAME, "www").ReadFile("a.txt"); err != nil || !bytes.Equal(b, stored) {
+		t.Error("fdsfs should return stored bytes", err)
+	}
+	rec := serveFDSHTTP(fdshttp.New(localClient, BUCKET_NAME, "www"), "GET", "/a.txt", nil)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), stored) {
+		t.Error("fdshttp should serve stored bytes", rec.Code)
+	}
+}
@@ -0,0 +1,275 @@
+package Test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdscrypto"
+)
+
+const cryptoChunk = 16
+
+func newCryptoClient(t *testing.T, client *galaxy_fds_sdk_golang.FDSClient, key byte, chunkSize int) *fdscrypto.Client {
+	provider, err := fdscrypto.NewLocalKeyProvider(bytes.Repeat([]byte{key}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := fdscrypto.New(client, fdscrypto.Options{KeyProvider: provider, ChunkSize: chunkSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func fdsCode(err error) int {
+	var fdsErr *Model.FDSError
+	if errors.As(err, &fdsErr) {
+		return fdsErr.Code()
+	}
+	return 0
+}
+
+func Test_Crypto_Round_Trip(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, cryptoChunk)
+	for _, n := range []int{0, 1, cryptoChunk - 1, cryptoChunk, 3 * cryptoChunk, 3*cryptoChunk + 1} {
+		data := randomBytes(n)
+		if _, err := c.Put_Object(BUCKET_NAME, "o", data, nil); err != nil {
+			t.Fatal(n, err)
+		}
+		stored := s.get(BUCKET_NAME + "/o")
+		if int64(len(stored.data)) != fdscrypto.EncryptedSize(int64(n), cryptoChunk) ||
+			stored.meta[fdscrypto.META_ALGORITHM] != fdscrypto.ALGORITHM {
+			t.Fatal(n, len(stored.data), stored.meta)
+		}
+		// 太短的内容可能碰巧出现在密文中
+		if n >= 16 && bytes.Contains(stored.data, data) {
+			t.Fatal("content should be encrypted")
+		}
+		object, err := c.Get_Object(BUCKET_NAME, "o", 0, -1)
+		if err != nil || !bytes.Equal(object.ObjectContent, data) {
+			t.Fatal(n, err)
+		}
+		if size, err := c.Get_Object_Size(BUCKET_NAME, "o"); err != nil || size != int64(n) {
+			t.Error(n, size, err)
+		}
+
+		// 流式上传的结果与Put_Object相同，长度未知时也能正确判断最后一块
+		if err := c.Put_Reader(BUCKET_NAME, "r", bytes.NewReader(data), -1, nil); err != nil {
+			t.Fatal(n, err)
+		}
+		object, err = c.Get_Object(BUCKET_NAME, "r", 0, -1)
+		if err != nil || !bytes.Equal(object.ObjectContent, data) {
+			t.Fatal("stream", n, err)
+		}
+	}
+
+	if _, err := localClient.Put_Object(BUCKET_NAME, "plain", []byte("x"), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get_Object(BUCKET_NAME, "plain", 0, -1); err == nil {
+		t.Error("plain object should be rejected")
+	}
+}
+
+func Test_Crypto_Range(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, cryptoChunk)
+	data := randomBytes(4*cryptoChunk + 5)
+	if _, err := c.Put_Object(BUCKET_NAME, "o", data, nil); err != nil {
+		t.Fatal(err)
+	}
+	var ranges []string
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "GET" && !r.URL.Query().Has("metadata") {
+			ranges = append(ranges, r.Header.Get("range"))
+		}
+		return false
+	})
+
+	cases := []struct {
+		position, size int64
+		encRange       string
+	}{
+		{0, 1, "bytes=0-31"},
+		{cryptoChunk - 1, 2, "bytes=0-63"},
+		{cryptoChunk, cryptoChunk, "bytes=32-63"},
+		{cryptoChunk + 3, 2*cryptoChunk + 4, "bytes=32-127"},
+		{4*cryptoChunk + 1, -1, "bytes=128-148"},
+		{5, -1, "bytes=0-148"},
+	}
+	for _, tc := range cases {
+		ranges = nil
+		object, err := c.Get_Object(BUCKET_NAME, "o", tc.position, tc.size)
+		end := int64(len(data))
+		if tc.size >= 0 {
+			end = tc.position + tc.size
+		}
+		if err != nil || !bytes.Equal(object.ObjectContent, data[tc.position:end]) {
+			t.Fatal(tc, err)
+		}
+		// 只下载覆盖该范围的加密块
+		if len(ranges) != 1 || ranges[0] != tc.encRange {
+			t.Error(tc, ranges)
+		}
+	}
+	if object, err := c.Get_Object(BUCKET_NAME, "o", int64(len(data)), -1); err != nil || len(object.ObjectContent) != 0 {
+		t.Error("read at the end", err)
+	}
+	if _, err := c.Get_Object(BUCKET_NAME, "o", int64(len(data))+1, -1); err == nil {
+		t.Error("position after the end should be rejected")
+	}
+}
+
+func Test_Crypto_Overwritten(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, cryptoChunk)
+	if _, err := c.Put_Object(BUCKET_NAME, "o", randomBytes(3*cryptoChunk), nil); err != nil {
+		t.Fatal(err)
+	}
+	replacement := randomBytes(2 * cryptoChunk)
+	if _, err := c.Put_Object(BUCKET_NAME, "new", replacement, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 获取metadata之后、下载密文之前object被覆盖
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if requestLine(r) == "GET /"+BUCKET_NAME+"/o" {
+			o := *s.objects[BUCKET_NAME+"/new"]
+			o.modified = o.modified.Add(time.Second)
+			s.objects[BUCKET_NAME+"/o"] = &o
+			s.hook = nil
+		}
+		return false
+	})
+	if _, err := c.Get_Object(BUCKET_NAME, "o", 0, -1); fdsCode(err) != http.StatusConflict {
+		t.Fatal("overwrite should be detected", err)
+	}
+	if object, err := c.Get_Object(BUCKET_NAME, "o", 0, -1); err != nil || !bytes.Equal(object.ObjectContent, replacement) {
+		t.Error(err)
+	}
+}
+
+func Test_Crypto_Tamper(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, cryptoChunk)
+	data := randomBytes(3*cryptoChunk + 4)
+	enc := cryptoChunk + 16
+	cases := []struct {
+		name   string
+		modify func(b []byte) []byte
+	}{
+		{"flipped byte", func(b []byte) []byte { b[enc+3] ^= 1; return b }},
+		// 去掉最后不完整的块后剩下的都是完整块，最后一块的标记不匹配
+		{"truncated", func(b []byte) []byte { return b[:3*enc] }},
+		{"reordered", func(b []byte) []byte {
+			out := append([]byte{}, b[enc:2*enc]...)
+			out = append(out, b[:enc]...)
+			return append(out, b[2*enc:]...)
+		}},
+		{"truncated tag", func(b []byte) []byte { return b[:len(b)-1] }},
+	}
+	for _, tc := range cases {
+		if _, err := c.Put_Object(BUCKET_NAME, "o", data, nil); err != nil {
+			t.Fatal(err)
+		}
+		s.mu.Lock()
+		o := s.objects[BUCKET_NAME+"/o"]
+		o.data = tc.modify(o.data)
+		s.mu.Unlock()
+		if object, err := c.Get_Object(BUCKET_NAME, "o", 0, -1); err == nil {
+			t.Error(tc.name, "should fail to decrypt", len(object.ObjectContent))
+		}
+	}
+
+	// 空object只有一个认证标签，也会校验
+	if _, err := c.Put_Object(BUCKET_NAME, "empty", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.objects[BUCKET_NAME+"/empty"].data[0] ^= 1
+	s.mu.Unlock()
+	if _, err := c.Get_Object(BUCKET_NAME, "empty", 0, -1); err == nil {
+		t.Error("tampered empty object should fail")
+	}
+}
+
+func Test_Crypto_Keys(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, 0)
+	if _, err := c.Put_Object(BUCKET_NAME, "o", []byte("secret"), nil); err != nil {
+		t.Fatal(err)
+	}
+	other := newCryptoClient(t, localClient, 2, 0)
+	if _, err := other.Get_Object(BUCKET_NAME, "o", 0, -1); err == nil {
+		t.Error("another key should be rejected")
+	}
+
+	s.mu.Lock()
+	meta := s.objects[BUCKET_NAME+"/o"].meta
+	keyId := meta[fdscrypto.META_KEY_ID]
+	meta[fdscrypto.META_KEY_ID] = "0000000000000000"
+	s.mu.Unlock()
+	if _, err := c.Get_Object(BUCKET_NAME, "o", 0, -1); err == nil {
+		t.Error("unknown key id should be rejected")
+	}
+	s.mu.Lock()
+	meta[fdscrypto.META_KEY_ID] = keyId
+	wrapped := []byte(meta[fdscrypto.META_WRAPPED_KEY])
+	wrapped[len(wrapped)/2] ^= 1
+	meta[fdscrypto.META_WRAPPED_KEY] = string(wrapped)
+	s.mu.Unlock()
+	if _, err := c.Get_Object(BUCKET_NAME, "o", 0, -1); err == nil {
+		t.Error("tampered data key should be rejected")
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	ioutil.WriteFile(keyFile, []byte("AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=\n"), 0600)
+	fromFile, err := fdscrypto.NewLocalKeyProviderFromFile(keyFile)
+	local, _ := fdscrypto.NewLocalKeyProvider(bytes.Repeat([]byte{1}, 32))
+	if err != nil || fromFile.KeyId() != local.KeyId() {
+		t.Error("base64 key file", err)
+	}
+	if _, err := fdscrypto.NewLocalKeyProvider([]byte("short")); err == nil {
+		t.Error("short key should be rejected")
+	}
+	if _, err := fdscrypto.New(localClient, fdscrypto.Options{}); err == nil {
+		t.Error("key provider is required")
+	}
+}
+
+func Test_Crypto_Multipart(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	c := newCryptoClient(t, localClient, 1, 0)
+	data := randomBytes(int(galaxy_fds_sdk_golang.MULTIPART_UPLOAD_THRESHOLD) + 100)
+	if err := c.Put_Reader(BUCKET_NAME, "big", bytes.NewReader(data), int64(len(data)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/big?partNumber") != 2 {
+		t.Fatal("should use multipart upload", s.count("PUT /"+BUCKET_NAME+"/big?partNumber"))
+	}
+	if size, err := c.Get_Object_Size(BUCKET_NAME, "big"); err != nil || size != int64(len(data)) {
+		t.Fatal(size, err)
+	}
+	reader, err := c.Get_Object_Reader(BUCKET_NAME, "big", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	got, err := ioutil.ReadAll(reader)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Error("multipart round trip", len(got), err)
+	}
+	// 跨越分片边界读取
+	position := galaxy_fds_sdk_golang.MULTIPART_UPLOAD_PART_SIZE - 10
+	object, err := c.Get_Object(BUCKET_NAME, "big", position, 20)
+	if err != nil || !bytes.Equal(object.ObjectContent, data[position:position+20]) {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,103 @@
+package Test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+func putObjects(s *fdsServer, prefix string, n int) []string {
+	keys := []string{}
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("%s%04d", prefix, i)
+		s.put(BUCKET_NAME+"/"+key, []byte("x"), nil)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func Test_Bulk_Delete_Retry(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	keys := putObjects(s, "p/", 5)
+	s.mu.Lock()
+	s.failures["p/0001"] = 1
+	s.failures["p/0003"] = 10
+	s.mu.Unlock()
+
+	progress := 0
+	result, err := localClient.Bulk_Delete_Objects(BUCKET_NAME, keys, &galaxy_fds_sdk_golang.BulkDeleteOptions{
+		BatchSize: 2, Progress: func(deleted, failed, total int) { progress++ }})
+	if err == nil || len(result.Failed) != 1 || result.Failed[0].Key != "p/0003" || result.Failed[0].Code != 503 {
+		t.Fatal(result, err)
+	}
+	// 3个批次，之后两个失败的object重试一次，p/0003再重试一次
+	if len(result.Deleted) != 4 || result.Requests != 5 || progress != 5 {
+		t.Error(result.Summary(), progress)
+	}
+	if s.get(BUCKET_NAME+"/p/0001") != nil || s.get(BUCKET_NAME+"/p/0003") == nil {
+		t.Error("p/0001 should be deleted on retry")
+	}
+
+	s.put(BUCKET_NAME+"/q", []byte("x"), nil)
+	s.mu.Lock()
+	s.failures["q"] = 1
+	s.mu.Unlock()
+	result, err = localClient.Bulk_Delete_Objects(BUCKET_NAME, []string{"q"}, &galaxy_fds_sdk_golang.BulkDeleteOptions{Retries: -1})
+	if err == nil || len(result.Failed) != 1 || result.Requests != 1 {
+		t.Error("Retries < 0 should not retry", result, err)
+	}
+}
+
+func Test_Bulk_Delete_Guards(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	keys := putObjects(s, "p/", 3)
+
+	if _, err := localClient.Bulk_Delete_Prefix(BUCKET_NAME, "", nil); err == nil {
+		t.Error("empty prefix should be refused")
+	}
+	opts := &galaxy_fds_sdk_golang.BulkDeleteOptions{MaxObjects: 2}
+	if _, err := localClient.Bulk_Delete_Prefix(BUCKET_NAME, "p/", opts); err == nil {
+		t.Error("MaxObjects should be enforced while listing")
+	}
+	if _, err := localClient.Bulk_Delete_Objects(BUCKET_NAME, keys, opts); err == nil {
+		t.Error("MaxObjects should be enforced")
+	}
+	if s.count("PUT /"+BUCKET_NAME+"?deleteObjects") != 0 || s.get(BUCKET_NAME+"/p/0000") == nil {
+		t.Fatal("nothing should be deleted when a guard fails")
+	}
+
+	result, err := localClient.Bulk_Delete_Prefix(BUCKET_NAME, "",
+		&galaxy_fds_sdk_golang.BulkDeleteOptions{AllowEmptyPrefix: true, DryRun: true})
+	if err != nil || len(result.Deleted) != 3 || result.Requests != 0 {
+		t.Fatal(result, err)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"?deleteObjects") != 0 || s.get(BUCKET_NAME+"/p/0002") == nil {
+		t.Error("DryRun should not delete")
+	}
+	if result, err := localClient.Bulk_Delete_Prefix(BUCKET_NAME, "p/", nil); err != nil || len(result.Deleted) != 3 {
+		t.Error(result, err)
+	}
+}
+
+func Test_Delete_Objects_Batches(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	n := galaxy_fds_sdk_golang.DELETE_OBJECTS_BATCH_SIZE + 1
+	keys := putObjects(s, "p/", n)
+	if err := localClient.Delete_Objects(BUCKET_NAME, keys); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.count("PUT /" + BUCKET_NAME + "?deleteObjects"); c != 2 || s.get(BUCKET_NAME+"/p/1000") != nil {
+		t.Error("oversized list should be split", c)
+	}
+
+	putObjects(s, "q/", n)
+	s.put(BUCKET_NAME+"/r", []byte("x"), nil)
+	if err := localClient.Delete_Objects_With_Prefix(BUCKET_NAME, "q/"); err != nil {
+		t.Fatal(err)
+	}
+	if c := s.count("PUT /" + BUCKET_NAME + "?deleteObjects"); c != 4 || s.get(BUCKET_NAME+"/q/1000") != nil ||
+		s.get(BUCKET_NAME+"/r") == nil {
+		t.Error("should delete one page per request", c)
+	}
+}
@@ -0,0 +1,253 @@
+package Test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdscache"
+)
+
+func readCached(t *testing.T, cache *fdscache.Cache, object string) string {
+	o, err := cache.Get_Object(BUCKET_NAME, object, 0, -1)
+	if err != nil {
+		t.Fatal(object, err)
+	}
+	return string(o.ObjectContent)
+}
+
+func Test_FDSCache_Singleflight(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/a", []byte("hello"), nil)
+	s.delay = 20 * time.Millisecond
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if o, err := cache.Get_Object(BUCKET_NAME, "a", 0, -1); err != nil || string(o.ObjectContent) != "hello" {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := s.countExact("GET /" + BUCKET_NAME + "/a"); n != 1 {
+		t.Error("content requests", n)
+	}
+	if n := s.countExact("GET /" + BUCKET_NAME + "/a?metadata"); n != 1 {
+		t.Error("metadata requests", n)
+	}
+}
+
+func Test_FDSCache_LRU(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	for _, name := range []string{"a", "b", "c"} {
+		s.put(BUCKET_NAME+"/"+name, []byte(strings.Repeat(name, 4)), nil)
+	}
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: t.TempDir(), MaxBytes: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "a", "c"} {
+		readCached(t, cache, name)
+		time.Sleep(time.Millisecond)
+	}
+	if cache.Size() != 8 {
+		t.Error(cache.Size())
+	}
+	// b最久没有访问，被淘汰
+	readCached(t, cache, "a")
+	readCached(t, cache, "c")
+	readCached(t, cache, "b")
+	for name, want := range map[string]int{"a": 1, "b": 2, "c": 1} {
+		if n := s.countExact("GET /" + BUCKET_NAME + "/" + name); n != want {
+			t.Error(name, n, want)
+		}
+	}
+}
+
+func Test_FDSCache_Too_Large(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/big", []byte(strings.Repeat("x", 20)), nil)
+	s.put(BUCKET_NAME+"/a", []byte("small"), nil)
+	dir := t.TempDir()
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: dir, MaxBytes: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readCached(t, cache, "a")
+	for i := 0; i < 2; i++ {
+		o, err := cache.Get_Object(BUCKET_NAME, "big", 5, 3)
+		if err != nil || string(o.ObjectContent) != "xxx" {
+			t.Fatal(err)
+		}
+	}
+	// 超过上限的object直接从FDS读取，不淘汰已有的缓存
+	if cache.Size() != 5 || s.countExact("GET /"+BUCKET_NAME+"/big") != 2 {
+		t.Error(cache.Size(), s.countExact("GET /"+BUCKET_NAME+"/big"))
+	}
+	if files, _ := os.ReadDir(filepath.Join(dir, fdscache.DATA_DIR)); len(files) != 1 {
+		t.Error(len(files))
+	}
+
+	// 已缓存的object被覆盖为超过上限的内容时删除缓存
+	s.put(BUCKET_NAME+"/a", []byte(strings.Repeat("y", 20)), nil)
+	cache, err = fdscache.New(localClient, fdscache.Options{Dir: dir, MaxBytes: 10, TTL: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readCached(t, cache, "a") != strings.Repeat("y", 20) || cache.Size() != 0 {
+		t.Error(cache.Size())
+	}
+}
+
+func Test_FDSCache_LRU_Reload(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	for _, name := range []string{"a", "b", "c"} {
+		s.put(BUCKET_NAME+"/"+name, []byte(strings.Repeat(name, 4)), nil)
+	}
+	dir := t.TempDir()
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: dir, MaxBytes: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "a"} {
+		readCached(t, cache, name)
+		time.Sleep(time.Millisecond)
+	}
+	if err := cache.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启后仍然淘汰最久没有访问的b
+	cache, err = fdscache.New(localClient, fdscache.Options{Dir: dir, MaxBytes: 10, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readCached(t, cache, "c")
+	readCached(t, cache, "a")
+	readCached(t, cache, "b")
+	for name, want := range map[string]int{"a": 1, "b": 2, "c": 1} {
+		if n := s.countExact("GET /" + BUCKET_NAME + "/" + name); n != want {
+			t.Error(name, n, want)
+		}
+	}
+}
+
+func Test_FDSCache_Index(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/a", []byte("hello"), nil)
+	s.put(BUCKET_NAME+"/b", []byte("world"), nil)
+	dir := t.TempDir()
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: dir, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readCached(t, cache, "a")
+	readCached(t, cache, "b")
+	stray := filepath.Join(dir, fdscache.DATA_DIR, "stray")
+	if err := os.WriteFile(stray, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err = fdscache.New(localClient, fdscache.Options{Dir: dir, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Size() != 10 || readCached(t, cache, "a") != "hello" || readCached(t, cache, "b") != "world" {
+		t.Error(cache.Size())
+	}
+	if n := s.count("GET /" + BUCKET_NAME + "/a"); n != 2 {
+		t.Error("cached object should not be fetched after reload", n)
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Error("files not referenced by the index should be removed")
+	}
+
+	// 索引损坏时当作空缓存
+	if err := os.WriteFile(filepath.Join(dir, fdscache.INDEX_FILE), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = fdscache.New(localClient, fdscache.Options{Dir: dir, TTL: time.Hour})
+	if err != nil || cache.Size() != 0 {
+		t.Fatal(err, cache.Size())
+	}
+	if files, _ := os.ReadDir(filepath.Join(dir, fdscache.DATA_DIR)); len(files) != 0 {
+		t.Error(len(files))
+	}
+}
+
+func Test_FDSCache_Validators_From_Response(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/a", []byte("old"), nil)
+	// 获取metadata之后、下载之前object被修改
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if requestLine(r) == "GET /"+BUCKET_NAME+"/a?metadata" {
+			s.store(BUCKET_NAME+"/a", &fdsObject{data: []byte("new"), meta: map[string]string{}})
+			s.hook = nil
+		}
+		return false
+	})
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: t.TempDir(), TTL: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := cache.Get_Object(BUCKET_NAME, "a", 0, -1)
+	if err != nil || string(o.ObjectContent) != "new" {
+		t.Fatal(err)
+	}
+	if md5sum, _ := o.Metadata.GetContentMD5(); md5sum != fmt.Sprintf("%x", md5.Sum([]byte("new"))) {
+		t.Error("content-md5 should match the cached content", md5sum)
+	}
+	if readCached(t, cache, "a") != "new" {
+		t.Error()
+	}
+	if n := s.countExact("GET /" + BUCKET_NAME + "/a"); n != 1 {
+		t.Error("revalidation should not download again", n)
+	}
+}
+
+func Test_FDSCache_Stale(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/a", []byte("hello"), nil)
+	cache, err := fdscache.New(localClient, fdscache.Options{Dir: t.TempDir(), TTL: time.Nanosecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readCached(t, cache, "a")
+
+	status := http.StatusServiceUnavailable
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		w.WriteHeader(status)
+		return true
+	})
+	if readCached(t, cache, "a") != "hello" {
+		t.Error("stale copy should be served when revalidation fails")
+	}
+	if _, err := cache.Get_Object(BUCKET_NAME, "b", 0, -1); err == nil {
+		t.Error("object not in cache should fail")
+	}
+
+	status = http.StatusForbidden
+	if _, err := cache.Get_Object(BUCKET_NAME, "a", 0, -1); err == nil {
+		t.Error("non transient error should not serve the stale copy")
+	}
+
+	s.setHook(nil)
+	s.mu.Lock()
+	delete(s.objects, BUCKET_NAME+"/a")
+	s.mu.Unlock()
+	if _, err := cache.Get_Object(BUCKET_NAME, "a", 0, -1); err == nil || cache.Size() != 0 {
+		t.Error("deleted object should be invalidated", err, cache.Size())
+	}
+}
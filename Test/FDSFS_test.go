@@ -0,0 +1,118 @@
+package Test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdsfs"
+)
+
+func Test_FDSFS(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/root/a.txt", []byte("hello world"), nil)
+	s.put(BUCKET_NAME+"/root/d/e/f.txt", []byte("deep"), nil)
+	s.put(BUCKET_NAME+"/root/d/g.txt", []byte("g"), nil)
+	s.put(BUCKET_NAME+"/other/x", []byte("x"), nil)
+	if err := fstest.TestFS(fdsfs.New(localClient, BUCKET_NAME, "root"), "a.txt", "d/e/f.txt", "d/g.txt"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_FDSFS_Seek(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/root/a.txt", []byte("hello world"), nil)
+	f, err := fdsfs.New(localClient, BUCKET_NAME, "root").Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	seeker := f.(io.ReadSeeker)
+
+	cases := []struct {
+		offset int64
+		whence int
+		want   int64
+		read   string
+	}{
+		{6, io.SeekStart, 6, "wor"},
+		{-2, io.SeekCurrent, 7, "orl"},
+		{-5, io.SeekEnd, 6, "wor"},
+		{0, io.SeekCurrent, 9, "ld"},
+	}
+	for _, c := range cases {
+		pos, err := seeker.Seek(c.offset, c.whence)
+		if err != nil || pos != c.want {
+			t.Fatal(c, pos, err)
+		}
+		buf := make([]byte, 3)
+		n, err := io.ReadFull(seeker, buf[:len(c.read)])
+		if err != nil || string(buf[:n]) != c.read {
+			t.Fatal(c, string(buf[:n]), err)
+		}
+	}
+	// 只有改变位置的Seek会重新发起range请求
+	if n := s.count("GET /" + BUCKET_NAME + "/root/a.txt?"); n != 1 {
+		t.Error("metadata requests", n)
+	}
+	if n := s.count("GET /"+BUCKET_NAME+"/root/a.txt") - 1; n != 3 {
+		t.Error("range requests", n)
+	}
+	if n, err := seeker.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Error(n, err)
+	}
+	if _, err := seeker.Seek(-1, io.SeekStart); err == nil {
+		t.Error("negative offset should fail")
+	}
+	if _, err := seeker.Seek(0, 3); err == nil {
+		t.Error("invalid whence should fail")
+	}
+	if pos, err := seeker.Seek(100, io.SeekStart); err != nil || pos != 100 {
+		t.Error(pos, err)
+	}
+	if n, err := seeker.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Error("read past the end", n, err)
+	}
+}
+
+func Test_FDSFS_ReadAt(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/root/a.txt", []byte("hello world"), nil)
+	f, err := fdsfs.New(localClient, BUCKET_NAME, "root").Open("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readerAt := f.(io.ReaderAt)
+
+	cases := []struct {
+		off  int64
+		size int
+		want string
+		err  error
+	}{
+		{0, 5, "hello", nil},
+		{6, 5, "world", nil},
+		{6, 10, "world", io.EOF},
+		{11, 1, "", io.EOF},
+		{3, 0, "", nil},
+	}
+	for _, c := range cases {
+		buf := make([]byte, c.size)
+		n, err := readerAt.ReadAt(buf, c.off)
+		if string(buf[:n]) != c.want || err != c.err {
+			t.Error(c, string(buf[:n]), err)
+		}
+	}
+	// ReadAt不影响Read的位置
+	buf := make([]byte, 5)
+	if n, err := f.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Error(string(buf[:n]), err)
+	}
+
+	f.Close()
+	if _, err := readerAt.ReadAt(buf, 0); !errors.Is(err, fs.ErrClosed) {
+		t.Error("ReadAt after Close", err)
+	}
+}
@@ -0,0 +1,151 @@
+package Test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/fdshttp"
+)
+
+func serveFDSHTTP(h http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_FDSHTTP_Range(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/www/a.txt", []byte("hello world"), map[string]string{"content-type": "text/plain"})
+	h := fdshttp.New(localClient, BUCKET_NAME, "www")
+
+	cases := []struct {
+		method       string
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"GET", "", http.StatusOK, "hello world", ""},
+		{"GET", "bytes=0-4", http.StatusPartialContent, "hello", "bytes 0-4/11"},
+		{"GET", "bytes=6-", http.StatusPartialContent, "world", "bytes 6-10/11"},
+		{"GET", "bytes=-3", http.StatusPartialContent, "rld", "bytes 8-10/11"},
+		{"GET", "bytes=4-100", http.StatusPartialContent, "o world", "bytes 4-10/11"},
+		{"GET", "bytes=20-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"HEAD", "", http.StatusOK, "", ""},
+	}
+	for _, c := range cases {
+		header := map[string]string{}
+		if len(c.rangeHeader) > 0 {
+			header["Range"] = c.rangeHeader
+		}
+		rec := serveFDSHTTP(h, c.method, "/a.txt", header)
+		body := rec.Body.String()
+		if c.status == http.StatusRequestedRangeNotSatisfiable {
+			body = ""
+		}
+		if rec.Code != c.status || body != c.body || rec.Header().Get("Content-Range") != c.contentRange {
+			t.Error(c.method, c.rangeHeader, rec.Code, body, rec.Header().Get("Content-Range"))
+		}
+	}
+	if ct := serveFDSHTTP(h, "GET", "/a.txt", nil).Header().Get("Content-Type"); ct != "text/plain" {
+		t.Error(ct)
+	}
+}
+
+func Test_FDSHTTP_ETag(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/www/a.txt", []byte("hello world"), nil)
+	h := fdshttp.New(localClient, BUCKET_NAME, "www")
+	etag := fmt.Sprintf(`"%x"`, md5.Sum([]byte("hello world")))
+
+	rec := serveFDSHTTP(h, "GET", "/a.txt", nil)
+	if rec.Header().Get("ETag") != etag || len(rec.Header().Get("Last-Modified")) == 0 {
+		t.Fatal(rec.Header())
+	}
+	lastModified := rec.Header().Get("Last-Modified")
+
+	cases := []struct {
+		name   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"if-none-match hit", map[string]string{"If-None-Match": etag}, http.StatusNotModified, ""},
+		{"if-none-match list", map[string]string{"If-None-Match": `"x", ` + etag}, http.StatusNotModified, ""},
+		{"if-none-match miss", map[string]string{"If-None-Match": `"x"`}, http.StatusOK, "hello world"},
+		{"if-modified-since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified, ""},
+		{"if-match miss", map[string]string{"If-Match": `"x"`}, http.StatusPreconditionFailed, ""},
+		{"if-range hit", map[string]string{"Range": "bytes=0-4", "If-Range": etag}, http.StatusPartialContent, "hello"},
+		{"if-range miss", map[string]string{"Range": "bytes=0-4", "If-Range": `"x"`}, http.StatusOK, "hello world"},
+	}
+	for _, c := range cases {
+		before := s.count("GET /" + BUCKET_NAME + "/www/a.txt")
+		rec := serveFDSHTTP(h, "GET", "/a.txt", c.header)
+		if rec.Code != c.status || (len(c.body) > 0 && rec.Body.String() != c.body) {
+			t.Error(c.name, rec.Code, rec.Body.String())
+		}
+		// 条件请求命中时只发起一次请求，不读取内容
+		if rec.Code == http.StatusNotModified && s.count("GET /"+BUCKET_NAME+"/www/a.txt")-before != 1 {
+			t.Error(c.name, "content should not be fetched")
+		}
+	}
+}
+
+func Test_FDSHTTP_Overwritten(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/www/a.txt", []byte("hello world"), nil)
+	h := fdshttp.New(localClient, BUCKET_NAME, "www")
+	// range请求需要重新发起GET，此时object已被覆盖
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "GET" && r.Header.Get("range") == "bytes=6-" {
+			o := s.objects[BUCKET_NAME+"/www/a.txt"]
+			o.data = []byte("HELLO WORLD")
+			o.modified = o.modified.Add(time.Second)
+		}
+		return false
+	})
+	rec := serveFDSHTTP(h, "GET", "/a.txt", map[string]string{"Range": "bytes=6-"})
+	if strings.Contains(rec.Body.String(), "WORLD") {
+		t.Error("content of the new object should not be served with the old validators", rec.Body.String())
+	}
+	if rec.Header().Get("ETag") != fmt.Sprintf(`"%x"`, md5.Sum([]byte("hello world"))) {
+		t.Error(rec.Header())
+	}
+
+	rec = serveFDSHTTP(h, "GET", "/a.txt", nil)
+	if rec.Body.String() != "HELLO WORLD" || rec.Header().Get("ETag") != fmt.Sprintf(`"%x"`, md5.Sum([]byte("HELLO WORLD"))) {
+		t.Error(rec.Body.String(), rec.Header())
+	}
+}
+
+func Test_FDSHTTP_Errors(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.put(BUCKET_NAME+"/www/secret.txt", []byte("secret"), nil)
+	s.put(BUCKET_NAME+"/www/big.bin", []byte(strings.Repeat("x", 100)), nil)
+	h := fdshttp.New(localClient, BUCKET_NAME, "www")
+	h.RedirectThreshold = 50
+	h.Authorize = func(r *http.Request, key string) bool { return !strings.Contains(key, "secret") }
+
+	if rec := serveFDSHTTP(h, "GET", "/nope", nil); rec.Code != http.StatusNotFound {
+		t.Error(rec.Code)
+	}
+	if rec := serveFDSHTTP(h, "GET", "/secret.txt", nil); rec.Code != http.StatusForbidden {
+		t.Error(rec.Code)
+	}
+	if rec := serveFDSHTTP(h, "PUT", "/big.bin", nil); rec.Code != http.StatusMethodNotAllowed {
+		t.Error(rec.Code)
+	}
+	rec := serveFDSHTTP(h, "GET", "/big.bin", nil)
+	if rec.Code != http.StatusFound || !strings.Contains(rec.Header().Get("Location"), "/"+BUCKET_NAME+"/www/big.bin") {
+		t.Error(rec.Code, rec.Header().Get("Location"))
+	}
+}
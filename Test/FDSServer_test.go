@@ -0,0 +1,564 @@
+package Test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+type fdsObject struct {
+	data      []byte
+	meta      map[string]string
+	modified  time.Time
+	versionId string
+	etag      string // 列表中返回的etag，为空时为md5，模拟分片上传等etag不是md5的object
+}
+
+type fdsUpload struct {
+	meta  map[string]string
+	parts map[int][]byte
+}
+
+// fdsServer 在内存中模拟FDS的object、分片上传、回收站、多版本、ACL、quota、lifecycle和CDN接口，只实现SDK用到的部分。key为bucket/object
+type fdsServer struct {
+	mu         sync.Mutex
+	objects    map[string]*fdsObject
+	versions   map[string][]*fdsObject // 从旧到新
+	versioning map[string]string
+	trash      map[string]*fdsObject
+	uploads    map[string]*fdsUpload
+	acls       map[string][]Model.AccessControlList
+	configs    map[string][]byte // quota/bucket、lifecycle/bucket
+	// failures 对prefetch、refresh和deleteObjects中的object名字返回503的剩余次数
+	failures map[string]int
+	requests []string
+	// hook 在处理请求之前调用，返回true时不再处理，用于注入错误或在请求之间修改object
+	hook func(w http.ResponseWriter, r *http.Request) bool
+	// delay 每个请求处理之前的延迟，需要在发出请求之前设置
+	delay time.Duration
+}
+
+func newFDSServer(t *testing.T) (*fdsServer, *galaxy_fds_sdk_golang.FDSClient) {
+	s := &fdsServer{
+		objects:    map[string]*fdsObject{},
+		versions:   map[string][]*fdsObject{},
+		versioning: map[string]string{},
+		trash:      map[string]*fdsObject{},
+		uploads:    map[string]*fdsUpload{},
+		acls:       map[string][]Model.AccessControlList{},
+		configs:    map[string][]byte{},
+		failures:   map[string]int{},
+	}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+	return s, galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME,
+		strings.TrimPrefix(ts.URL, "http://"), false, false)
+}
+
+func (s *fdsServer) put(key string, data []byte, meta map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	s.store(key, &fdsObject{data: data, meta: meta})
+}
+
+func (s *fdsServer) setHook(hook func(w http.ResponseWriter, r *http.Request) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hook = hook
+}
+
+func (s *fdsServer) get(key string) *fdsObject {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.objects[key]
+}
+
+// count 返回以method和path开头的请求数
+func (s *fdsServer) count(prefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// countExact 返回与method和uri完全相同的请求数
+func (s *fdsServer) countExact(request string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if r == request {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *fdsServer) store(key string, o *fdsObject) {
+	o.modified = time.Now().Truncate(time.Second) // 与FDS返回的last-modified精度一致
+	if s.versioning[strings.SplitN(key, "/", 2)[0]] == Model.VERSIONING_ENABLED {
+		o.versionId = fmt.Sprintf("v%d", len(s.versions[key])+1)
+		s.versions[key] = append(s.versions[key], o)
+	}
+	s.objects[key] = o
+}
+
+func (s *fdsServer) fail(name string) bool {
+	if s.failures[name] > 0 {
+		s.failures[name]--
+		return true
+	}
+	return false
+}
+
+// requestLine 返回"GET /bucket/object?metadata"形式的请求，没有值的参数不带=，便于在测试中比较
+func requestLine(r *http.Request) string {
+	params := []string{}
+	for _, p := range strings.Split(r.URL.RawQuery, "&") {
+		if len(p) > 0 {
+			params = append(params, strings.TrimSuffix(p, "="))
+		}
+	}
+	line := r.Method + " " + r.URL.Path
+	if len(params) > 0 {
+		line += "?" + strings.Join(params, "&")
+	}
+	return line
+}
+
+// requestMeta 取出请求中需要保存为object metadata的header
+func requestMeta(r *http.Request) map[string]string {
+	meta := map[string]string{}
+	for k, v := range r.Header {
+		k = strings.ToLower(k)
+		switch {
+		case strings.HasPrefix(k, Model.UserMetadataPrefix), k == Model.ContentType, k == Model.ContentEncoding,
+			k == Model.CacheControl, k == Model.ContentDisposition, k == Model.Expires,
+			k == Model.ServerSideEncryption, k == Model.SSECustomerAlgorithm, k == Model.SSECustomerKeyMD5:
+			meta[k] = v[0]
+		}
+	}
+	return meta
+}
+
+// customerKeyMatches 检查请求是否带有加密object使用的客户密钥，prefix为x-xiaomi-或x-xiaomi-copy-source-
+func customerKeyMatches(meta map[string]string, r *http.Request, prefix string) bool {
+	want := meta[Model.SSECustomerKeyMD5]
+	if len(want) == 0 {
+		return true
+	}
+	key, _ := base64.StdEncoding.DecodeString(r.Header.Get(prefix + "server-side-encryption-customer-key"))
+	sum := md5.Sum(key)
+	return r.Header.Get(prefix+"server-side-encryption-customer-key-md5") == want &&
+		base64.StdEncoding.EncodeToString(sum[:]) == want
+}
+
+func (s *fdsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	q := r.URL.Query()
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, object := parts[0], ""
+	if len(parts) == 2 {
+		object = parts[1]
+	}
+	key := bucket + "/" + object
+	s.requests = append(s.requests, requestLine(r))
+	body, _ := ioutil.ReadAll(r.Body)
+	if s.hook != nil && s.hook(w, r) {
+		return
+	}
+
+	switch {
+	case bucket == "trash" && len(object) == 0 && r.Method == "GET":
+		s.listTrash(w, q)
+	case bucket == "trash" && r.Method == "DELETE":
+		if _, ok := s.trash[object]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.trash, object)
+	case q.Has("restore"):
+		o, ok := s.trash[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.trash, key)
+		s.objects[key] = o
+	case q.Has("prefetch") || q.Has("refresh"):
+		if s.fail(object) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if _, ok := s.objects[key]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case q.Has("deleteObjects"):
+		var names []string
+		json.Unmarshal(body, &names)
+		if len(names) > 1000 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		errs := []Model.DeleteObjectError{}
+		for _, name := range names {
+			if s.fail(name) {
+				errs = append(errs, Model.DeleteObjectError{ObjectName: name, ErrorCode: 503, ErrorDescription: "busy"})
+				continue
+			}
+			o, ok := s.objects[bucket+"/"+name]
+			if !ok {
+				errs = append(errs, Model.DeleteObjectError{ObjectName: name, ErrorCode: 404, ErrorDescription: "not found"})
+				continue
+			}
+			s.trash[bucket+"/"+name] = o
+			delete(s.objects, bucket+"/"+name)
+		}
+		json.NewEncoder(w).Encode(errs)
+	case q.Has("setMetaData"):
+		var m struct{ RawMeta map[string]string }
+		json.Unmarshal(body, &m)
+		s.objects[key].meta = m.RawMeta
+	case q.Has("versioning") && r.Method == "GET":
+		json.NewEncoder(w).Encode(Model.BucketVersioning{Status: s.versioning[bucket]})
+	case q.Has("versioning"):
+		var v Model.BucketVersioning
+		json.Unmarshal(body, &v)
+		s.versioning[bucket] = v.Status
+	case q.Has("versions"):
+		s.listVersions(w, bucket, q)
+	case q.Has("versionId") && r.Method == "DELETE":
+		list := s.versions[key]
+		for i, v := range list {
+			if v.versionId == q.Get("versionId") {
+				s.versions[key] = append(list[:i:i], list[i+1:]...)
+			}
+		}
+	case q.Has("quota") || q.Has("lifecycle"):
+		name := "quota/" + bucket
+		if q.Has("lifecycle") {
+			name = "lifecycle/" + bucket
+		}
+		switch r.Method {
+		case "GET":
+			w.Write(s.configs[name])
+		case "PUT":
+			s.configs[name] = body
+		case "DELETE":
+			delete(s.configs, name)
+		}
+	case q.Has("acl") && r.Method == "GET":
+		json.NewEncoder(w).Encode(Model.ACL{AccessControlLists: s.acls[key], Owners: Model.Owner{Id: APP_KEY}})
+	case q.Has("acl"):
+		var acl Model.ACL
+		json.Unmarshal(body, &acl)
+		if q.Get("action") != "delete" {
+			s.acls[key] = acl.AccessControlLists
+			return
+		}
+		kept := []Model.AccessControlList{}
+		for _, g := range s.acls[key] {
+			drop := false
+			for _, d := range acl.AccessControlLists {
+				drop = drop || d.Type == g.Type && d.Grantees.Id == g.Grantees.Id && d.Permission == g.Permission
+			}
+			if !drop {
+				kept = append(kept, g)
+			}
+		}
+		s.acls[key] = kept
+	case len(object) == 0 && r.Method == "GET":
+		s.listObjects(w, bucket, q)
+	case q.Has("uploads"):
+		id := fmt.Sprintf("upload-%d", len(s.uploads)+1)
+		s.uploads[id] = &fdsUpload{meta: requestMeta(r), parts: map[int][]byte{}}
+		json.NewEncoder(w).Encode(Model.InitMultipartUploadResult{BucketName: bucket, ObjectName: object, UploadId: id})
+	case q.Has("partNumber"):
+		upload := s.uploads[q.Get("uploadId")]
+		if !customerKeyMatches(upload.meta, r, "x-xiaomi-") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		upload.parts[n] = body
+		json.NewEncoder(w).Encode(Model.UploadPartResult{PartNumber: n, Etag: "etag", PartSize: int64(len(body))})
+	case q.Has("uploadId") && r.Method == "PUT":
+		upload := s.uploads[q.Get("uploadId")]
+		numbers := []int{}
+		for n := range upload.parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		data := []byte{}
+		for _, n := range numbers {
+			data = append(data, upload.parts[n]...)
+		}
+		delete(s.uploads, q.Get("uploadId"))
+		s.store(key, &fdsObject{data: data, meta: upload.meta})
+		json.NewEncoder(w).Encode(Model.PutObjectResult{BucketName: bucket, ObjectName: object})
+	case q.Has("uploadId") && r.Method == "DELETE":
+		delete(s.uploads, q.Get("uploadId"))
+	case q.Has("cpFrom"):
+		var source map[string]string
+		json.Unmarshal(body, &source)
+		srcKey := source["srcBucketName"] + "/" + source["srcObjectName"]
+		src, ok := s.objects[srcKey]
+		if id := source["srcVersionId"]; len(id) > 0 {
+			src, ok = s.version(srcKey, id)
+		}
+		if !ok || !customerKeyMatches(src.meta, r, "x-xiaomi-copy-source-") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		meta := requestMeta(r)
+		for k, v := range src.meta {
+			if !strings.Contains(k, "server-side-encryption") {
+				meta[k] = v
+			}
+		}
+		s.store(key, &fdsObject{data: src.data, meta: meta})
+		json.NewEncoder(w).Encode(Model.PutObjectResult{BucketName: bucket, ObjectName: object})
+	case q.Has("renameTo"):
+		o, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(s.objects, key)
+		s.objects[bucket+"/"+q.Get("renameTo")] = o
+	case r.Method == "PUT":
+		o := &fdsObject{data: body, meta: requestMeta(r)}
+		s.store(key, o)
+		json.NewEncoder(w).Encode(Model.PutObjectResult{BucketName: bucket, ObjectName: object, VersionId: o.versionId})
+	case r.Method == "DELETE":
+		o, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		o.modified = time.Now().Truncate(time.Second)
+		s.trash[key] = o
+		delete(s.objects, key)
+	case r.Method == "GET" || r.Method == "HEAD":
+		s.getObject(w, r, key)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (s *fdsServer) version(key, versionId string) (*fdsObject, bool) {
+	for _, v := range s.versions[key] {
+		if v.versionId == versionId {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func (s *fdsServer) getObject(w http.ResponseWriter, r *http.Request, key string) {
+	o, ok := s.objects[key]
+	if id := r.URL.Query().Get("versionId"); len(id) > 0 {
+		o, ok = s.version(key, id)
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if !customerKeyMatches(o.meta, r, "x-xiaomi-") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for k, v := range o.meta {
+		w.Header().Set(k, v)
+	}
+	if len(o.versionId) > 0 {
+		w.Header().Set(Model.VersionId, o.versionId)
+	}
+	w.Header().Set(Model.LastModified, o.modified.UTC().Format(http.TimeFormat))
+	w.Header().Set(Model.ContentMD5, fmt.Sprintf("%x", md5.Sum(o.data)))
+	w.Header().Set(Model.ContentMetadataLength, strconv.Itoa(len(o.data)))
+	if r.URL.Query().Has("metadata") {
+		return
+	}
+	data := o.data
+	if spec := strings.TrimPrefix(r.Header.Get("range"), "bytes="); len(spec) > 0 {
+		bounds := strings.SplitN(spec, "-", 2)
+		start, _ := strconv.Atoi(bounds[0])
+		end := len(data) - 1
+		if len(bounds[1]) > 0 {
+			end, _ = strconv.Atoi(bounds[1])
+		}
+		if end >= len(data) {
+			end = len(data) - 1
+		}
+		if start > end {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		data = data[start : end+1]
+		w.Header().Set("content-length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	if r.Method == "GET" {
+		w.Write(data)
+	}
+}
+
+func maxKeys(q map[string][]string) int {
+	n, _ := strconv.Atoi(firstValue(q["maxKeys"]))
+	if n <= 0 {
+		n = 1000
+	}
+	return n
+}
+
+func firstValue(v []string) string {
+	if len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
+func (s *fdsServer) listObjects(w http.ResponseWriter, bucket string, q map[string][]string) {
+	prefix, delimiter, marker := firstValue(q["prefix"]), firstValue(q["delimiter"]), firstValue(q["marker"])
+	limit := maxKeys(q)
+	names := []string{}
+	for k := range s.objects {
+		if name := strings.TrimPrefix(k, bucket+"/"); name != k && strings.HasPrefix(name, prefix) && name > marker {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	listing := map[string]interface{}{"name": bucket, "prefix": prefix, "delimiter": delimiter, "maxKeys": limit}
+	objects := []map[string]interface{}{}
+	commonPrefixes := []string{}
+	seen := map[string]bool{}
+	next, truncated := "", false
+	for _, name := range names {
+		rest := name[len(prefix):]
+		if i := strings.Index(rest, delimiter); len(delimiter) > 0 && i >= 0 {
+			if cp := prefix + rest[:i+1]; !seen[cp] {
+				if len(objects)+len(commonPrefixes) >= limit {
+					truncated = true
+					break
+				}
+				seen[cp] = true
+				commonPrefixes = append(commonPrefixes, cp)
+			}
+			next = name
+			continue
+		}
+		if len(objects)+len(commonPrefixes) >= limit {
+			truncated = true
+			break
+		}
+		o := s.objects[bucket+"/"+name]
+		etag := o.etag
+		if len(etag) == 0 {
+			etag = fmt.Sprintf("%x", md5.Sum(o.data))
+		}
+		objects = append(objects, map[string]interface{}{"name": name, "size": len(o.data),
+			"etag": etag, "lastModified": o.modified.Format(time.RFC3339Nano)})
+		next = name
+	}
+	listing["objects"] = objects
+	listing["commonPrefixes"] = commonPrefixes
+	listing["truncated"] = truncated
+	listing["nextMarker"] = next
+	json.NewEncoder(w).Encode(listing)
+}
+
+func (s *fdsServer) listTrash(w http.ResponseWriter, q map[string][]string) {
+	prefix, marker := firstValue(q["prefix"]), firstValue(q["marker"])
+	limit := maxKeys(q)
+	names := []string{}
+	for k := range s.trash {
+		if strings.HasPrefix(k, prefix) && k > marker {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	truncated := len(names) > limit
+	if truncated {
+		names = names[:limit]
+	}
+	objects := []map[string]interface{}{}
+	for _, name := range names {
+		objects = append(objects, map[string]interface{}{"name": name, "size": len(s.trash[name].data),
+			"lastModified": s.trash[name].modified.Format(time.RFC3339Nano)})
+	}
+	next := ""
+	if len(names) > 0 {
+		next = names[len(names)-1]
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": "trash", "prefix": prefix, "maxKeys": limit,
+		"objects": objects, "truncated": truncated, "nextMarker": next})
+}
+
+// listVersions 按object名字排序，同一个object从新到旧，使用keyMarker和versionIdMarker分页
+func (s *fdsServer) listVersions(w http.ResponseWriter, bucket string, q map[string][]string) {
+	prefix := firstValue(q["prefix"])
+	keyMarker, versionIdMarker := firstValue(q["keyMarker"]), firstValue(q["versionIdMarker"])
+	limit := maxKeys(q)
+	keys := []string{}
+	for k := range s.versions {
+		if name := strings.TrimPrefix(k, bucket+"/"); name != k && strings.HasPrefix(name, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	versions := []Model.FDSObjectVersionSummary{}
+	skipping := len(keyMarker) > 0
+	truncated := false
+	for _, k := range keys {
+		name := strings.TrimPrefix(k, bucket+"/")
+		list := s.versions[k]
+		for i := len(list) - 1; i >= 0; i-- {
+			if skipping {
+				if name == keyMarker && list[i].versionId == versionIdMarker {
+					skipping = false
+				}
+				continue
+			}
+			if len(versions) >= limit {
+				truncated = true
+				break
+			}
+			versions = append(versions, Model.FDSObjectVersionSummary{ObjectName: name, VersionId: list[i].versionId,
+				IsLatest: i == len(list)-1, Size: int64(len(list[i].data)), LastModified: list[i].modified})
+		}
+		if truncated {
+			break
+		}
+	}
+	listing := Model.FDSObjectVersionListing{BucketName: bucket, Prefix: prefix, MaxKeys: limit,
+		Truncated: truncated, Versions: versions}
+	if truncated {
+		last := versions[len(versions)-1]
+		listing.NextKeyMarker, listing.NextVersionIdMarker = last.ObjectName, last.VersionId
+	}
+	json.NewEncoder(w).Encode(listing)
+}
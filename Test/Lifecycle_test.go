@@ -0,0 +1,92 @@
+package Test
+
+import (
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func days(n int) *Model.LifecycleDays {
+	return &Model.LifecycleDays{Days: n}
+}
+
+func expireRule(id, prefix string, n int) Model.LifecycleRule {
+	return Model.LifecycleRule{Id: id, Prefix: prefix, Enabled: true, Actions: Model.LifecycleActions{Expiration: days(n)}}
+}
+
+func Test_Lifecycle_Validate(t *testing.T) {
+	disabled := expireRule("b", "logs/app/", 7)
+	disabled.Enabled = false
+	cases := []struct {
+		name  string
+		rules []Model.LifecycleRule
+		valid bool
+	}{
+		{"empty", nil, true},
+		{"disjoint prefixes", []Model.LifecycleRule{expireRule("a", "logs/", 30), expireRule("b", "tmp/", 1)}, true},
+		{"similar but disjoint prefixes", []Model.LifecycleRule{expireRule("a", "logs/a", 30), expireRule("b", "logs/b", 1)}, true},
+		{"nested prefixes", []Model.LifecycleRule{expireRule("a", "logs/", 30), expireRule("b", "logs/app/", 7)}, false},
+		{"nested prefixes reversed", []Model.LifecycleRule{expireRule("b", "logs/app/", 7), expireRule("a", "logs/", 30)}, false},
+		{"empty prefix covers all", []Model.LifecycleRule{expireRule("a", "", 30), expireRule("b", "tmp/", 1)}, false},
+		{"overlap with disabled rule", []Model.LifecycleRule{expireRule("a", "logs/", 30), disabled}, true},
+		{"overlap on different actions", []Model.LifecycleRule{expireRule("a", "logs/", 30), {Id: "b", Prefix: "logs/app/",
+			Enabled: true, Actions: Model.LifecycleActions{AbortIncompleteMultipartUpload: days(1)}}}, true},
+		{"duplicate id", []Model.LifecycleRule{expireRule("a", "logs/", 30), expireRule("a", "tmp/", 1)}, false},
+		{"missing id", []Model.LifecycleRule{expireRule("", "logs/", 30)}, false},
+		{"no action", []Model.LifecycleRule{{Id: "a", Prefix: "logs/", Enabled: true}}, false},
+		{"zero days", []Model.LifecycleRule{expireRule("a", "logs/", 0)}, false},
+		{"negative days", []Model.LifecycleRule{{Id: "a", Enabled: true,
+			Actions: Model.LifecycleActions{AbortIncompleteMultipartUpload: days(-1)}}}, false},
+		{"trash before expiration", []Model.LifecycleRule{{Id: "a", Enabled: true,
+			Actions: Model.LifecycleActions{MoveToTrash: days(7), Expiration: days(30)}}}, true},
+		{"trash same day as expiration", []Model.LifecycleRule{{Id: "a", Enabled: true,
+			Actions: Model.LifecycleActions{MoveToTrash: days(30), Expiration: days(30)}}}, false},
+		{"trash after expiration", []Model.LifecycleRule{{Id: "a", Enabled: true,
+			Actions: Model.LifecycleActions{MoveToTrash: days(31), Expiration: days(30)}}}, false},
+	}
+	for _, c := range cases {
+		config := Model.LifecycleConfig{Rules: c.rules}
+		if err := config.Validate(); (err == nil) != c.valid {
+			t.Error(c.name, err)
+		}
+	}
+}
+
+func Test_Lifecycle_Config(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	config, err := localClient.Get_Bucket_Lifecycle(BUCKET_NAME)
+	if err != nil || len(config.Rules) != 0 {
+		t.Fatal(config, err)
+	}
+	config.SetRule(expireRule("logs", "logs/", 30))
+	config.SetRule(expireRule("tmp", "tmp/", 1))
+	config.SetRule(expireRule("logs", "logs/", 60))
+	if _, err := localClient.Set_Bucket_Lifecycle(BUCKET_NAME, *config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := localClient.Get_Bucket_Lifecycle(BUCKET_NAME)
+	if err != nil || len(got.Rules) != 2 {
+		t.Fatal(got, err)
+	}
+	if r, ok := got.GetRule("logs"); !ok || r.Actions.Expiration.Days != 60 || r.Actions.MoveToTrash != nil {
+		t.Error(r)
+	}
+
+	got.SetRule(expireRule("all", "", 90))
+	if _, err := localClient.Set_Bucket_Lifecycle(BUCKET_NAME, *got); err == nil {
+		t.Error("conflicting rules should be rejected")
+	}
+	if s.count("PUT") != 1 {
+		t.Error("invalid config should not be sent")
+	}
+	if !got.RemoveRule("all") || got.RemoveRule("all") {
+		t.Error("RemoveRule")
+	}
+
+	if _, err := localClient.Delete_Bucket_Lifecycle(BUCKET_NAME); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := localClient.Get_Bucket_Lifecycle(BUCKET_NAME); err != nil || len(got.Rules) != 0 {
+		t.Error(got, err)
+	}
+}
@@ -0,0 +1,108 @@
+package Test
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func forbidden(w http.ResponseWriter, r *http.Request) bool {
+	w.WriteHeader(http.StatusForbidden)
+	w.Write([]byte("signature mismatch, secret " + SECRET_KEY))
+	return true
+}
+
+func Test_Logger_Redaction(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	buf := &bytes.Buffer{}
+	localClient.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	localClient.SignatureDebug = true
+	s.setHook(forbidden)
+
+	localClient.Logger.Info("client", "client", localClient)
+	headers := map[string]string{"x-xiaomi-meta-token": SECRET_KEY}
+	res, err := localClient.Auth(galaxy_fds_sdk_golang.FDSAuth{
+		UrlBase: localClient.GetBaseUri() + BUCKET_NAME + "/a?Signature=presigned-signature",
+		Method:  "GET",
+		Headers: &headers,
+	})
+	if err != nil || res.StatusCode != http.StatusForbidden {
+		t.Fatal(res, err)
+	}
+	res.Body.Close()
+
+	out := buf.String()
+	for _, leaked := range []string{SECRET_KEY, "presigned-signature", "Galaxy-V2"} {
+		if strings.Contains(out, leaked) {
+			t.Error(leaked, "should be redacted:", out)
+		}
+	}
+	for _, want := range []string{"fds signature mismatch", "string_to_sign", `"authorization":"[REDACTED]"`,
+		`"app_secret":"[REDACTED]"`, "Signature=%5BREDACTED%5D"} {
+		if !strings.Contains(out, want) {
+			t.Error("missing", want, out)
+		}
+	}
+}
+
+func Test_Logger_Redaction_Customer_Key(t *testing.T) {
+	_, localClient := newFDSServer(t)
+	buf := &bytes.Buffer{}
+	localClient.Logger = slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	key, other := customerKey(t, 1), customerKey(t, 2)
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "c", []byte("secret"), nil, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "c", 0, -1, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localClient.Copy_Object_With_SSE(BUCKET_NAME, "c", BUCKET_NAME, "d", key, other); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, sse := range []*Model.SSEOptions{key, other} {
+		if leaked := sse.ReadHeaders()[Model.SSECustomerKey]; strings.Contains(out, leaked) {
+			t.Error("customer key should be redacted:", out)
+		}
+	}
+	for _, want := range []string{`"` + Model.SSECustomerKey + `":"[REDACTED]"`,
+		`"` + Model.CopySourceSSECustomerKey + `":"[REDACTED]"`, Model.SSECustomerKey + `:[REDACTED]\n`} {
+		if !strings.Contains(out, want) {
+			t.Error("missing", want, out)
+		}
+	}
+}
+
+func Test_Logger_Signature_Debug_Default(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	buf := &bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	s.put(BUCKET_NAME+"/a", []byte("a"), nil)
+	if _, err := localClient.Get_Object(BUCKET_NAME, "a", 0, -1); err != nil {
+		t.Fatal(err)
+	}
+	localClient.SignatureDebug = true
+	if _, err := localClient.Get_Object(BUCKET_NAME, "a", 0, -1); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatal("only signature mismatches should be logged without a Logger:", buf.String())
+	}
+	s.setHook(forbidden)
+	if _, err := localClient.Get_Object(BUCKET_NAME, "a", 0, -1); err == nil {
+		t.Fatal("expected 403")
+	}
+	out := buf.String()
+	if !strings.Contains(out, "fds signature mismatch") || strings.Contains(out, SECRET_KEY) {
+		t.Error(out)
+	}
+}
@@ -0,0 +1,101 @@
+package Test
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+func Test_Mirror(t *testing.T) {
+	src, srcClient := newFDSServer(t)
+	dst, dstClient := newFDSServer(t)
+	src.put("src/p/1", []byte("one"), map[string]string{"content-type": "text/plain", "x-xiaomi-meta-k": "v"})
+	src.put("src/p/2", []byte("two"), nil)
+	dst.put("dst/p/3", []byte("three"), nil)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	opts := &galaxy_fds_sdk_golang.MirrorOptions{Delete: true, Checkpoint: checkpoint}
+
+	res, err := galaxy_fds_sdk_golang.Mirror(srcClient, "src", dstClient, "dst", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Copied != 2 || res.Deleted != 1 || res.Actions[0].Method != galaxy_fds_sdk_golang.MIRROR_METHOD_STREAM {
+		t.Fatal(res.Summary(), res.Actions)
+	}
+	o := dst.get("dst/p/1")
+	if o == nil || string(o.data) != "one" || o.meta["content-type"] != "text/plain" || o.meta["x-xiaomi-meta-k"] != "v" {
+		t.Fatal("p/1 should be copied with metadata", o)
+	}
+	if dst.get("dst/p/3") != nil {
+		t.Error("p/3 should be deleted")
+	}
+
+	res, err = galaxy_fds_sdk_golang.Mirror(srcClient, "src", dstClient, "dst", opts)
+	if err != nil || len(res.Actions) != 0 || res.Skipped != 2 {
+		t.Error(err, res.Summary())
+	}
+}
+
+func Test_Mirror_Non_MD5_Etag(t *testing.T) {
+	src, srcClient := newFDSServer(t)
+	dst, dstClient := newFDSServer(t)
+	src.put("src/same", []byte("same"), nil)
+	src.put("src/changed", []byte("new!"), nil)
+	dst.put("dst/same", []byte("same"), nil)
+	dst.put("dst/changed", []byte("old!"), nil)
+	// 分片上传得到的etag不是md5，也没有checkpoint，需要通过metadata中的content-md5比较
+	dst.get("dst/same").etag = "multipart-1"
+	dst.get("dst/changed").etag = "multipart-2"
+
+	res, err := galaxy_fds_sdk_golang.Mirror(srcClient, "src", dstClient, "dst", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Skipped != 1 || res.Copied != 1 || res.Actions[0].Key != "changed" || res.Actions[0].Reason != "changed" {
+		t.Fatal(res.Summary(), res.Actions)
+	}
+	if o := dst.get("dst/changed"); string(o.data) != "new!" {
+		t.Error(string(o.data))
+	}
+	if dst.count("GET /dst/same?metadata") != 1 {
+		t.Error("content-md5 should be read from metadata")
+	}
+}
+
+func Test_Mirror_Server_Side_Copy(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		method string
+		copied bool
+	}{
+		{"copied", 0, galaxy_fds_sdk_golang.MIRROR_METHOD_SERVER_SIDE, true},
+		{"unsupported", http.StatusNotImplemented, galaxy_fds_sdk_golang.MIRROR_METHOD_STREAM, true},
+		{"cross account", http.StatusForbidden, galaxy_fds_sdk_golang.MIRROR_METHOD_STREAM, true},
+		// 其它错误不会改为下载后上传
+		{"quota", http.StatusBadRequest, galaxy_fds_sdk_golang.MIRROR_METHOD_SERVER_SIDE, false},
+		{"not found", http.StatusNotFound, galaxy_fds_sdk_golang.MIRROR_METHOD_SERVER_SIDE, false},
+	}
+	for _, c := range cases {
+		s, localClient := newFDSServer(t)
+		s.put("src/o", []byte("data"), nil)
+		status := c.status
+		s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+			if status != 0 && r.URL.Query().Has("cpFrom") {
+				w.WriteHeader(status)
+				w.Write([]byte("quota exceeded"))
+				return true
+			}
+			return false
+		})
+		res, _ := galaxy_fds_sdk_golang.Mirror(localClient, "src", localClient, "dst", nil)
+		if len(res.Actions) != 1 || res.Actions[0].Method != c.method || (res.Copied == 1) != c.copied {
+			t.Error(c.name, res.Summary(), res.Actions)
+		}
+		if streamed := s.count("GET /src/o") > 0; streamed != (c.copied && c.status != 0) {
+			t.Error(c.name, "unexpected download of the source object")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package Test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func Test_Object_Metadata_Validate(t *testing.T) {
+	cases := []struct {
+		name  string
+		meta  *Model.ObjectMetadata
+		valid bool
+	}{
+		{"empty", Model.NewObjectMetadata(), true},
+		{"settable", Model.NewObjectMetadata().SetContentType("text/html").SetCacheControl("no-cache").
+			SetContentEncoding("gzip").SetContentDisposition("attachment").SetExpires(time.Now()), true},
+		{"user", Model.NewObjectMetadata().SetUserMetadata("Owner_1.x", "web"), true},
+		{"user name", Model.NewObjectMetadata().SetUserMetadata("a b", "v"), false},
+		{"control character", Model.NewObjectMetadata().SetCacheControl("\x01"), false},
+		{"non-ascii", Model.NewObjectMetadata().SetUserMetadata("name", "中文"), false},
+		{"too large", Model.NewObjectMetadata().SetUserMetadata("a", strings.Repeat("x", Model.MaxUserMetadataBytes)), false},
+	}
+	for _, c := range cases {
+		if err := c.meta.Validate(); (err == nil) != c.valid {
+			t.Error(c.name, err)
+		}
+	}
+
+	raw := Model.NewFDSMetaData(map[string][]string{Model.Expires: {"tomorrow"}, "content-md5": {"x"}})
+	if err := Model.NewObjectMetadataFromFDSMetaData(raw).Validate(); err == nil {
+		t.Error("invalid expires should be rejected")
+	}
+}
+
+func Test_Object_Metadata_Headers(t *testing.T) {
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600))
+	meta := Model.NewObjectMetadata().SetContentType("text/plain").SetExpires(expires).
+		SetUserMetadata("X-Xiaomi-Meta-Owner", "me").SetUserMetadata("tag", "a")
+	want := map[string]string{
+		Model.Expires:         "Tue, 01 Jan 2030 19:04:05 GMT",
+		"x-xiaomi-meta-owner": "me",
+		"x-xiaomi-meta-tag":   "a",
+	}
+	if headers := meta.Headers(); !reflect.DeepEqual(headers, want) {
+		t.Error(headers)
+	}
+	if user := meta.GetUserMetadata(); len(user) != 2 || user["owner"] != "me" {
+		t.Error(user)
+	}
+	clone := meta.Clone().SetContentType("").SetExpires(time.Time{}).SetUserMetadata("tag", "")
+	if clone.GetContentType() != "" || len(clone.Headers()) != 1 || meta.GetContentType() != "text/plain" {
+		t.Error("empty values should delete the header without changing the original", clone.Headers())
+	}
+}
+
+func Test_Object_Metadata_Round_Trip(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	meta := Model.NewObjectMetadata().SetContentType("text/plain").SetCacheControl("max-age=60").
+		SetContentDisposition("attachment").SetUserMetadata("owner", "me")
+	if _, err := localClient.Put_Object_With_Metadata(BUCKET_NAME, "o", []byte("data"), meta); err != nil {
+		t.Fatal(err)
+	}
+	fdsMeta, err := localClient.Get_Object_Meta(BUCKET_NAME, "o")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// last-modified、content-md5和x-xiaomi-meta-content-length等只读的metadata不会被取出
+	got := Model.NewObjectMetadataFromFDSMetaData(fdsMeta)
+	if !reflect.DeepEqual(got.Headers(), meta.Headers()) || got.GetContentType() != "text/plain" {
+		t.Fatal(got.Headers(), meta.Headers())
+	}
+
+	if ok, err := localClient.Set_Object_Metadata(BUCKET_NAME, "o", got.SetUserMetadata("owner", "you")); !ok || err != nil {
+		t.Fatal(err)
+	}
+	if owner := s.get(BUCKET_NAME + "/o").meta["x-xiaomi-meta-owner"]; owner != "you" {
+		t.Error(owner)
+	}
+	if _, err := localClient.Set_Object_Metadata(BUCKET_NAME, "o", Model.NewObjectMetadata().SetUserMetadata("a b", "v")); err == nil {
+		t.Error("invalid metadata should not be sent")
+	}
+}
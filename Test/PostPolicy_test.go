@@ -0,0 +1,177 @@
+package Test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+// postPolicyServer 模拟FDS的表单上传，校验签名和policy中的条件
+type postPolicyServer struct {
+	secret  string
+	objects map[string][]byte
+}
+
+func (s *postPolicyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile(galaxy_fds_sdk_golang.POST_POLICY_FILE_FIELD)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	content, _ := ioutil.ReadAll(file)
+	form := map[string]string{"bucket": strings.Trim(r.URL.Path, "/")}
+	for k, v := range r.MultipartForm.Value {
+		form[strings.ToLower(k)] = v[0]
+	}
+	form["key"] = strings.Replace(form["key"], galaxy_fds_sdk_golang.POST_POLICY_FILENAME, header.Filename, -1)
+
+	h := hmac.New(sha1.New, []byte(s.secret))
+	h.Write([]byte(form["policy"]))
+	if base64.StdEncoding.EncodeToString(h.Sum(nil)) != form["signature"] {
+		http.Error(w, "signature mismatch", http.StatusForbidden)
+		return
+	}
+	doc, _ := base64.StdEncoding.DecodeString(form["policy"])
+	var policy struct {
+		Expiration string
+		Conditions []interface{}
+	}
+	if err := json.Unmarshal(doc, &policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	expiration, err := time.Parse("2006-01-02T15:04:05.000Z", policy.Expiration)
+	if err != nil || time.Now().After(expiration) {
+		http.Error(w, "policy expired", http.StatusForbidden)
+		return
+	}
+	for _, c := range policy.Conditions {
+		ok := true
+		switch c := c.(type) {
+		case map[string]interface{}:
+			for k, v := range c {
+				ok = ok && form[strings.ToLower(k)] == v
+			}
+		case []interface{}:
+			if c[0] == "content-length-range" {
+				size := float64(len(content))
+				ok = size >= c[1].(float64) && size <= c[2].(float64)
+				break
+			}
+			value := form[strings.ToLower(strings.TrimPrefix(c[1].(string), "$"))]
+			if c[0] == "eq" {
+				ok = value == c[2]
+			} else {
+				ok = strings.HasPrefix(value, c[2].(string))
+			}
+		}
+		if !ok {
+			http.Error(w, "policy condition failed", http.StatusForbidden)
+			return
+		}
+	}
+	s.objects[form["key"]] = content
+	if redirect := form[strings.ToLower(galaxy_fds_sdk_golang.POST_POLICY_FIELD_SUCCESS_REDIRECT)]; len(redirect) > 0 {
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func postForm(form *galaxy_fds_sdk_golang.PostPolicyForm, fields map[string]string,
+	filename string, content []byte) (*http.Response, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	values := map[string]string{}
+	for k, v := range form.Fields {
+		values[k] = v
+	}
+	for k, v := range fields {
+		values[k] = v
+	}
+	for k, v := range values {
+		writer.WriteField(k, v)
+	}
+	part, _ := writer.CreateFormFile(galaxy_fds_sdk_golang.POST_POLICY_FILE_FIELD, filename)
+	part.Write(content)
+	writer.Close()
+	req, _ := http.NewRequest("POST", form.URL, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	return noRedirect.Do(req)
+}
+
+func Test_Post_Policy(t *testing.T) {
+	server := &postPolicyServer{secret: SECRET_KEY, objects: map[string][]byte{}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME,
+		strings.TrimPrefix(ts.URL, "http://"), false, false)
+
+	form, err := localClient.Generate_Post_Policy(&galaxy_fds_sdk_golang.PostPolicy{
+		Bucket:            BUCKET_NAME,
+		KeyPrefix:         "uploads/",
+		ContentTypePrefix: "image/",
+		MaxContentLength:  16,
+		SuccessRedirect:   "https://example.com/done",
+	})
+	if err != nil {
+		t.Fatal("Fail to generate post policy", err)
+	}
+
+	res, err := postForm(form, map[string]string{"Content-Type": "image/png"}, "a.png", []byte("png data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSeeOther || res.Header.Get("Location") != "https://example.com/done" {
+		t.Error("unexpected response", res.Status)
+	}
+	if string(server.objects["uploads/a.png"]) != "png data" {
+		t.Error("object not uploaded")
+	}
+
+	cases := map[string]map[string]string{
+		"content type": {"Content-Type": "text/plain"},
+		"key prefix":   {"Content-Type": "image/png", "key": "other/a.png"},
+	}
+	for name, fields := range cases {
+		res, err := postForm(form, fields, "b.png", []byte("png data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusForbidden {
+			t.Error(name+": expected 403, got", res.Status)
+		}
+	}
+	res, err = postForm(form, map[string]string{"Content-Type": "image/png"}, "c.png", make([]byte, 17))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusForbidden {
+		t.Error("content length: expected 403, got", res.Status)
+	}
+	if _, ok := server.objects["uploads/c.png"]; ok || len(server.objects) != 1 {
+		t.Error("policy violation should not be stored")
+	}
+}
@@ -0,0 +1,131 @@
+package Test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func presignedHeaders(req *galaxy_fds_sdk_golang.PresignedRequest) map[string][]string {
+	headers := map[string][]string{}
+	for k, v := range req.Headers {
+		headers[k] = []string{v}
+	}
+	return headers
+}
+
+func Test_Presigned_Request_Verify(t *testing.T) {
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME, "localhost", false, false)
+	before := time.Now()
+	req, err := localClient.Generate_Presigned_Request(BUCKET_NAME, "a/b c.png", &galaxy_fds_sdk_golang.PresignOptions{
+		Method:       "put",
+		Expires:      time.Hour,
+		ContentType:  "image/png",
+		ContentMD5:   "0123456789abcdef0123456789abcdef",
+		Headers:      map[string]string{"X-Xiaomi-Meta-Owner": "me"},
+		SubResources: map[string]string{"uploadId": "upload-1", "partNumber": "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPut || req.Headers["x-xiaomi-meta-owner"] != "me" || len(req.Headers) != 3 {
+		t.Fatal(req)
+	}
+	expiration := time.Unix(0, req.Expiration*int64(time.Millisecond))
+	if expiration.Before(before.Add(time.Hour-time.Second)) || expiration.After(time.Now().Add(time.Hour)) {
+		t.Error("expiration", expiration)
+	}
+	u, _ := url.Parse(req.URL)
+	if q := u.Query(); q.Get("uploadId") != "upload-1" || q.Get("partNumber") != "2" {
+		t.Error(req.URL)
+	}
+
+	headers := presignedHeaders(req)
+	accessKeyId, err := galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "PUT", req.URL, headers, time.Now())
+	if err != nil || accessKeyId != APP_KEY {
+		t.Fatal(req.URL, err)
+	}
+
+	// 修改方法、绑定的header或子资源后签名都不再匹配
+	changed := []struct {
+		name, method, url, header, value string
+	}{
+		{"method", "GET", req.URL, "", ""},
+		{"content-md5", "PUT", req.URL, Model.ContentMD5, "x"},
+		{"meta header", "PUT", req.URL, "x-xiaomi-meta-owner", "other"},
+		{"part number", "PUT", strings.Replace(req.URL, "partNumber=2", "partNumber=3", 1), "", ""},
+	}
+	for _, c := range changed {
+		h := presignedHeaders(req)
+		if len(c.header) > 0 {
+			h[c.header] = []string{c.value}
+		}
+		_, err := galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, c.method, c.url, h, time.Now())
+		if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_SIGNATURE_MISMATCH {
+			t.Error(c.name, "should be rejected", err)
+		}
+	}
+	_, err = galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "PUT", req.URL, headers, expiration.Add(time.Second))
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_EXPIRED {
+		t.Error("expected expired", err)
+	}
+}
+
+func Test_Presigned_Upload_Part(t *testing.T) {
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME, "localhost", false, false)
+	initResult := &Model.InitMultipartUploadResult{BucketName: BUCKET_NAME, ObjectName: "big", UploadId: "upload-9"}
+	req, err := localClient.Generate_Presigned_Upload_Part(initResult, 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPut || !strings.Contains(req.URL, "/"+BUCKET_NAME+"/big?") ||
+		!strings.Contains(req.URL, "partNumber=7") || !strings.Contains(req.URL, "uploadId=upload-9") {
+		t.Fatal(req)
+	}
+	if left := time.Until(time.Unix(0, req.Expiration*int64(time.Millisecond))); left <= 0 ||
+		left > galaxy_fds_sdk_golang.DEFAULT_PRESIGN_EXPIRES {
+		t.Error("default expiration", left)
+	}
+	if _, err := galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "PUT", req.URL, nil, time.Now()); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_Presigned_Request_Options(t *testing.T) {
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME, "", true, false)
+	req, err := localClient.Generate_Presigned_Request(BUCKET_NAME, "o", &galaxy_fds_sdk_golang.PresignOptions{
+		Method: "HEAD", Host: galaxy_fds_sdk_golang.PRESIGN_HOST_CDN})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(req.URL, "https://cdn."+REGION_NAME+galaxy_fds_sdk_golang.URI_FDS_CDN_SUFFIX) ||
+		!strings.Contains(req.URL, "metadata") {
+		t.Error(req.URL)
+	}
+	if _, err := galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "HEAD", req.URL, nil, time.Now()); err != nil {
+		t.Error(err)
+	}
+	req, err = localClient.Generate_Presigned_Request(BUCKET_NAME, "o", nil)
+	if err != nil || req.Method != http.MethodGet ||
+		!strings.HasPrefix(req.URL, "https://"+REGION_NAME+galaxy_fds_sdk_golang.URI_FDS_SUFFIX) {
+		t.Error(req, err)
+	}
+
+	invalid := []galaxy_fds_sdk_golang.PresignOptions{
+		{Method: "PATCH"},
+		{Headers: map[string]string{"cache-control": "no-cache"}},
+		{SubResources: map[string]string{"unknown": ""}},
+		{Host: "elsewhere"},
+	}
+	for _, opts := range invalid {
+		opts := opts
+		if _, err := localClient.Generate_Presigned_Request(BUCKET_NAME, "o", &opts); err == nil {
+			t.Error("should be rejected", opts)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package Test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func Test_Quota_Report(t *testing.T) {
+	policy := func(space, objects int64) *Model.QuotaPolicy {
+		p := &Model.QuotaPolicy{}
+		p.SetQuota(Model.QUOTA_TYPE_QPS, Model.QUOTA_ACTION_GET, 10)
+		if space >= 0 {
+			p.SetQuota(Model.QUOTA_TYPE_SPACE, "", space)
+		}
+		if objects >= 0 {
+			p.SetQuota(Model.QUOTA_TYPE_OBJECTS, "", objects)
+		}
+		return p
+	}
+	cases := []struct {
+		name           string
+		space, objects int64 // 已使用
+		policy         *Model.QuotaPolicy
+		warnRatio      float64
+		status         string
+		usages         []string // 每项quota的状态
+	}{
+		{"no quota", 100, 10, policy(-1, -1), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_OK, nil},
+		{"zero is unlimited", 100, 10, policy(0, 0), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_OK, nil},
+		{"below warn", 79, 1, policy(100, 100), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_OK,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_OK, galaxy_fds_sdk_golang.QUOTA_STATUS_OK}},
+		{"default warn ratio", 80, 1, policy(100, 100), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_WARN,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_WARN, galaxy_fds_sdk_golang.QUOTA_STATUS_OK}},
+		{"custom warn ratio", 80, 1, policy(100, 100), 0.9, galaxy_fds_sdk_golang.QUOTA_STATUS_OK,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_OK, galaxy_fds_sdk_golang.QUOTA_STATUS_OK}},
+		{"exactly at quota", 100, 1, policy(100, 100), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_WARN,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_WARN, galaxy_fds_sdk_golang.QUOTA_STATUS_OK}},
+		{"worst status wins", 90, 101, policy(100, 100), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_EXCEEDED,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_WARN, galaxy_fds_sdk_golang.QUOTA_STATUS_EXCEEDED}},
+		{"only objects", 1000, 5, policy(0, 4), 0, galaxy_fds_sdk_golang.QUOTA_STATUS_EXCEEDED,
+			[]string{galaxy_fds_sdk_golang.QUOTA_STATUS_EXCEEDED}},
+	}
+	for _, c := range cases {
+		info := &Model.BucketInfo{BucketName: "b", UsedSpace: c.space, ObjectNum: c.objects}
+		report := galaxy_fds_sdk_golang.NewQuotaReport(info, c.policy, c.warnRatio)
+		if report.Status != c.status || len(report.Usages) != len(c.usages) {
+			t.Error(c.name, report)
+			continue
+		}
+		for i, u := range report.Usages {
+			if u.Status != c.usages[i] || u.Type == Model.QUOTA_TYPE_QPS {
+				t.Error(c.name, i, u)
+			}
+		}
+	}
+
+	info := &Model.BucketInfo{BucketName: "b", UsedSpace: 850}
+	if s := galaxy_fds_sdk_golang.NewQuotaReport(info, policy(1000, -1), 0).String(); s != "b: WARN, WARN SPACE 85.0% (850/1000)" {
+		t.Error(s)
+	}
+}
+
+func Test_Quota_Policy(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	invalid := []Model.Quota{
+		{Type: "", Value: 1},
+		{Type: Model.QUOTA_TYPE_SPACE, Value: -1},
+		{Type: Model.QUOTA_TYPE_QPS, Value: 1},
+	}
+	for _, q := range invalid {
+		if _, err := localClient.Set_Bucket_Quota(BUCKET_NAME, Model.QuotaPolicy{Quotas: []Model.Quota{q}}); err == nil {
+			t.Error("should be rejected", q)
+		}
+	}
+	duplicate := Model.QuotaPolicy{Quotas: []Model.Quota{{Type: "SPACE", Value: 1}, {Type: "SPACE", Value: 2}}}
+	if _, err := localClient.Set_Bucket_Quota(BUCKET_NAME, duplicate); err == nil {
+		t.Error("duplicate quota should be rejected")
+	}
+	if s.count("PUT") != 0 {
+		t.Error("invalid quota should not be sent")
+	}
+
+	policy := Model.QuotaPolicy{}
+	policy.SetQuota(Model.QUOTA_TYPE_SPACE, "", 1000)
+	policy.SetQuota(Model.QUOTA_TYPE_QPS, Model.QUOTA_ACTION_PUT, 10)
+	policy.SetQuota(Model.QUOTA_TYPE_SPACE, "", 2000)
+	if _, err := localClient.Set_Bucket_Quota(BUCKET_NAME, policy); err != nil {
+		t.Fatal(err)
+	}
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.Method == "GET" && r.URL.Path == "/"+BUCKET_NAME && len(r.URL.RawQuery) == 0 {
+			w.Write([]byte(`{"name": "` + BUCKET_NAME + `", "numObjects": 3, "usedSpace": 2500}`))
+			return true
+		}
+		return false
+	})
+	report, err := localClient.Get_Bucket_Quota_Report(BUCKET_NAME, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != galaxy_fds_sdk_golang.QUOTA_STATUS_EXCEEDED || len(report.Usages) != 1 ||
+		report.Usages[0].Limit != 2000 || !strings.HasPrefix(report.String(), BUCKET_NAME+": EXCEEDED") {
+		t.Error(report)
+	}
+}
@@ -0,0 +1,93 @@
+package Test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+// throttleServer 前failures次请求返回status，之后返回200
+type throttleServer struct {
+	mu         sync.Mutex
+	failures   int
+	status     int
+	retryAfter string
+	requests   int
+}
+
+func (s *throttleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if s.requests <= s.failures {
+		if len(s.retryAfter) > 0 {
+			w.Header().Set("Retry-After", s.retryAfter)
+		}
+		w.WriteHeader(s.status)
+		return
+	}
+	w.Write([]byte("ok"))
+}
+
+func Test_Rate_Limit_Retry(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		failures int
+		retries  int
+		want     int
+		requests int
+	}{
+		{"429 retried", http.StatusTooManyRequests, 2, 3, http.StatusOK, 3},
+		{"503 retried", http.StatusServiceUnavailable, 1, 3, http.StatusOK, 2},
+		{"retries exhausted", http.StatusTooManyRequests, 5, 2, http.StatusTooManyRequests, 3},
+		{"500 not retried", http.StatusInternalServerError, 1, 3, http.StatusInternalServerError, 1},
+	}
+	for _, c := range cases {
+		s := &throttleServer{failures: c.failures, status: c.status}
+		ts := httptest.NewServer(s)
+		localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME,
+			strings.TrimPrefix(ts.URL, "http://"), false, false)
+		localClient.RateLimiter = galaxy_fds_sdk_golang.NewRateLimiter(0, 0, 0)
+		localClient.RateLimiter.SetRetryPolicy(c.retries, time.Millisecond, 5*time.Millisecond)
+		res, err := localClient.Auth(galaxy_fds_sdk_golang.FDSAuth{
+			UrlBase: localClient.GetBaseUri() + BUCKET_NAME + "/a",
+			Method:  "GET",
+		})
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != c.want || s.requests != c.requests {
+			t.Error(c.name, res.StatusCode, s.requests)
+		}
+		ts.Close()
+	}
+}
+
+func Test_Rate_Limit_Retry_After(t *testing.T) {
+	limiter := galaxy_fds_sdk_golang.NewRateLimiter(0, 0, 0)
+	limiter.SetRetryPolicy(3, 10*time.Millisecond, 2*time.Second)
+	header := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+	if d := limiter.Throttled(header("1")); d != time.Second {
+		t.Error("seconds form", d)
+	}
+	if d := limiter.Throttled(header("3600")); d != 2*time.Second {
+		t.Error("Retry-After should be capped at MaxBackoff", d)
+	}
+	date := time.Now().Add(time.Second + 500*time.Millisecond).UTC().Format(http.TimeFormat)
+	if d := limiter.Throttled(header(date)); d <= 0 || d > 2*time.Second {
+		t.Error("HTTP-date form", d)
+	}
+	limiter.Succeeded()
+	if d := limiter.Throttled(header("invalid")); d != 10*time.Millisecond {
+		t.Error("invalid Retry-After should use backoff", d)
+	}
+}
@@ -0,0 +1,201 @@
+package Test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func customerKey(t *testing.T, b byte) *Model.SSEOptions {
+	sse, err := Model.NewSSECustomerOptions(bytes.Repeat([]byte{b}, Model.SSE_CUSTOMER_KEY_SIZE))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sse
+}
+
+func Test_SSE_Put_Get(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	key, other := customerKey(t, 1), customerKey(t, 2)
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "c", []byte("secret"), nil, key); err != nil {
+		t.Fatal(err)
+	}
+	stored := s.get(BUCKET_NAME + "/c")
+	if stored.meta[Model.SSECustomerKeyMD5] != key.ReadHeaders()[Model.SSECustomerKeyMD5] ||
+		len(stored.meta[Model.SSECustomerKey]) > 0 {
+		t.Fatal("only the key md5 should be stored", stored.meta)
+	}
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "m", []byte("managed"),
+		Model.NewObjectMetadata().SetContentType("text/plain"), Model.NewSSEOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if m := s.get(BUCKET_NAME + "/m").meta; m[Model.ServerSideEncryption] != Model.SSE_AES256 || m[Model.ContentType] != "text/plain" {
+		t.Error(m)
+	}
+
+	object, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "c", 0, -1, key)
+	if err != nil || string(object.ObjectContent) != "secret" {
+		t.Fatal(err)
+	}
+	reader, err := localClient.Get_Object_Reader_With_SSE(BUCKET_NAME, "c", 2, 3, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(reader)
+	reader.Close()
+	if string(got) != "cre" {
+		t.Error(string(got))
+	}
+	meta, err := localClient.Get_Object_Meta_With_SSE(BUCKET_NAME, "c", key)
+	if err != nil || !meta.IsSSECustomerKey() {
+		t.Fatal(err)
+	}
+	if keyMD5, _ := meta.GetSSECustomerKeyMD5(); keyMD5 != key.ReadHeaders()[Model.SSECustomerKeyMD5] {
+		t.Error(keyMD5)
+	}
+	filename := filepath.Join(t.TempDir(), "c")
+	if _, err := localClient.Download_Object_With_SSE(BUCKET_NAME, "c", filename, key); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(filename); string(b) != "secret" {
+		t.Error(string(b))
+	}
+
+	for name, sse := range map[string]*Model.SSEOptions{"no key": nil, "wrong key": other} {
+		if _, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "c", 0, -1, sse); fdsCode(err) != http.StatusBadRequest {
+			t.Error(name, err)
+		}
+	}
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "x", nil, nil, &Model.SSEOptions{Algorithm: "AES128"}); err == nil {
+		t.Error("invalid algorithm should be rejected")
+	}
+	if _, err := Model.NewSSECustomerOptions([]byte("short")); err == nil {
+		t.Error("short customer key should be rejected")
+	}
+}
+
+func Test_SSE_Multipart(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	key := customerKey(t, 1)
+	initResult, err := localClient.Init_MultiPart_Upload_With_SSE(BUCKET_NAME, "parts", nil, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个分片都需要带上客户密钥
+	if _, err := localClient.Upload_Part(initResult, 1, []byte("a")); fdsCode(err) != http.StatusBadRequest {
+		t.Fatal("part without customer key should be rejected", err)
+	}
+	var parts Model.UploadPartList
+	for i, data := range []string{"first-", "second"} {
+		result, err := localClient.Upload_Part_With_SSE(initResult, i+1, []byte(data), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts.AddUploadPartResult(result)
+	}
+	if _, err := localClient.Complete_Multipart_Upload(initResult, &parts); err != nil {
+		t.Fatal(err)
+	}
+	object, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "parts", 0, -1, key)
+	if err != nil || string(object.ObjectContent) != "first-second" {
+		t.Fatal(err)
+	}
+
+	data := randomBytes(int(galaxy_fds_sdk_golang.MULTIPART_UPLOAD_THRESHOLD) + 10)
+	if err := localClient.Put_Reader_With_SSE(BUCKET_NAME, "big", bytes.NewReader(data), int64(len(data)), nil, key); err != nil {
+		t.Fatal(err)
+	}
+	if s.count("PUT /"+BUCKET_NAME+"/big?partNumber") != 2 {
+		t.Error("should use multipart upload")
+	}
+	object, err = localClient.Get_Object_With_SSE(BUCKET_NAME, "big", 0, -1, key)
+	if err != nil || !bytes.Equal(object.ObjectContent, data) {
+		t.Error(err)
+	}
+}
+
+func Test_SSE_Copy(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	key, other := customerKey(t, 1), customerKey(t, 2)
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "src", []byte("data"), nil, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localClient.Copy_Object_With_SSE(BUCKET_NAME, "src", BUCKET_NAME, "dst", nil, nil); fdsCode(err) != http.StatusBadRequest {
+		t.Fatal("copy without the source key should be rejected", err)
+	}
+	if _, err := localClient.Copy_Object_With_SSE(BUCKET_NAME, "src", BUCKET_NAME, "dst", key, other); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "dst", 0, -1, key); err == nil {
+		t.Error("copy should be encrypted with the destination key")
+	}
+	if object, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "dst", 0, -1, other); err != nil || string(object.ObjectContent) != "data" {
+		t.Error(err)
+	}
+	if _, err := localClient.Copy_Object_With_SSE(BUCKET_NAME, "src", BUCKET_NAME, "plain", key, nil); err != nil {
+		t.Fatal(err)
+	}
+	if m := s.get(BUCKET_NAME + "/plain").meta; len(m[Model.SSECustomerKeyMD5]) > 0 {
+		t.Error("copy without dstSSE should not be encrypted", m)
+	}
+}
+
+func Test_SSE_Append(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	key := customerKey(t, 1)
+	position, err := localClient.Append_Object_With_SSE(BUCKET_NAME, "log", 0, []byte("a"), key)
+	if err != nil || position != 1 {
+		t.Fatal(position, err)
+	}
+	// 没有客户密钥时无法读取metadata，返回服务端的错误
+	if _, err := localClient.Append_Object(BUCKET_NAME, "log", 1, []byte("b")); fdsCode(err) != http.StatusBadRequest {
+		t.Fatal("customer key object should need Append_Object_With_SSE", err)
+	}
+	// 服务端不带密钥也返回metadata时，通过metadata中的加密算法拒绝
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if requestLine(r) != "GET /"+BUCKET_NAME+"/log?metadata" {
+			return false
+		}
+		for k, v := range s.objects[BUCKET_NAME+"/log"].meta {
+			w.Header().Set(k, v)
+		}
+		return true
+	})
+	if _, err := localClient.Append_Object(BUCKET_NAME, "log", 1, []byte("b")); fdsCode(err) != http.StatusBadRequest ||
+		!strings.Contains(err.Error(), "Append_Object_With_SSE") {
+		t.Fatal("customer key object should need Append_Object_With_SSE", err)
+	}
+	s.setHook(nil)
+	if string(s.get(BUCKET_NAME+"/log").data) != "a" || s.count("PUT /"+BUCKET_NAME+"/log") != 1 {
+		t.Fatal("object should not be rewritten")
+	}
+	w, err := localClient.Append_Writer_With_SSE(BUCKET_NAME, "log", 0, key)
+	if err != nil || w.Position() != 1 {
+		t.Fatal(err)
+	}
+	w.Write([]byte("bc"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	object, err := localClient.Get_Object_With_SSE(BUCKET_NAME, "log", 0, -1, key)
+	if err != nil || string(object.ObjectContent) != "abc" {
+		t.Fatal(err)
+	}
+
+	// 服务端管理密钥的加密在重写后保留
+	if _, err := localClient.Put_Object_With_SSE(BUCKET_NAME, "m", []byte("a"), nil, Model.NewSSEOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := localClient.Append_Object(BUCKET_NAME, "m", 1, []byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if o := s.get(BUCKET_NAME + "/m"); string(o.data) != "ab" || o.meta[Model.ServerSideEncryption] != Model.SSE_AES256 {
+		t.Error(string(o.data), o.meta)
+	}
+}
@@ -0,0 +1,136 @@
+package Test
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(b)
+	return b
+}
+
+func Test_Sync_Upload_Plan(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a/b/x.txt": "hello", "y.log": "log", "same.txt": "same", "md5.txt": "abcd"})
+	s.put(BUCKET_NAME+"/site/old.txt", []byte("old"), nil)
+	s.put(BUCKET_NAME+"/site/same.txt", []byte("same"), nil)
+	s.put(BUCKET_NAME+"/site/md5.txt", []byte("dcba"), nil)
+
+	opts := &galaxy_fds_sdk_golang.SyncOptions{Delete: true, Exclude: []string{"*.log"}, DryRun: true}
+	res, err := localClient.Sync(dir, BUCKET_NAME, "site", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []galaxy_fds_sdk_golang.SyncAction{
+		{Action: galaxy_fds_sdk_golang.SYNC_ACTION_UPLOAD, Key: "site/a/b/x.txt", Reason: "new"},
+		{Action: galaxy_fds_sdk_golang.SYNC_ACTION_UPLOAD, Key: "site/md5.txt", Reason: "md5 differs"},
+		{Action: galaxy_fds_sdk_golang.SYNC_ACTION_DELETE, Key: "site/old.txt", Reason: "not in source"},
+	}
+	if len(res.Actions) != len(want) || res.Skipped != 1 {
+		t.Fatal(res.Actions, res.Skipped)
+	}
+	for i, a := range res.Actions {
+		if a.Action != want[i].Action || a.Key != want[i].Key || a.Reason != want[i].Reason {
+			t.Error(i, a)
+		}
+	}
+	if s.count("PUT") != 0 || s.get(BUCKET_NAME+"/site/old.txt") == nil {
+		t.Error("dry run should not modify the bucket")
+	}
+
+	opts.DryRun = false
+	if _, err := localClient.Sync(dir, BUCKET_NAME, "site", opts); err != nil {
+		t.Fatal(err)
+	}
+	if o := s.get(BUCKET_NAME + "/site/md5.txt"); o == nil || string(o.data) != "abcd" {
+		t.Error("md5.txt should be uploaded")
+	}
+	if s.get(BUCKET_NAME+"/site/old.txt") != nil || s.get(BUCKET_NAME+"/site/y.log") != nil {
+		t.Error("old.txt should be deleted and y.log excluded")
+	}
+	res, err = localClient.Sync(dir, BUCKET_NAME, "site", opts)
+	if err != nil || len(res.Actions) != 0 || res.Skipped != 3 {
+		t.Error(err, res)
+	}
+}
+
+func Test_Sync_Download_Unsafe_Key(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	root := t.TempDir()
+	dir := filepath.Join(root, "dst")
+	s.put(BUCKET_NAME+"/site/a/x.txt", []byte("hello"), nil)
+	s.put(BUCKET_NAME+"/site/../evil.txt", []byte("evil"), nil)
+	s.put(BUCKET_NAME+"/site/a/../../../evil.txt", []byte("evil"), nil)
+
+	opts := &galaxy_fds_sdk_golang.SyncOptions{Direction: galaxy_fds_sdk_golang.SYNC_DOWNLOAD}
+	res, err := localClient.Sync(dir, BUCKET_NAME, "site", opts)
+	if err == nil || res == nil {
+		t.Fatal("unsafe keys should be reported", err)
+	}
+	if len(res.Failed) != 2 || res.Failed[0].Key != "site/../evil.txt" || res.Failed[1].Key != "site/a/../../../evil.txt" {
+		t.Fatal(res.Failed)
+	}
+	if len(res.Actions) != 1 || res.Actions[0].Key != "site/a/x.txt" {
+		t.Fatal(res.Actions)
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "a", "x.txt")); err != nil || string(b) != "hello" {
+		t.Error(string(b), err)
+	}
+	for _, name := range []string{filepath.Join(root, "evil.txt"), filepath.Join(filepath.Dir(root), "evil.txt")} {
+		if _, err := os.Stat(name); err == nil {
+			t.Error("written outside the local directory:", name)
+		}
+	}
+}
+
+func Test_Sync_Upload_Multipart_Abort(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	dir := t.TempDir()
+	data := randomBytes(int(galaxy_fds_sdk_golang.MULTIPART_UPLOAD_THRESHOLD) + 10)
+	if err := os.WriteFile(filepath.Join(dir, "big"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 第二个分片上传失败时abort整个上传
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Get("partNumber") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		return false
+	})
+	if _, err := localClient.Sync(dir, BUCKET_NAME, "site", nil); err == nil {
+		t.Fatal("failed part should fail the sync")
+	}
+	if s.count("DELETE /"+BUCKET_NAME+"/site/big?uploadId") != 1 || len(s.uploads) != 0 || s.get(BUCKET_NAME+"/site/big") != nil {
+		t.Fatal("upload should be aborted", s.uploads)
+	}
+
+	s.setHook(nil)
+	if _, err := localClient.Sync(dir, BUCKET_NAME, "site", nil); err != nil {
+		t.Fatal(err)
+	}
+	if o := s.get(BUCKET_NAME + "/site/big"); o == nil || !bytes.Equal(o.data, data) || s.count("PUT /"+BUCKET_NAME+"/site/big?partNumber") != 4 {
+		t.Error("multipart upload should succeed")
+	}
+}
@@ -0,0 +1,189 @@
+package Test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+// trashObject 将object放入回收站，modified为进入回收站的时间
+func (s *fdsServer) trashObject(key, content string, modified time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trash[key] = &fdsObject{data: []byte(content), meta: map[string]string{}, modified: modified}
+}
+
+func Test_Trash_Iterator(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	n := galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS + 1
+	for i := 0; i < n; i++ {
+		s.trashObject(fmt.Sprintf("%s/p/%04d", BUCKET_NAME, i), "x", time.Now())
+	}
+	s.trashObject("other/p/0", "x", time.Now())
+
+	it := localClient.Trash_Iterator(BUCKET_NAME + "/p/")
+	count := 0
+	for it.Next() {
+		o := it.Object()
+		if o.BucketName != BUCKET_NAME || o.ObjectName != fmt.Sprintf("p/%04d", count) {
+			t.Fatal(o)
+		}
+		count++
+	}
+	if it.Err() != nil || count != n || s.count("GET /trash") != 2 {
+		t.Error(count, it.Err(), s.count("GET /trash"))
+	}
+
+	listing, err := localClient.List_Trash_Object(BUCKET_NAME+"/p/", "", 600)
+	if err != nil || !listing.Truncated {
+		t.Fatal(listing, err)
+	}
+	listing, err = localClient.List_Next_Batch_Of_Trash_Objects(listing)
+	if err != nil || listing.Truncated || len(listing.ObjectSummaries) != n-600 {
+		t.Fatal(err)
+	}
+	if _, err := localClient.List_Next_Batch_Of_Trash_Objects(listing); err == nil {
+		t.Error("last page should have no next batch")
+	}
+}
+
+func Test_Restore_Trash_Objects(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	s.trashObject(BUCKET_NAME+"/p/new", "new", time.Now())
+	s.trashObject(BUCKET_NAME+"/p/skip", "trash", time.Now())
+	s.put(BUCKET_NAME+"/p/skip", []byte("current"), nil)
+
+	opts := &galaxy_fds_sdk_golang.RestoreOptions{DryRun: true}
+	res, err := localClient.Restore_Trash_Objects(BUCKET_NAME, "p/", opts)
+	if err != nil || res.Restored != 1 || res.Skipped != 1 || s.count("PUT") != 0 {
+		t.Fatal(res, err)
+	}
+	var progress []string
+	opts = &galaxy_fds_sdk_golang.RestoreOptions{Progress: func(a galaxy_fds_sdk_golang.TrashAction) {
+		progress = append(progress, a.Action+" "+a.ObjectName)
+	}}
+	res, err = localClient.Restore_Trash_Objects(BUCKET_NAME, "p/", opts)
+	if err != nil || res.Restored != 1 || res.Skipped != 1 || strings.Join(progress, ",") != "restore p/new,skip p/skip" {
+		t.Fatal(res, progress, err)
+	}
+	if string(s.get(BUCKET_NAME+"/p/new").data) != "new" || string(s.get(BUCKET_NAME+"/p/skip").data) != "current" {
+		t.Error("restore with skip")
+	}
+
+	res, err = localClient.Restore_Trash_Objects(BUCKET_NAME, "p/", &galaxy_fds_sdk_golang.RestoreOptions{
+		Conflict: galaxy_fds_sdk_golang.RESTORE_CONFLICT_OVERWRITE})
+	if err != nil || res.Restored != 1 || string(s.get(BUCKET_NAME+"/p/skip").data) != "trash" {
+		t.Fatal(res, err)
+	}
+
+	s.trashObject(BUCKET_NAME+"/p/skip", "again", time.Now())
+	s.put(BUCKET_NAME+"/p/skip.restored", []byte("taken"), nil)
+	res, err = localClient.Restore_Trash_Objects(BUCKET_NAME, "p/", &galaxy_fds_sdk_golang.RestoreOptions{
+		Conflict: galaxy_fds_sdk_golang.RESTORE_CONFLICT_RENAME})
+	if err != nil || res.Restored != 1 || res.Actions[0].Target != "p/skip.restored-1" {
+		t.Fatal(res, err)
+	}
+	if string(s.get(BUCKET_NAME+"/p/skip").data) != "trash" || string(s.get(BUCKET_NAME+"/p/skip.restored-1").data) != "again" ||
+		string(s.get(BUCKET_NAME+"/p/skip.restored").data) != "taken" {
+		t.Error("restore with rename")
+	}
+	if _, err := localClient.Restore_Trash_Objects(BUCKET_NAME, "p/", &galaxy_fds_sdk_golang.RestoreOptions{
+		Conflict: "merge"}); err == nil {
+		t.Error("invalid conflict policy should be rejected")
+	}
+}
+
+// Test_Restore_Trash_Rename_Failures 检查RESTORE_CONFLICT_RENAME时改名、恢复、再改名三步中每一步失败的处理
+func Test_Restore_Trash_Rename_Failures(t *testing.T) {
+	cases := []struct {
+		name string
+		fail func(r *http.Request) bool
+		// 失败后原位置和回收站中的内容，以及错误信息
+		current, trash, target, message string
+	}{
+		{"rename current away", func(r *http.Request) bool {
+			return r.URL.Path == "/"+BUCKET_NAME+"/a" && r.URL.Query().Has("renameTo")
+		}, "current", "trash", "", "[500]"},
+		{"restore", func(r *http.Request) bool {
+			return r.URL.Query().Has("restore")
+		}, "current", "trash", "", "[500]"},
+		{"rename restored to target", func(r *http.Request) bool {
+			return r.URL.Query().Get("renameTo") == "a.restored"
+		}, "trash", "", "", "original object kept as a.restoring-"},
+		{"move current back", func(r *http.Request) bool {
+			return strings.HasPrefix(r.URL.Path, "/"+BUCKET_NAME+"/a.restoring-")
+		}, "", "", "trash", "failed to move a.restoring-"},
+	}
+	for _, c := range cases {
+		s, localClient := newFDSServer(t)
+		s.trashObject(BUCKET_NAME+"/a", "trash", time.Now())
+		s.put(BUCKET_NAME+"/a", []byte("current"), nil)
+		s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+			if c.fail(r) {
+				w.WriteHeader(http.StatusInternalServerError)
+				return true
+			}
+			return false
+		})
+		res, err := localClient.Restore_Trash_Objects(BUCKET_NAME, "a", &galaxy_fds_sdk_golang.RestoreOptions{
+			Conflict: galaxy_fds_sdk_golang.RESTORE_CONFLICT_RENAME})
+		if err == nil || len(res.Failed) != 1 || !strings.Contains(res.Failed[0].Err.Error(), c.message) {
+			t.Error(c.name, res, err)
+			continue
+		}
+		content := func(key string) string {
+			if o := s.get(key); o != nil {
+				return string(o.data)
+			}
+			return ""
+		}
+		s.mu.Lock()
+		trash := ""
+		if o, ok := s.trash[BUCKET_NAME+"/a"]; ok {
+			trash = string(o.data)
+		}
+		s.mu.Unlock()
+		if content(BUCKET_NAME+"/a") != c.current || trash != c.trash || content(BUCKET_NAME+"/a.restored") != c.target {
+			t.Error(c.name, content(BUCKET_NAME+"/a"), trash, content(BUCKET_NAME+"/a.restored"))
+		}
+	}
+}
+
+func Test_Purge_Trash(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	now := time.Now().Truncate(time.Second)
+	s.trashObject(BUCKET_NAME+"/old", "x", now.Add(-48*time.Hour))
+	s.trashObject(BUCKET_NAME+"/new", "x", now)
+	s.trashObject("other/old", "x", now.Add(-48*time.Hour))
+
+	res, err := localClient.Purge_Trash(BUCKET_NAME+"/", now.Add(-24*time.Hour), true)
+	if err != nil || res.Purged != 1 || res.Actions[0].ObjectName != "old" || s.count("DELETE") != 0 {
+		t.Fatal(res, err)
+	}
+	res, err = localClient.Purge_Trash(BUCKET_NAME+"/", now.Add(-24*time.Hour), false)
+	if err != nil || res.Purged != 1 {
+		t.Fatal(res, err)
+	}
+	s.mu.Lock()
+	_, old := s.trash[BUCKET_NAME+"/old"]
+	_, other := s.trash["other/old"]
+	remaining := len(s.trash)
+	s.mu.Unlock()
+	if old || !other || remaining != 2 {
+		t.Error("only old objects under the prefix should be purged")
+	}
+
+	if err := localClient.Purge_Trash_Object(BUCKET_NAME, "missing"); err == nil {
+		t.Error("missing object should fail")
+	}
+	if err := localClient.Purge_Trash_Object(BUCKET_NAME, ""); err == nil {
+		t.Error("empty object name should be rejected")
+	}
+	if err := localClient.Purge_Trash_Object("other", "old"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,141 @@
+package Test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func lookupSecret(accessKeyId string) (string, bool) {
+	if accessKeyId == APP_KEY {
+		return SECRET_KEY, true
+	}
+	return "", false
+}
+
+// verifyServer 校验签名后返回固定内容，失败时在header中返回原因
+func verifyServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := galaxy_fds_sdk_golang.VerifyRequest(lookupSecret, r, time.Now())
+		var verifyErr *galaxy_fds_sdk_golang.VerifyError
+		if errors.As(err, &verifyErr) {
+			w.Header().Set("x-verify-reason", string(verifyErr.Reason))
+			http.Error(w, err.Error(), verifyErr.StatusCode())
+			return
+		}
+		w.Write([]byte("verified"))
+	}))
+}
+
+func verifyReason(err error) galaxy_fds_sdk_golang.VerifyReason {
+	var verifyErr *galaxy_fds_sdk_golang.VerifyError
+	if errors.As(err, &verifyErr) {
+		return verifyErr.Reason
+	}
+	return ""
+}
+
+func Test_Verify_Authorization_Header(t *testing.T) {
+	ts := verifyServer()
+	defer ts.Close()
+	endpoint := strings.TrimPrefix(ts.URL, "http://")
+
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME, endpoint, false, false)
+	object, err := localClient.Get_Object(BUCKET_NAME, "a/b c.txt", 0, -1)
+	if err != nil || string(object.ObjectContent) != "verified" {
+		t.Error("signed request should be verified", err)
+	}
+
+	wrongSecret := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, "wrong", REGION_NAME, endpoint, false, false)
+	_, err = wrongSecret.Get_Object(BUCKET_NAME, "a.txt", 0, -1)
+	var fdsErr *Model.FDSError
+	if !errors.As(err, &fdsErr) || fdsErr.Code() != http.StatusForbidden {
+		t.Error("wrong secret should be rejected", err)
+	}
+
+	headers := map[string][]string{
+		"date":          {time.Now().Add(-time.Hour).Format(time.RFC1123)},
+		"authorization": {"Galaxy-V2 " + APP_KEY + ":c2lnbmF0dXJl"},
+	}
+	_, err = galaxy_fds_sdk_golang.VerifyAuthorizationHeader(lookupSecret, "GET", "/b/o", headers, time.Now())
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_CLOCK_SKEW {
+		t.Error("expected clock skew", err)
+	}
+	headers["authorization"] = []string{"Galaxy-V2 unknown:c2lnbmF0dXJl"}
+	headers["date"] = []string{time.Now().Format(time.RFC1123)}
+	_, err = galaxy_fds_sdk_golang.VerifyAuthorizationHeader(lookupSecret, "GET", "/b/o", headers, time.Now())
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_UNKNOWN_ACCESS_KEY {
+		t.Error("expected unknown access key", err)
+	}
+}
+
+func Test_Verify_Presigned_URL(t *testing.T) {
+	ts := verifyServer()
+	defer ts.Close()
+	localClient := galaxy_fds_sdk_golang.NEWFDSClient(APP_KEY, SECRET_KEY, REGION_NAME,
+		strings.TrimPrefix(ts.URL, "http://"), false, false)
+
+	expiration := time.Now().Add(time.Minute).UnixNano() / int64(time.Millisecond)
+	for i := 0; i < 20; i++ {
+		// Generate_Presigned_URI生成的Signature没有转义，多生成几次以覆盖包含+和/的签名
+		u, err := localClient.Generate_Presigned_URI(BUCKET_NAME, "o"+string(rune('a'+i)), "GET", expiration, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "GET", u, nil, time.Now()); err != nil {
+			t.Fatal(u, err)
+		}
+	}
+
+	req, err := localClient.Generate_Presigned_Request(BUCKET_NAME, "upload.png", &galaxy_fds_sdk_golang.PresignOptions{
+		Method: "PUT", ContentType: "image/png", Headers: map[string]string{"x-xiaomi-meta-owner": "me"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	send := func(contentType string) *http.Response {
+		r, _ := http.NewRequest(req.Method, req.URL, strings.NewReader("data"))
+		for k, v := range req.Headers {
+			r.Header.Set(k, v)
+		}
+		r.Header.Set("content-type", contentType)
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	if res := send("image/png"); res.StatusCode != http.StatusOK {
+		t.Error("presigned request should be verified", res.Status)
+	}
+	if res := send("text/plain"); res.Header.Get("x-verify-reason") != string(galaxy_fds_sdk_golang.VERIFY_SIGNATURE_MISMATCH) {
+		t.Error("changed content type should be rejected", res.Status)
+	}
+
+	expired, _ := localClient.Generate_Presigned_URI(BUCKET_NAME, "o", "GET",
+		time.Now().Add(-time.Hour).UnixNano()/int64(time.Millisecond), nil)
+	_, err = galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "GET", expired, nil, time.Now())
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_EXPIRED {
+		t.Error("expected expired", err)
+	}
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	presigned, _ := localClient.Generate_Presigned_URI(BUCKET_NAME, "o", "GET",
+		expiresAt.UnixNano()/int64(time.Millisecond), nil)
+	if _, err = galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "GET", presigned, nil, expiresAt); err != nil {
+		t.Error("url should be valid until Expires", err)
+	}
+	_, err = galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "GET", presigned, nil, expiresAt.Add(time.Second))
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_EXPIRED {
+		t.Error("url should expire one second after Expires", err)
+	}
+	_, err = galaxy_fds_sdk_golang.VerifyPresignedURL(lookupSecret, "GET", ts.URL+"/b/o", nil, time.Now())
+	if verifyReason(err) != galaxy_fds_sdk_golang.VERIFY_MISSING_SIGNATURE {
+		t.Error("expected missing signature", err)
+	}
+}
@@ -0,0 +1,126 @@
+package Test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+func Test_Versioning(t *testing.T) {
+	_, localClient := newFDSServer(t)
+	if status, err := localClient.Get_Bucket_Versioning(BUCKET_NAME); err != nil || status != "" {
+		t.Fatal(status, err)
+	}
+	if _, err := localClient.Set_Bucket_Versioning(BUCKET_NAME, "On"); err == nil {
+		t.Error("invalid status should be rejected")
+	}
+	if _, err := localClient.Set_Bucket_Versioning(BUCKET_NAME, Model.VERSIONING_ENABLED); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := localClient.Get_Bucket_Versioning(BUCKET_NAME); status != Model.VERSIONING_ENABLED {
+		t.Fatal(status)
+	}
+	for _, content := range []string{"one", "two", "three"} {
+		if _, err := localClient.Put_Object(BUCKET_NAME, "a", []byte(content), "text/plain", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	localClient.Put_Object(BUCKET_NAME, "b", []byte("b"), "", nil)
+
+	listing, err := localClient.List_Object_Versions(BUCKET_NAME, "", "", 2)
+	if err != nil || !listing.Truncated || len(listing.Versions) != 2 {
+		t.Fatal(listing, err)
+	}
+	versions := listing.Versions
+	listing, err = localClient.List_Next_Batch_Of_Object_Versions(listing)
+	if err != nil || listing.Truncated {
+		t.Fatal(listing, err)
+	}
+	versions = append(versions, listing.Versions...)
+	want := []string{"a v3", "a v2", "a v1", "b v1"}
+	if len(versions) != len(want) || !versions[0].IsLatest || versions[1].IsLatest {
+		t.Fatal(versions)
+	}
+	for i, v := range versions {
+		if v.ObjectName+" "+v.VersionId != want[i] {
+			t.Error(i, v)
+		}
+	}
+	if _, err := localClient.List_Next_Batch_Of_Object_Versions(listing); err == nil {
+		t.Error("last page should have no next batch")
+	}
+
+	object, err := localClient.Get_Object_Version(BUCKET_NAME, "a", "v2", 0, -1)
+	if err != nil || string(object.ObjectContent) != "two" {
+		t.Fatal(object, err)
+	}
+	reader, err := localClient.Get_Object_Version_Reader(BUCKET_NAME, "a", "v1", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(*reader)
+	(*reader).Close()
+	if string(data) != "ne" {
+		t.Error(string(data))
+	}
+	meta, err := localClient.Get_Object_Version_Meta(BUCKET_NAME, "a", "v1")
+	if id, _ := meta.GetKey(Model.VersionId); err != nil || id != "v1" {
+		t.Error(id, err)
+	}
+	if _, err := localClient.Get_Object_Version(BUCKET_NAME, "a", "", 0, -1); err == nil {
+		t.Error("empty version id should be rejected")
+	}
+
+	if _, err := localClient.Delete_Object_Version(BUCKET_NAME, "a", "v2"); err != nil {
+		t.Fatal(err)
+	}
+	listing, _ = localClient.List_Object_Versions(BUCKET_NAME, "a", "", 10)
+	if len(listing.Versions) != 2 || listing.Versions[1].VersionId != "v1" {
+		t.Error(listing.Versions)
+	}
+}
+
+func Test_Restore_Object_Version(t *testing.T) {
+	s, localClient := newFDSServer(t)
+	localClient.Set_Bucket_Versioning(BUCKET_NAME, Model.VERSIONING_ENABLED)
+	headers := map[string]string{"x-xiaomi-meta-k": "old"}
+	localClient.Put_Object(BUCKET_NAME, "a", []byte("one"), "text/plain", &headers)
+	localClient.Put_Object(BUCKET_NAME, "a", []byte("two"), "", nil)
+
+	if _, err := localClient.Restore_Object_Version(BUCKET_NAME, "a", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if o := s.get(BUCKET_NAME + "/a"); string(o.data) != "one" || o.versionId != "v3" || o.meta["x-xiaomi-meta-k"] != "old" {
+		t.Fatal(string(o.data), o.versionId, o.meta)
+	}
+
+	// 权限等错误直接返回，不会改为下载后上传
+	status := http.StatusForbidden
+	s.setHook(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Query().Has("cpFrom") {
+			w.WriteHeader(status)
+			return true
+		}
+		return false
+	})
+	if _, err := localClient.Restore_Object_Version(BUCKET_NAME, "a", "v2"); err == nil {
+		t.Fatal("403 should be returned")
+	}
+	if s.count("GET /"+BUCKET_NAME+"/a") != 0 || string(s.get(BUCKET_NAME+"/a").data) != "one" {
+		t.Fatal("should not fall back on 403")
+	}
+
+	status = http.StatusNotImplemented
+	if _, err := localClient.Restore_Object_Version(BUCKET_NAME, "a", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	o := s.get(BUCKET_NAME + "/a")
+	if string(o.data) != "one" || o.versionId != "v4" || o.meta["content-type"] != "text/plain" || o.meta["x-xiaomi-meta-k"] != "old" {
+		t.Error(string(o.data), o.versionId, o.meta)
+	}
+	if s.count("GET /"+BUCKET_NAME+"/a?versionId=v1") != 1 {
+		t.Error("version should be downloaded when copy is unsupported")
+	}
+}
@@ -0,0 +1,275 @@
+// Package acl 提供构造Model.ACL的builder、授权校验、ACL比较、按读-改-写方式增删单条授权的辅助函数，
+// 以及对前缀下所有object批量添加或删除授权的ApplyToPrefix
+package acl
+
+import (
+	"sort"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// 权限
+const (
+	READ         = galaxy_fds_sdk_golang.PERMISSION_READ
+	WRITE        = galaxy_fds_sdk_golang.PERMISSION_WRITE
+	READ_OBJECTS = "READ_OBJECTS"
+	SSO_WRITE    = "SSO_WRITE"
+	FULL_CONTROL = galaxy_fds_sdk_golang.PERMISSION_FULL_CONTROL
+)
+
+// 授权对象的类型
+const (
+	USER  = galaxy_fds_sdk_golang.PERMISSION_USER
+	GROUP = galaxy_fds_sdk_golang.PERMISSION_GROUP
+)
+
+// GROUP类型的授权对象
+const (
+	AllUsers           = "ALL_USERS"
+	AuthenticatedUsers = "AUTHENTICATED_USERS"
+)
+
+var permissions = map[string]bool{READ: true, WRITE: true, READ_OBJECTS: true, SSO_WRITE: true, FULL_CONTROL: true}
+
+var groups = map[string]bool{AllUsers: true, AuthenticatedUsers: true}
+
+// Grant 返回一条授权
+func Grant(granteeType, id, permission string) Model.AccessControlList {
+	return Model.AccessControlList{Grantees: Model.Grantee{Id: id}, Permission: permission, Type: granteeType}
+}
+
+// ValidateGrant 检查授权的类型、对象和权限是否合法
+func ValidateGrant(grant Model.AccessControlList) error {
+	if !permissions[grant.Permission] {
+		return Model.NewFDSError("invalid permission: "+grant.Permission, -1)
+	}
+	switch grant.Type {
+	case USER:
+		if len(grant.Grantees.Id) == 0 {
+			return Model.NewFDSError("grantee id is required", -1)
+		}
+	case GROUP:
+		if !groups[grant.Grantees.Id] {
+			return Model.NewFDSError("invalid group: "+grant.Grantees.Id, -1)
+		}
+	default:
+		return Model.NewFDSError("invalid grantee type: "+grant.Type, -1)
+	}
+	return nil
+}
+
+// Validate 检查ACL中的每一条授权
+func Validate(acl *Model.ACL) error {
+	for _, grant := range acl.AccessControlLists {
+		if err := ValidateGrant(grant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builder 链式构造ACL，重复的授权只保留一条，错误在Build时返回
+type Builder struct {
+	acl Model.ACL
+	err error
+}
+
+// New 返回空的Builder，例如 acl.New().GrantUser(id, acl.READ).GrantGroup(acl.AllUsers, acl.READ).Build()
+func New() *Builder {
+	return &Builder{}
+}
+
+// Owner 设置ACL的owner
+func (b *Builder) Owner(id string) *Builder {
+	b.acl.Owners = Model.Owner{Id: id}
+	return b
+}
+
+// Grant 添加一条授权
+func (b *Builder) Grant(grant Model.AccessControlList) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if err := ValidateGrant(grant); err != nil {
+		b.err = err
+		return b
+	}
+	if indexOf(b.acl.AccessControlLists, grant) < 0 {
+		b.acl.AccessControlLists = append(b.acl.AccessControlLists, grant)
+	}
+	return b
+}
+
+// GrantUser 为用户(app id)授权
+func (b *Builder) GrantUser(id string, permissions ...string) *Builder {
+	for _, permission := range permissions {
+		b.Grant(Grant(USER, id, permission))
+	}
+	return b
+}
+
+// GrantGroup 为AllUsers或AuthenticatedUsers授权
+func (b *Builder) GrantGroup(id string, permissions ...string) *Builder {
+	for _, permission := range permissions {
+		b.Grant(Grant(GROUP, id, permission))
+	}
+	return b
+}
+
+// Build 返回构造的ACL，有不合法的授权时返回第一个错误
+func (b *Builder) Build() (*Model.ACL, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	acl := b.acl
+	acl.AccessControlLists = append([]Model.AccessControlList(nil), b.acl.AccessControlLists...)
+	return &acl, nil
+}
+
+func sameGrant(a, b Model.AccessControlList) bool {
+	return a.Type == b.Type && a.Grantees.Id == b.Grantees.Id && a.Permission == b.Permission
+}
+
+func indexOf(grants []Model.AccessControlList, grant Model.AccessControlList) int {
+	for i, g := range grants {
+		if sameGrant(g, grant) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Change 两个ACL之间的差异
+type Change struct {
+	Added   []Model.AccessControlList
+	Removed []Model.AccessControlList
+}
+
+// Empty 两个ACL的授权是否相同
+func (c Change) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// Diff 比较两个ACL的授权列表，返回to相对from新增和删除的授权，忽略owner和displayName
+func Diff(from, to *Model.ACL) Change {
+	var fromGrants, toGrants []Model.AccessControlList
+	if from != nil {
+		fromGrants = from.AccessControlLists
+	}
+	if to != nil {
+		toGrants = to.AccessControlLists
+	}
+	change := Change{}
+	for _, g := range toGrants {
+		if indexOf(fromGrants, g) < 0 && indexOf(change.Added, g) < 0 {
+			change.Added = append(change.Added, g)
+		}
+	}
+	for _, g := range fromGrants {
+		if indexOf(toGrants, g) < 0 && indexOf(change.Removed, g) < 0 {
+			change.Removed = append(change.Removed, g)
+		}
+	}
+	sortGrants(change.Added)
+	sortGrants(change.Removed)
+	return change
+}
+
+func sortGrants(grants []Model.AccessControlList) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Grantees.Id != b.Grantees.Id {
+			return a.Grantees.Id < b.Grantees.Id
+		}
+		return a.Permission < b.Permission
+	})
+}
+
+// target 抽象object和bucket的ACL读写
+type target struct {
+	get    func() (*Model.ACL, error)
+	set    func(Model.ACL) (bool, error)
+	delete func(Model.ACL) (bool, error)
+}
+
+func objectTarget(c *galaxy_fds_sdk_golang.FDSClient, bucketname, objectname string) target {
+	return target{
+		get:    func() (*Model.ACL, error) { return c.Get_Object_ACL(bucketname, objectname) },
+		set:    func(acl Model.ACL) (bool, error) { return c.Set_Object_Acl_New(bucketname, objectname, acl) },
+		delete: func(acl Model.ACL) (bool, error) { return c.Delete_Object_ACL(bucketname, objectname, acl) },
+	}
+}
+
+func bucketTarget(c *galaxy_fds_sdk_golang.FDSClient, bucketname string) target {
+	return target{
+		get:    func() (*Model.ACL, error) { return c.Get_Bucket_ACL(bucketname) },
+		set:    func(acl Model.ACL) (bool, error) { return c.Set_Bucket_ACL(bucketname, acl) },
+		delete: func(acl Model.ACL) (bool, error) { return c.Delete_Bucket_ACL(bucketname, acl) },
+	}
+}
+
+// update 读取当前ACL，revoke为false时添加其中缺少的授权，为true时删除其中存在的授权，
+// 返回实际变化的授权，没有变化时不写回。dryRun为true时只计算变化
+func (t target) update(grants []Model.AccessControlList, revoke, dryRun bool) ([]Model.AccessControlList, error) {
+	for _, grant := range grants {
+		if err := ValidateGrant(grant); err != nil {
+			return nil, err
+		}
+	}
+	current, err := t.get()
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		current = &Model.ACL{}
+	}
+	var changed []Model.AccessControlList
+	for _, grant := range grants {
+		if (indexOf(current.AccessControlLists, grant) >= 0) == revoke && indexOf(changed, grant) < 0 {
+			changed = append(changed, grant)
+		}
+	}
+	if len(changed) == 0 || dryRun {
+		return changed, nil
+	}
+	if revoke {
+		_, err = t.delete(Model.ACL{AccessControlLists: changed})
+	} else {
+		merged := *current
+		merged.AccessControlLists = append(append([]Model.AccessControlList(nil),
+			current.AccessControlLists...), changed...)
+		_, err = t.set(merged)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// AddObjectGrants 为object添加授权，已经存在的授权不会重复设置，返回实际新增的授权
+func AddObjectGrants(c *galaxy_fds_sdk_golang.FDSClient, bucketname, objectname string,
+	grants ...Model.AccessControlList) ([]Model.AccessControlList, error) {
+	return objectTarget(c, bucketname, objectname).update(grants, false, false)
+}
+
+// RemoveObjectGrants 删除object的授权，不存在的授权会被忽略，返回实际删除的授权
+func RemoveObjectGrants(c *galaxy_fds_sdk_golang.FDSClient, bucketname, objectname string,
+	grants ...Model.AccessControlList) ([]Model.AccessControlList, error) {
+	return objectTarget(c, bucketname, objectname).update(grants, true, false)
+}
+
+// AddBucketGrants 为bucket添加授权，已经存在的授权不会重复设置，返回实际新增的授权
+func AddBucketGrants(c *galaxy_fds_sdk_golang.FDSClient, bucketname string,
+	grants ...Model.AccessControlList) ([]Model.AccessControlList, error) {
+	return bucketTarget(c, bucketname).update(grants, false, false)
+}
+
+// RemoveBucketGrants 删除bucket的授权，不存在的授权会被忽略，返回实际删除的授权
+func RemoveBucketGrants(c *galaxy_fds_sdk_golang.FDSClient, bucketname string,
+	grants ...Model.AccessControlList) ([]Model.AccessControlList, error) {
+	return bucketTarget(c, bucketname).update(grants, true, false)
+}
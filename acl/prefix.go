@@ -0,0 +1,136 @@
+package acl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const DEFAULT_PREFIX_CONCURRENCY = 8
+
+// PrefixOptions ApplyToPrefix的参数
+type PrefixOptions struct {
+	Grants []Model.AccessControlList
+	// Revoke 为true时删除授权，否则添加授权
+	Revoke      bool
+	Concurrency int
+	// DryRun 为true时只读取每个object的ACL并返回将要发生的变化
+	DryRun bool
+	// UpdateCDN 为true时，添加授权后预取到CDN(与Set_Public相同)，删除授权后刷新CDN缓存
+	UpdateCDN bool
+	// Progress 每处理完一个object调用一次，不会并发调用
+	Progress func(done, total int, result ObjectResult)
+}
+
+// ObjectResult 单个object的处理结果，Changed为实际(DryRun时为将要)添加或删除的授权
+type ObjectResult struct {
+	Key     string
+	Changed []Model.AccessControlList
+	Err     error `json:"-"`
+}
+
+// PrefixResult ApplyToPrefix的结果
+type PrefixResult struct {
+	Objects   []ObjectResult
+	Changed   int
+	Unchanged int
+	Failed    []ObjectResult
+}
+
+// Summary 返回一行可读的统计信息
+func (r *PrefixResult) Summary() string {
+	return fmt.Sprintf("objects %d, changed %d, unchanged %d, failed %d",
+		len(r.Objects), r.Changed, r.Unchanged, len(r.Failed))
+}
+
+func listKeys(c *galaxy_fds_sdk_golang.FDSClient, bucketname, prefix string) ([]string, error) {
+	keys := []string{}
+	listing, err := c.List_Object(bucketname, prefix, "", galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS)
+	for err == nil {
+		for _, o := range listing.ObjectSummaries {
+			keys = append(keys, o.ObjectName)
+		}
+		if !listing.Truncated {
+			return keys, nil
+		}
+		listing, err = c.List_Next_Batch_Of_Objects(listing)
+	}
+	return nil, err
+}
+
+// ApplyToPrefix 并发地为prefix下的每个object添加或删除opts.Grants中的授权，
+// 已经满足的object不会写回。单个object失败不会中断其它object，失败的object记录在Failed中并返回错误
+func ApplyToPrefix(c *galaxy_fds_sdk_golang.FDSClient, bucketname, prefix string,
+	opts *PrefixOptions) (*PrefixResult, error) {
+	o := PrefixOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Grants) == 0 {
+		return nil, Model.NewFDSError("no grants to apply", -1)
+	}
+	for _, grant := range o.Grants {
+		if err := ValidateGrant(grant); err != nil {
+			return nil, err
+		}
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DEFAULT_PREFIX_CONCURRENCY
+	}
+	keys, err := listKeys(c, bucketname, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PrefixResult{Objects: make([]ObjectResult, len(keys))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	ch := make(chan int)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range ch {
+				r := ObjectResult{Key: keys[idx]}
+				r.Changed, r.Err = objectTarget(c, bucketname, r.Key).update(o.Grants, o.Revoke, o.DryRun)
+				if r.Err == nil && len(r.Changed) > 0 && o.UpdateCDN && !o.DryRun {
+					if o.Revoke {
+						_, r.Err = c.Refresh_Object(bucketname, r.Key)
+					} else {
+						_, r.Err = c.Prefetch_Object(bucketname, r.Key)
+					}
+				}
+				mu.Lock()
+				result.Objects[idx] = r
+				switch {
+				case r.Err != nil:
+					result.Failed = append(result.Failed, r)
+				case len(r.Changed) > 0:
+					result.Changed++
+				default:
+					result.Unchanged++
+				}
+				done++
+				if o.Progress != nil {
+					o.Progress(done, len(keys), r)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range keys {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+
+	sort.Slice(result.Failed, func(i, j int) bool { return result.Failed[i].Key < result.Failed[j].Key })
+	if len(result.Failed) > 0 {
+		return result, Model.NewFDSError(result.Summary()+", first error: "+result.Failed[0].Err.Error(), -1)
+	}
+	return result, nil
+}
@@ -0,0 +1,222 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// DEFAULT_APPEND_BUFFER_SIZE AppendWriter默认的缓冲大小，每次追加都会重写整个object，缓冲越大重写的次数越少
+const DEFAULT_APPEND_BUFFER_SIZE = 4 * 1024 * 1024
+
+// appendEncryptionPrefix fdscrypto客户端加密的object记录加密参数的metadata前缀
+const appendEncryptionPrefix = Model.UserMetadataPrefix + "encryption-"
+
+// Get_Append_Position 返回object当前的长度，即下一次追加的位置，object不存在时返回0
+func (c *FDSClient) Get_Append_Position(bucketname, objectname string) (int64, error) {
+	return c.getAppendPosition(bucketname, objectname, nil)
+}
+
+func (c *FDSClient) getAppendPosition(bucketname, objectname string, sse *Model.SSEOptions) (int64, error) {
+	meta, err := c.appendMeta(bucketname, objectname, sse)
+	if err != nil {
+		return 0, err
+	}
+	if meta == nil {
+		return 0, nil
+	}
+	return meta.GetMetadataContentLength()
+}
+
+// appendMeta 返回object当前的metadata，object不存在时返回nil。
+// 没有提供客户密钥时拒绝使用客户密钥加密的object，避免重写时丢失加密
+func (c *FDSClient) appendMeta(bucketname, objectname string, sse *Model.SSEOptions) (*Model.FDSMetaData, error) {
+	meta, err := c.getObjectMeta(bucketname, objectname, "", sse)
+	if err != nil {
+		var fdsErr *Model.FDSError
+		if errors.As(err, &fdsErr) && fdsErr.Code() == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if meta.IsSSECustomerKey() && (sse == nil || !sse.IsCustomerKey()) {
+		return nil, Model.NewFDSError("object is encrypted with a customer key,"+
+			" use Append_Object_With_SSE", http.StatusBadRequest)
+	}
+	return meta, nil
+}
+
+// Append_Object 将data追加到object的position处，返回下一次追加的位置。position必须等于object当前的长度，
+// object不存在时为0，否则返回状态码为409的错误，可以通过Get_Append_Position获取当前位置后重试。
+//
+// FDS没有原生的追加接口，这里通过读取已有内容再和data一起重新上传(超过MULTIPART_UPLOAD_THRESHOLD时使用分片上传)来模拟，
+// 保留content-type、用户metadata和服务端管理密钥的加密；使用客户密钥加密的object需要通过Append_Object_With_SSE追加。
+// FDS的分片上传只能上传数据，没有把已有object作为分片的upload-part-copy接口，所以无法在服务端拼接，已有内容必须经过客户端。
+// 设置了content-encoding的object(压缩后的流不能直接拼接)和fdscrypto加密的object(加密块和认证标签依赖原始长度)
+// 不能追加，返回状态码为400的错误。限制：
+//   - 每次追加都会重写整个object，耗时和流量与object大小成正比，小的写入应通过AppendWriter合并；
+//   - 位置检查不是原子的，检查之后的并发追加可能会被覆盖，同一个object只应有一个写入者；
+//   - 重写会生成新的object，开启多版本的bucket中每次追加都会产生一个历史版本
+func (c *FDSClient) Append_Object(bucketname, objectname string, position int64, data []byte) (int64, error) {
+	return c.appendObject(bucketname, objectname, position, data, nil)
+}
+
+func (c *FDSClient) appendObject(bucketname, objectname string, position int64, data []byte,
+	sse *Model.SSEOptions) (int64, error) {
+	current, err := c.appendMeta(bucketname, objectname, sse)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	if current != nil {
+		if size, err = current.GetMetadataContentLength(); err != nil {
+			return 0, err
+		}
+	}
+	if current != nil {
+		if encoding, _ := current.GetContentEncoding(); len(encoding) > 0 {
+			return 0, Model.NewFDSError("can not append to object with content-encoding "+encoding, http.StatusBadRequest)
+		}
+		for k := range current.GetRawMetadata() {
+			if strings.HasPrefix(strings.ToLower(k), appendEncryptionPrefix) {
+				return 0, Model.NewFDSError("can not append to client side encrypted object", http.StatusBadRequest)
+			}
+		}
+	}
+	if position != size {
+		return 0, Model.NewFDSError("append position "+strconv.FormatInt(position, 10)+
+			" does not match object length "+strconv.FormatInt(size, 10), http.StatusConflict)
+	}
+	if len(data) == 0 {
+		return position, nil
+	}
+
+	contentType, headers := "", sse.Headers()
+	if current != nil {
+		var kept map[string]string
+		contentType, kept = uploadHeaders(current)
+		for k, v := range kept {
+			headers[k] = v
+		}
+		if algorithm, _ := current.GetKey(Model.ServerSideEncryption); len(algorithm) > 0 && sse == nil {
+			headers[Model.ServerSideEncryption] = algorithm
+		}
+	}
+	var content io.Reader = bytes.NewReader(data)
+	if size > 0 {
+		reader, header, err := c.getObjectResponse(bucketname, objectname, "", 0, size, false, sse)
+		if err != nil {
+			return 0, err
+		}
+		defer reader.Close()
+		modified, _ := current.GetLastModified()
+		if got := header.Get(Model.LastModified); len(modified) > 0 && len(got) > 0 && got != modified {
+			return 0, Model.NewFDSError("object was modified while appending", http.StatusConflict)
+		}
+		content = io.MultiReader(reader, content)
+	}
+	if err := c.putReader(bucketname, objectname, content, size+int64(len(data)), contentType, headers); err != nil {
+		return 0, err
+	}
+	return size + int64(len(data)), nil
+}
+
+// AppendWriter 将多次小的写入合并后通过Append_Object追加到object，可以在多个goroutine中使用。
+// 写入的内容在缓冲满、调用Flush或Close时才会上传
+type AppendWriter struct {
+	client     *FDSClient
+	bucketname string
+	objectname string
+	bufferSize int
+	sse        *Model.SSEOptions
+
+	mu       sync.Mutex
+	position int64
+	buf      []byte
+}
+
+// Append_Writer 从object当前的末尾开始追加，bufferSize小于等于0时使用DEFAULT_APPEND_BUFFER_SIZE
+func (c *FDSClient) Append_Writer(bucketname, objectname string, bufferSize int) (*AppendWriter, error) {
+	return c.appendWriter(bucketname, objectname, bufferSize, nil)
+}
+
+func (c *FDSClient) appendWriter(bucketname, objectname string, bufferSize int,
+	sse *Model.SSEOptions) (*AppendWriter, error) {
+	if bufferSize <= 0 {
+		bufferSize = DEFAULT_APPEND_BUFFER_SIZE
+	}
+	position, err := c.getAppendPosition(bucketname, objectname, sse)
+	if err != nil {
+		return nil, err
+	}
+	return &AppendWriter{
+		client:     c,
+		bucketname: bucketname,
+		objectname: objectname,
+		bufferSize: bufferSize,
+		sse:        sse,
+		position:   position,
+	}, nil
+}
+
+// Write 将p写入缓冲，缓冲满时追加到object。追加失败时返回0和错误，p不会保留在缓冲中，
+// 之前缓冲的内容仍然保留，之后的Flush会重试，调用方可以重新写入p
+func (w *AppendWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	buffered := len(w.buf)
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.bufferSize {
+		return len(p), nil
+	}
+	if err := w.flush(); err != nil {
+		w.buf = w.buf[:buffered]
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush 将缓冲中的内容追加到object
+func (w *AppendWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flush()
+}
+
+func (w *AppendWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	position, err := w.client.appendObject(w.bucketname, w.objectname, w.position, w.buf, w.sse)
+	if err != nil {
+		return err
+	}
+	w.position = position
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close 追加缓冲中剩余的内容
+func (w *AppendWriter) Close() error {
+	return w.Flush()
+}
+
+// Position 返回已经追加到object的长度，不包括缓冲中的内容
+func (w *AppendWriter) Position() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.position
+}
+
+// Buffered 返回缓冲中还没有追加的字节数
+func (w *AppendWriter) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.buf)
+}
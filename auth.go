@@ -80,6 +80,11 @@ var SUB_RESOURCE_MAP = map[string]string{
 	"uploadId":           "",
 	"storageAccessToken": "",
 	"metadata":           "",
+	"lifecycle":          "",
+	"cors":               "",
+	"versioning":         "",
+	"versions":           "",
+	"versionId":          "",
 }
 
 func canonicalizeResource(uri string) ([]byte, error) {
@@ -129,7 +134,7 @@ func canonicalizeResource(uri string) ([]byte, error) {
 
 }
 
-func Signature(app_secret, method, u string, headers map[string][]string) (string, error) {
+func stringToSign(method, u string, headers map[string][]string) ([]byte, error) {
 	var string_to_sign bytes.Buffer
 	content_md5 := getStrFromHeader(headers, "content-md5")
 	content_type := getStrFromHeader(headers, "content-type")
@@ -160,19 +165,31 @@ func Signature(app_secret, method, u string, headers map[string][]string) (strin
 		}*/
 	ch, err := canonicalizeXiaomiHeaders(headers)
 	if err != nil {
-		return "", Model.NewFDSError(err.Error(), -1)
+		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 	string_to_sign.Write(ch)
 	cr, err := canonicalizeResource(u)
 	if err != nil {
-		return "", Model.NewFDSError(err.Error(), -1)
+		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 	string_to_sign.Write(cr)
+	return string_to_sign.Bytes(), nil
+}
+
+func signStringToSign(app_secret string, string_to_sign []byte) (string, error) {
 	h := hmac.New(sha1.New, []byte(app_secret))
-	_, err = h.Write(string_to_sign.Bytes())
+	_, err := h.Write(string_to_sign)
 	if err != nil {
 		return "", Model.NewFDSError(err.Error(), -1)
 	}
 	b := base64.StdEncoding.EncodeToString(h.Sum(nil))
 	return b, nil
 }
+
+func Signature(app_secret, method, u string, headers map[string][]string) (string, error) {
+	string_to_sign, err := stringToSign(method, u, headers)
+	if err != nil {
+		return "", err
+	}
+	return signStringToSign(app_secret, string_to_sign)
+}
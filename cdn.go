@@ -0,0 +1,174 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	CDN_ACTION_PREFETCH = "prefetch"
+	CDN_ACTION_REFRESH  = "refresh"
+
+	DEFAULT_CDN_CONCURRENCY = 4
+	DEFAULT_CDN_RETRIES     = 2
+	DEFAULT_CDN_BACKOFF     = time.Second
+)
+
+// CDNOptions 批量预取、刷新CDN的参数
+type CDNOptions struct {
+	Concurrency int
+	// RequestsPerSecond 大于0时限制每秒提交的请求数，避免超过CDN的配额
+	RequestsPerSecond float64
+	// Retries 失败后的重试次数，默认DEFAULT_CDN_RETRIES，小于0时不重试；除429外的4xx错误不重试
+	Retries int
+	// Backoff 第一次重试前等待的时间，之后每次翻倍，默认DEFAULT_CDN_BACKOFF
+	Backoff time.Duration
+	// Progress 每处理完一个object调用一次，不会并发调用
+	Progress func(done, total int, item CDNItem)
+}
+
+// CDNItem 一个object的提交结果，Attempts为提交的次数
+type CDNItem struct {
+	Key      string
+	Attempts int
+	Err      error `json:"-"`
+}
+
+// CDNResult 批量预取、刷新的结果。FDS的预取和刷新接口只返回是否提交成功，不返回任务id，
+// 所以无法查询CDN任务的执行状态，Submitted只表示提交成功
+type CDNResult struct {
+	Action    string
+	Items     []CDNItem
+	Submitted int
+	Failed    []CDNItem
+}
+
+// Summary 返回一行可读的统计信息
+func (r *CDNResult) Summary() string {
+	return fmt.Sprintf("%s %d, submitted %d, failed %d", r.Action, len(r.Items), r.Submitted, len(r.Failed))
+}
+
+// cdnRetryable 判断提交失败后是否重试，请求被拒绝(除限流外的4xx)时重试没有意义
+func cdnRetryable(err error) bool {
+	var fdsErr *Model.FDSError
+	if errors.As(err, &fdsErr) {
+		return fdsErr.Code() == 429 || fdsErr.Code() < 400 || fdsErr.Code() >= 500
+	}
+	return true
+}
+
+func (c *FDSClient) cdnBatch(action, bucketname string, keys []string, opts *CDNOptions) (*CDNResult, error) {
+	o := CDNOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DEFAULT_CDN_CONCURRENCY
+	}
+	if o.Retries == 0 {
+		o.Retries = DEFAULT_CDN_RETRIES
+	} else if o.Retries < 0 {
+		o.Retries = 0
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = DEFAULT_CDN_BACKOFF
+	}
+	submit := c.Prefetch_Object
+	if action == CDN_ACTION_REFRESH {
+		submit = c.Refresh_Object
+	}
+
+	var limiterMu sync.Mutex
+	limiter := tokenBucket{}
+	limiter.setRate(o.RequestsPerSecond)
+	wait := func() {
+		limiterMu.Lock()
+		delay := limiter.reserve(1, time.Now())
+		limiterMu.Unlock()
+		time.Sleep(delay)
+	}
+
+	result := &CDNResult{Action: action, Items: make([]CDNItem, len(keys))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	ch := make(chan int)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range ch {
+				item := CDNItem{Key: keys[idx]}
+				backoff := o.Backoff
+				for {
+					wait()
+					item.Attempts++
+					_, item.Err = submit(bucketname, item.Key)
+					if item.Err == nil || item.Attempts > o.Retries || !cdnRetryable(item.Err) {
+						break
+					}
+					time.Sleep(backoff)
+					backoff *= 2
+				}
+				mu.Lock()
+				result.Items[idx] = item
+				if item.Err != nil {
+					result.Failed = append(result.Failed, item)
+				} else {
+					result.Submitted++
+				}
+				done++
+				if o.Progress != nil {
+					o.Progress(done, len(keys), item)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range keys {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(result.Failed) > 0 {
+		return result, Model.NewFDSError(result.Summary()+", first error: "+result.Failed[0].Err.Error(), -1)
+	}
+	return result, nil
+}
+
+func (c *FDSClient) cdnPrefix(action, bucketname, prefix string, opts *CDNOptions) (*CDNResult, error) {
+	keys := []string{}
+	err := c.walkObjects(bucketname, prefix, func(summary Model.FDSObjectSummary) error {
+		keys = append(keys, summary.ObjectName)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.cdnBatch(action, bucketname, keys, opts)
+}
+
+// Prefetch_Objects 并发地将keys预取到CDN，单个object失败不会中断其它object，失败的object记录在Failed中并返回错误
+func (c *FDSClient) Prefetch_Objects(bucketname string, keys []string, opts *CDNOptions) (*CDNResult, error) {
+	return c.cdnBatch(CDN_ACTION_PREFETCH, bucketname, keys, opts)
+}
+
+// Refresh_Objects 并发地刷新keys的CDN缓存，单个object失败不会中断其它object，失败的object记录在Failed中并返回错误
+func (c *FDSClient) Refresh_Objects(bucketname string, keys []string, opts *CDNOptions) (*CDNResult, error) {
+	return c.cdnBatch(CDN_ACTION_REFRESH, bucketname, keys, opts)
+}
+
+// Prefetch_Prefix 将prefix下的所有object预取到CDN
+func (c *FDSClient) Prefetch_Prefix(bucketname, prefix string, opts *CDNOptions) (*CDNResult, error) {
+	return c.cdnPrefix(CDN_ACTION_PREFETCH, bucketname, prefix, opts)
+}
+
+// Refresh_Prefix 刷新prefix下所有object的CDN缓存
+func (c *FDSClient) Refresh_Prefix(bucketname, prefix string, opts *CDNOptions) (*CDNResult, error) {
+	return c.cdnPrefix(CDN_ACTION_REFRESH, bucketname, prefix, opts)
+}
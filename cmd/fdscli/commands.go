@@ -0,0 +1,689 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	fdsacl "github.com/qkzsky/galaxy-fds-sdk-golang/acl"
+)
+
+func newFlagSet(c *cli, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	return fs
+}
+
+type listEntry struct {
+	Type         string    `json:"type"`
+	Name         string    `json:"name"`
+	Size         int64     `json:"size,omitempty"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+	Etag         string    `json:"etag,omitempty"`
+}
+
+func printEntries(w io.Writer, entries []listEntry) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, e := range entries {
+		if e.Type == "dir" {
+			fmt.Fprintf(tw, "DIR\t\t\t%s\n", e.Name)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", e.Type, e.Size, e.LastModified.Format(time.RFC3339), e.Name)
+	}
+	tw.Flush()
+}
+
+func (c *cli) ls(args []string) error {
+	fs := newFlagSet(c, "ls")
+	recursive := fs.Bool("r", false, "list recursively instead of by directory")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		buckets, err := c.client.List_Bucket()
+		if err != nil {
+			return err
+		}
+		return c.output(buckets, func(w io.Writer) {
+			for _, b := range buckets {
+				fmt.Fprintln(w, b)
+			}
+		})
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(fs.Arg(0), false)
+	if err != nil {
+		return err
+	}
+	delimiter := galaxy_fds_sdk_golang.DELIMITER
+	if *recursive {
+		delimiter = ""
+	}
+
+	entries := []listEntry{}
+	listing, err := c.client.List_Object(l.bucket, l.object, delimiter, galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS)
+	for err == nil {
+		for _, p := range listing.CommonPrefixes {
+			entries = append(entries, listEntry{Type: "dir", Name: p})
+		}
+		for _, o := range listing.ObjectSummaries {
+			entries = append(entries, listEntry{Type: "object", Name: o.ObjectName, Size: o.Size,
+				LastModified: o.LastModified, Etag: o.Etag})
+		}
+		if !listing.Truncated {
+			break
+		}
+		listing, err = c.client.List_Next_Batch_Of_Objects(listing)
+	}
+	if err != nil {
+		return err
+	}
+	return c.output(entries, func(w io.Writer) { printEntries(w, entries) })
+}
+
+func (c *cli) cp(args []string) error {
+	fs := newFlagSet(c, "cp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	src, dst := parseLocation(fs.Arg(0)), parseLocation(fs.Arg(1))
+	if src.remote && len(src.object) == 0 {
+		return fmt.Errorf("%q has no object name", fs.Arg(0))
+	}
+	if err := c.copy(src, dst); err != nil {
+		return err
+	}
+	return c.output(map[string]string{"copied": src.String(), "to": dst.String()}, nil)
+}
+
+func (c *cli) mv(args []string) error {
+	fs := newFlagSet(c, "mv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	src, dst := parseLocation(fs.Arg(0)), parseLocation(fs.Arg(1))
+	if src.remote && len(src.object) == 0 {
+		return fmt.Errorf("%q has no object name", fs.Arg(0))
+	}
+	// 同一个bucket内直接rename
+	if src.remote && dst.remote && src.bucket == dst.bucket &&
+		len(dst.object) > 0 && !strings.HasSuffix(dst.object, "/") {
+		if _, err := c.client.Rename_Object(src.bucket, src.object, dst.object); err != nil {
+			return err
+		}
+	} else {
+		if err := c.copy(src, dst); err != nil {
+			return err
+		}
+		var err error
+		if src.remote {
+			_, err = c.client.Delete_Object(src.bucket, src.object)
+		} else {
+			err = os.Remove(src.local)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return c.output(map[string]string{"moved": src.String(), "to": dst.String()}, nil)
+}
+
+func (c *cli) rm(args []string) error {
+	fs := newFlagSet(c, "rm")
+	recursive := fs.Bool("r", false, "remove every object under the prefix")
+	force := fs.Bool("force", false, "allow recursive removal of a whole bucket")
+	maxObjects := fs.Int("max", 0, "with -r, refuse to remove anything if more than this many objects match")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(fs.Arg(0), !*recursive)
+	if err != nil {
+		return err
+	}
+	if *recursive {
+		if len(l.object) == 0 && !*force {
+			return fmt.Errorf("refusing to remove every object in bucket %s without -force", l.bucket)
+		}
+		result, err := c.client.Bulk_Delete_Prefix(l.bucket, l.object, &galaxy_fds_sdk_golang.BulkDeleteOptions{
+			AllowEmptyPrefix: *force,
+			MaxObjects:       *maxObjects,
+		})
+		if result != nil {
+			for _, f := range result.Failed {
+				fmt.Fprintf(c.stderr, "%s: [%d] %s\n", f.Key, f.Code, f.Message)
+			}
+			c.output(result, func(w io.Writer) { fmt.Fprintln(w, result.Summary()) })
+		}
+		return err
+	}
+	if _, err = c.client.Delete_Object(l.bucket, l.object); err != nil {
+		return err
+	}
+	return c.output(map[string]string{"removed": l.String()}, nil)
+}
+
+func (c *cli) cat(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(args[0], true)
+	if err != nil {
+		return err
+	}
+	reader, err := c.client.Get_Object_Reader(l.bucket, l.object, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer (*reader).Close()
+	_, err = io.Copy(c.stdout, *reader)
+	return err
+}
+
+func (c *cli) stat(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(args[0], true)
+	if err != nil {
+		return err
+	}
+	meta, err := c.client.Get_Object_Meta(l.bucket, l.object)
+	if err != nil {
+		return err
+	}
+	m := map[string]string{}
+	for k, v := range meta.GetRawMetadata() {
+		m[k] = strings.Join(v, ",")
+	}
+	return c.output(m, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, k := range sortedKeys(m) {
+			fmt.Fprintf(tw, "%s:\t%s\n", k, m[k])
+		}
+		tw.Flush()
+	})
+}
+
+func printACL(w io.Writer, acl *Model.ACL) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "owner:\t%s\n", acl.Owners.Id)
+	for _, g := range acl.AccessControlLists {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.Type, g.Grantees.Id, g.Permission)
+	}
+	tw.Flush()
+}
+
+func (c *cli) aclGet(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(args[0], false)
+	if err != nil {
+		return err
+	}
+	var acl *Model.ACL
+	if len(l.object) == 0 {
+		acl, err = c.client.Get_Bucket_ACL(l.bucket)
+	} else {
+		acl, err = c.client.Get_Object_ACL(l.bucket, l.object)
+	}
+	if err != nil {
+		return err
+	}
+	if acl == nil {
+		acl = &Model.ACL{}
+	}
+	return c.output(acl, func(w io.Writer) { printACL(w, acl) })
+}
+
+func (c *cli) aclSet(args []string) error {
+	fs := newFlagSet(c, "acl set")
+	perm := fs.String("perm", galaxy_fds_sdk_golang.PERMISSION_READ, "READ, WRITE or FULL_CONTROL")
+	user := fs.String("user", "", "grantee app id / user id")
+	group := fs.String("group", "", "grantee group: ALL_USERS or AUTHENTICATED_USERS")
+	revoke := fs.Bool("revoke", false, "remove the grant instead of adding it")
+	recursive := fs.Bool("r", false, "apply to every object under the prefix")
+	dryRun := fs.Bool("dry-run", false, "with -r, only show which objects would change")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || (len(*user) == 0) == (len(*group) == 0) {
+		return errUsage
+	}
+	l, err := parseRemote(fs.Arg(0), false)
+	if err != nil {
+		return err
+	}
+	grant := Model.AccessControlList{Permission: *perm}
+	if len(*user) > 0 {
+		grant.Type = galaxy_fds_sdk_golang.PERMISSION_USER
+		grant.Grantees.Id = *user
+	} else {
+		grant.Type = galaxy_fds_sdk_golang.PERMISSION_GROUP
+		grant.Grantees.Id = *group
+	}
+	if err := fdsacl.ValidateGrant(grant); err != nil {
+		return err
+	}
+	if *recursive {
+		return c.aclSetPrefix(l, grant, *revoke, *dryRun)
+	}
+	acl := Model.ACL{AccessControlLists: []Model.AccessControlList{grant}}
+
+	switch {
+	case len(l.object) == 0 && *revoke:
+		_, err = c.client.Delete_Bucket_ACL(l.bucket, acl)
+	case len(l.object) == 0:
+		_, err = c.client.Set_Bucket_ACL(l.bucket, acl)
+	case *revoke:
+		_, err = c.client.Delete_Object_ACL(l.bucket, l.object, acl)
+	default:
+		_, err = c.client.Set_Object_Acl_New(l.bucket, l.object, acl)
+	}
+	if err != nil {
+		return err
+	}
+	return c.output(acl, nil)
+}
+
+func (c *cli) aclSetPrefix(l location, grant Model.AccessControlList, revoke, dryRun bool) error {
+	opts := &fdsacl.PrefixOptions{
+		Grants: []Model.AccessControlList{grant},
+		Revoke: revoke,
+		DryRun: dryRun,
+	}
+	if !c.quiet && !c.jsonOutput {
+		opts.Progress = func(done, total int, r fdsacl.ObjectResult) {
+			if r.Err != nil {
+				fmt.Fprintf(c.stderr, "[%d/%d] %s: %v\n", done, total, r.Key, r.Err)
+			} else if len(r.Changed) > 0 {
+				fmt.Fprintf(c.stderr, "[%d/%d] %s\n", done, total, r.Key)
+			}
+		}
+	}
+	result, err := fdsacl.ApplyToPrefix(c.client, l.bucket, l.object, opts)
+	if result != nil {
+		c.output(result, func(w io.Writer) { fmt.Fprintln(w, result.Summary()) })
+	}
+	return err
+}
+
+func (c *cli) presign(args []string) error {
+	fs := newFlagSet(c, "presign")
+	method := fs.String("method", "GET", "HTTP method the url is signed for")
+	expires := fs.Duration("expires", time.Hour, "how long the url stays valid")
+	contentType := fs.String("content-type", "", "content-type the client must send (PUT/POST)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(fs.Arg(0), true)
+	if err != nil {
+		return err
+	}
+	headers := map[string][]string{}
+	if len(*contentType) > 0 {
+		headers["content-type"] = []string{*contentType}
+	}
+	expiration := time.Now().Add(*expires).UnixNano() / int64(time.Millisecond)
+	u, err := c.client.Generate_Presigned_URI(l.bucket, l.object, strings.ToUpper(*method), expiration, headers)
+	if err != nil {
+		return err
+	}
+	return c.output(map[string]interface{}{"url": u, "expires": expiration}, func(w io.Writer) {
+		fmt.Fprintln(w, u)
+	})
+}
+
+func bucketArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errUsage
+	}
+	if !strings.HasPrefix(args[0], FDS_SCHEME) {
+		return args[0], nil
+	}
+	l, err := parseRemote(args[0], false)
+	return l.bucket, err
+}
+
+func (c *cli) mb(args []string) error {
+	bucket, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Create_Bucket(bucket); err != nil {
+		return err
+	}
+	return c.output(map[string]string{"created": bucket}, nil)
+}
+
+func (c *cli) rb(args []string) error {
+	bucket, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Delete_Bucket(bucket); err != nil {
+		return err
+	}
+	return c.output(map[string]string{"deleted": bucket}, nil)
+}
+
+func printQuota(w io.Writer, policy *Model.QuotaPolicy) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, q := range policy.Quotas {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", q.Type, q.Action, q.Value)
+	}
+	tw.Flush()
+}
+
+func (c *cli) quotaGet(args []string) error {
+	bucket, err := bucketArg(args)
+	if err != nil {
+		return err
+	}
+	policy, err := c.client.Get_Bucket_Quota(bucket)
+	if err != nil {
+		return err
+	}
+	return c.output(policy, func(w io.Writer) { printQuota(w, policy) })
+}
+
+func (c *cli) quotaSet(args []string) error {
+	fs := newFlagSet(c, "quota set")
+	space := fs.Int64("space", -1, "space quota in bytes, 0 for unlimited")
+	objects := fs.Int64("objects", -1, "object number quota, 0 for unlimited")
+	qps := fs.String("qps", "", "comma separated ACTION=N QPS quotas, e.g. GET=1000,PUT=100")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	bucket, err := bucketArg(fs.Args())
+	if err != nil {
+		return err
+	}
+	policy, err := c.client.Get_Bucket_Quota(bucket)
+	if err != nil {
+		return err
+	}
+	if *space >= 0 {
+		policy.SetQuota(Model.QUOTA_TYPE_SPACE, "", *space)
+	}
+	if *objects >= 0 {
+		policy.SetQuota(Model.QUOTA_TYPE_OBJECTS, "", *objects)
+	}
+	if len(*qps) > 0 {
+		for _, item := range strings.Split(*qps, ",") {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("invalid qps quota %q", item)
+			}
+			value, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid qps quota %q", item)
+			}
+			policy.SetQuota(Model.QUOTA_TYPE_QPS, strings.ToUpper(kv[0]), value)
+		}
+	}
+	if _, err := c.client.Set_Bucket_Quota(bucket, *policy); err != nil {
+		return err
+	}
+	return c.output(policy, func(w io.Writer) { printQuota(w, policy) })
+}
+
+func (c *cli) quotaReport(args []string) error {
+	fs := newFlagSet(c, "quota report")
+	warn := fs.Float64("warn", galaxy_fds_sdk_golang.DEFAULT_QUOTA_WARN_RATIO, "usage ratio that triggers a warning")
+	check := fs.Bool("check", false, "exit with an error when any bucket is not OK")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errUsage
+	}
+	reports := []*galaxy_fds_sdk_golang.QuotaReport{}
+	for _, arg := range fs.Args() {
+		bucket, err := bucketArg([]string{arg})
+		if err != nil {
+			return err
+		}
+		report, err := c.client.Get_Bucket_Quota_Report(bucket, *warn)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+	err := c.output(reports, func(w io.Writer) {
+		for _, r := range reports {
+			fmt.Fprintln(w, r)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if *check && r.Status != galaxy_fds_sdk_golang.QUOTA_STATUS_OK {
+			return fmt.Errorf("bucket %s quota status %s", r.BucketName, r.Status)
+		}
+	}
+	return nil
+}
+
+// cdnBatch 返回cdn prefetch和cdn refresh命令，参数可以是多个bucket中的object
+func cdnBatch(action string) command {
+	return func(c *cli, args []string) error {
+		fs := newFlagSet(c, "cdn "+action)
+		recursive := fs.Bool("r", false, "apply to every object under the prefix")
+		rps := fs.Float64("rps", 0, "max requests per second, 0 means unlimited")
+		concurrency := fs.Int("concurrency", galaxy_fds_sdk_golang.DEFAULT_CDN_CONCURRENCY, "number of concurrent requests")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() == 0 {
+			return errUsage
+		}
+		opts := &galaxy_fds_sdk_golang.CDNOptions{RequestsPerSecond: *rps, Concurrency: *concurrency}
+		if !c.quiet && !c.jsonOutput {
+			opts.Progress = func(done, total int, item galaxy_fds_sdk_golang.CDNItem) {
+				if item.Err != nil {
+					fmt.Fprintf(c.stderr, "[%d/%d] %s: %v\n", done, total, item.Key, item.Err)
+				}
+			}
+		}
+		prefetch := action == galaxy_fds_sdk_golang.CDN_ACTION_PREFETCH
+		buckets := []string{}
+		keys := map[string][]string{}
+		for _, arg := range fs.Args() {
+			l, err := parseRemote(arg, !*recursive)
+			if err != nil {
+				return err
+			}
+			if _, ok := keys[l.bucket]; !ok {
+				buckets = append(buckets, l.bucket)
+			}
+			keys[l.bucket] = append(keys[l.bucket], l.object)
+		}
+		results := []*galaxy_fds_sdk_golang.CDNResult{}
+		var firstErr error
+		collect := func(result *galaxy_fds_sdk_golang.CDNResult, err error) {
+			if result != nil {
+				results = append(results, result)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		for _, bucket := range buckets {
+			switch {
+			case *recursive:
+				for _, prefix := range keys[bucket] {
+					if prefetch {
+						collect(c.client.Prefetch_Prefix(bucket, prefix, opts))
+					} else {
+						collect(c.client.Refresh_Prefix(bucket, prefix, opts))
+					}
+				}
+			case prefetch:
+				collect(c.client.Prefetch_Objects(bucket, keys[bucket], opts))
+			default:
+				collect(c.client.Refresh_Objects(bucket, keys[bucket], opts))
+			}
+		}
+		c.output(results, func(w io.Writer) {
+			for _, r := range results {
+				fmt.Fprintln(w, r.Summary())
+			}
+		})
+		return firstErr
+	}
+}
+
+func (c *cli) trashLs(args []string) error {
+	fs := newFlagSet(c, "trash ls")
+	prefix := fs.String("prefix", "", "prefix of bucket_name/object_name")
+	maxKeys := fs.Int("max", 0, "max number of objects, 0 for all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	entries := []listEntry{}
+	it := c.client.Trash_Iterator(*prefix)
+	for (*maxKeys <= 0 || len(entries) < *maxKeys) && it.Next() {
+		o := it.Object().Summary
+		entries = append(entries, listEntry{Type: "trash", Name: o.ObjectName, Size: o.Size,
+			LastModified: o.LastModified, Etag: o.Etag})
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return c.output(entries, func(w io.Writer) { printEntries(w, entries) })
+}
+
+func (c *cli) trashRestore(args []string) error {
+	fs := newFlagSet(c, "trash restore")
+	recursive := fs.Bool("r", false, "restore every object under the prefix")
+	conflict := fs.String("conflict", galaxy_fds_sdk_golang.RESTORE_CONFLICT_SKIP,
+		"when the object already exists with -r: skip, overwrite or rename")
+	suffix := fs.String("suffix", galaxy_fds_sdk_golang.DEFAULT_RESTORE_SUFFIX, "suffix of the new name with -conflict rename")
+	dryRun := fs.Bool("dry-run", false, "only show what would be restored with -r")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(fs.Arg(0), !*recursive)
+	if err != nil {
+		return err
+	}
+	if !*recursive {
+		if err := c.client.Restore_Object(l.bucket, l.object); err != nil {
+			return err
+		}
+		return c.output(map[string]string{"restored": l.String()}, nil)
+	}
+	opts := &galaxy_fds_sdk_golang.RestoreOptions{Conflict: *conflict, Suffix: *suffix, DryRun: *dryRun}
+	if !c.quiet && !c.jsonOutput {
+		opts.Progress = c.printTrashAction
+	}
+	result, err := c.client.Restore_Trash_Objects(l.bucket, l.object, opts)
+	if result != nil {
+		c.output(result, func(w io.Writer) { fmt.Fprintln(w, result.Summary()) })
+	}
+	return err
+}
+
+func (c *cli) printTrashAction(a galaxy_fds_sdk_golang.TrashAction) {
+	name := a.BucketName + "/" + a.ObjectName
+	switch {
+	case a.Err != nil:
+		fmt.Fprintf(c.stderr, "%s %s: %v\n", a.Action, name, a.Err)
+	case a.Target != "" && a.Target != a.ObjectName:
+		fmt.Fprintf(c.stderr, "%s %s -> %s\n", a.Action, name, a.Target)
+	case a.Reason != "":
+		fmt.Fprintf(c.stderr, "%s %s (%s)\n", a.Action, name, a.Reason)
+	default:
+		fmt.Fprintf(c.stderr, "%s %s\n", a.Action, name)
+	}
+}
+
+func (c *cli) trashPurge(args []string) error {
+	fs := newFlagSet(c, "trash purge")
+	prefix := fs.String("prefix", "", "prefix of bucket_name/object_name")
+	olderThan := fs.Duration("older-than", 0, "only purge objects deleted before this long ago")
+	dryRun := fs.Bool("dry-run", false, "only show what would be purged")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+	result, err := c.client.Purge_Trash(*prefix, time.Now().Add(-*olderThan), *dryRun)
+	if result != nil {
+		if !c.quiet && !c.jsonOutput {
+			for _, a := range result.Actions {
+				c.printTrashAction(a)
+			}
+		}
+		c.output(result, func(w io.Writer) { fmt.Fprintln(w, result.Summary()) })
+	}
+	return err
+}
+
+func (c *cli) multipartLs(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	l, err := parseRemote(args[0], false)
+	if err != nil {
+		return err
+	}
+	result, err := c.client.List_Multipart_Uploads(l.bucket, l.object, "", galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS)
+	if err != nil {
+		return err
+	}
+	return c.output(result.Uploads, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, u := range result.Uploads {
+			fmt.Fprintf(tw, "%s\t%s\n", u.UploadId, u.ObjectName)
+		}
+		tw.Flush()
+	})
+}
+
+func (c *cli) multipartAbort(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	l, err := parseRemote(args[0], true)
+	if err != nil {
+		return err
+	}
+	err = c.client.Abort_MultipartUpload(&Model.InitMultipartUploadResult{
+		BucketName: l.bucket,
+		ObjectName: l.object,
+		UploadId:   args[1],
+	})
+	if err != nil {
+		return err
+	}
+	return c.output(map[string]string{"aborted": args[1]}, nil)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ENV_ACCESS_KEY_ID     = "XIAOMI_ACCESS_KEY_ID"
+	ENV_SECRET_ACCESS_KEY = "XIAOMI_SECRET_ACCESS_KEY"
+	ENV_REGION            = "XIAOMI_REGION"
+	ENV_FDS_ENDPOINT      = "XIAOMI_FDS_ENDPOINT"
+	ENV_CONFIG_FILE       = "XIAOMI_CONFIG"
+)
+
+// profile 对应配置文件中的一组凭证，配置文件为json格式：
+//
+//	{
+//	  "xiaomi_access_key_id": "...",
+//	  "xiaomi_secret_access_key": "...",
+//	  "xiaomi_region": "cnbj1",
+//	  "profiles": {
+//	    "sgp": {"xiaomi_access_key_id": "...", "xiaomi_secret_access_key": "...", "xiaomi_region": "awssgp0"}
+//	  }
+//	}
+type profile struct {
+	AccessKeyId     string `json:"xiaomi_access_key_id"`
+	SecretAccessKey string `json:"xiaomi_secret_access_key"`
+	Region          string `json:"xiaomi_region"`
+	Endpoint        string `json:"xiaomi_fds_endpoint"`
+	EnableHttps     *bool  `json:"xiaomi_enable_https"`
+	EnableCDN       bool   `json:"xiaomi_enable_cdn"`
+}
+
+type configFile struct {
+	profile
+	Profiles map[string]profile `json:"profiles"`
+}
+
+func defaultConfigPath() string {
+	if p := os.Getenv(ENV_CONFIG_FILE); len(p) > 0 {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "xiaomi", "config")
+}
+
+// loadProfile 依次读取配置文件和环境变量，环境变量优先
+func loadProfile(path, name string) (*profile, error) {
+	p := &profile{}
+	if len(path) > 0 {
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			var cf configFile
+			if err := json.Unmarshal(data, &cf); err != nil {
+				return nil, fmt.Errorf("parse %s: %v", path, err)
+			}
+			*p = cf.profile
+			if len(name) > 0 {
+				named, ok := cf.Profiles[name]
+				if !ok {
+					return nil, fmt.Errorf("profile %q not found in %s", name, path)
+				}
+				*p = named
+			}
+		} else if len(name) > 0 {
+			return nil, fmt.Errorf("profile %q requested but %s does not exist", name, path)
+		}
+	}
+
+	if v := os.Getenv(ENV_ACCESS_KEY_ID); len(v) > 0 {
+		p.AccessKeyId = v
+	}
+	if v := os.Getenv(ENV_SECRET_ACCESS_KEY); len(v) > 0 {
+		p.SecretAccessKey = v
+	}
+	if v := os.Getenv(ENV_REGION); len(v) > 0 {
+		p.Region = v
+	}
+	if v := os.Getenv(ENV_FDS_ENDPOINT); len(v) > 0 {
+		p.Endpoint = v
+	}
+	return p, nil
+}
@@ -0,0 +1,205 @@
+// fdscli 是基于galaxy-fds-sdk-golang的命令行工具
+//
+// 凭证依次从配置文件(~/.config/xiaomi/config，可用XIAOMI_CONFIG或-config指定)、
+// 环境变量(XIAOMI_ACCESS_KEY_ID、XIAOMI_SECRET_ACCESS_KEY、XIAOMI_REGION、XIAOMI_FDS_ENDPOINT)
+// 和命令行参数中读取，后者优先。
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+)
+
+const usage = `usage: fdscli [global flags] <command> [flags] [args]
+
+commands:
+  ls [-r] [fds://bucket[/prefix]]        list buckets, or objects under a prefix
+  cp <src> <dst>                         copy local<->fds or fds<->fds
+  mv <src> <dst>                         move local<->fds or fds<->fds
+  rm [-r [-force] [-max N]] fds://bucket/key
+                                         remove an object, or every object under a prefix with -r
+  cat fds://bucket/key                   write object content to stdout
+  stat fds://bucket/key                  show object metadata
+  acl get fds://bucket[/key]             show bucket or object ACL
+  acl set [-revoke] [-r [-dry-run]] [-perm P] (-user ID | -group G) fds://bucket[/key]
+  presign [-method M] [-expires D] [-content-type T] fds://bucket/key
+  mb fds://bucket                        create a bucket
+  rb fds://bucket                        delete a bucket
+  quota get fds://bucket                 show bucket quotas
+  quota set [-space N] [-objects N] [-qps ACTION=N,...] fds://bucket
+  quota report [-warn R] [-check] fds://bucket...
+  cdn prefetch [-r] [-rps N] fds://bucket/key...
+                                         prefetch objects, or every object under prefixes with -r, to CDN
+  cdn refresh [-r] [-rps N] fds://bucket/key...
+                                         refresh CDN cache of objects
+  trash ls [-prefix P] [-max N]          list objects in trash
+  trash restore fds://bucket/key         restore an object from trash
+  trash restore -r [-conflict skip|overwrite|rename] [-dry-run] fds://bucket[/prefix]
+  trash purge [-prefix P] [-older-than D] [-dry-run]
+  multipart ls fds://bucket[/prefix]     list in-progress multipart uploads
+  multipart abort fds://bucket/key ID    abort a multipart upload
+
+global flags:
+`
+
+type cli struct {
+	client     *galaxy_fds_sdk_golang.FDSClient
+	jsonOutput bool
+	quiet      bool
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+type command func(c *cli, args []string) error
+
+var commands = map[string]command{
+	"ls":      (*cli).ls,
+	"cp":      (*cli).cp,
+	"mv":      (*cli).mv,
+	"rm":      (*cli).rm,
+	"cat":     (*cli).cat,
+	"stat":    (*cli).stat,
+	"acl":     subcommands(map[string]command{"get": (*cli).aclGet, "set": (*cli).aclSet}),
+	"presign": (*cli).presign,
+	"mb":      (*cli).mb,
+	"rb":      (*cli).rb,
+	"quota": subcommands(map[string]command{"get": (*cli).quotaGet, "set": (*cli).quotaSet,
+		"report": (*cli).quotaReport}),
+	"cdn": subcommands(map[string]command{"prefetch": cdnBatch(galaxy_fds_sdk_golang.CDN_ACTION_PREFETCH),
+		"refresh": cdnBatch(galaxy_fds_sdk_golang.CDN_ACTION_REFRESH)}),
+	"trash": subcommands(map[string]command{"ls": (*cli).trashLs, "restore": (*cli).trashRestore,
+		"purge": (*cli).trashPurge}),
+	"multipart": subcommands(map[string]command{"ls": (*cli).multipartLs, "abort": (*cli).multipartAbort}),
+}
+
+var errUsage = errors.New("invalid usage")
+
+func subcommands(m map[string]command) command {
+	return func(c *cli, args []string) error {
+		if len(args) == 0 {
+			return errUsage
+		}
+		sub, ok := m[args[0]]
+		if !ok {
+			return errUsage
+		}
+		return sub(c, args[1:])
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fdscli", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", defaultConfigPath(), "config file")
+	profileName := fs.String("profile", "", "profile name in config file")
+	region := fs.String("region", "", "region, overrides config")
+	endpoint := fs.String("endpoint", "", "fds endpoint, overrides config")
+	https := fs.Bool("https", true, "use https")
+	cdn := fs.Bool("cdn", false, "use cdn domain for downloads")
+	jsonOutput := fs.Bool("json", false, "print results as json")
+	quiet := fs.Bool("quiet", false, "do not show progress")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "fdscli: unknown command %q\n", fs.Arg(0))
+		fs.Usage()
+		return 2
+	}
+
+	p, err := loadProfile(*configPath, *profileName)
+	if err != nil {
+		fmt.Fprintf(stderr, "fdscli: %v\n", err)
+		return 1
+	}
+	if len(*region) > 0 {
+		p.Region = *region
+	}
+	if len(*endpoint) > 0 {
+		p.Endpoint = *endpoint
+	}
+	enableHttps := *https
+	if p.EnableHttps != nil && !isFlagSet(fs, "https") {
+		enableHttps = *p.EnableHttps
+	}
+	enableCDN := *cdn || p.EnableCDN
+	if len(p.AccessKeyId) == 0 || len(p.SecretAccessKey) == 0 {
+		fmt.Fprintf(stderr, "fdscli: missing credentials, set %s and %s or write them to %s\n",
+			ENV_ACCESS_KEY_ID, ENV_SECRET_ACCESS_KEY, *configPath)
+		return 1
+	}
+	// endpoint可能来自配置文件、环境变量或-endpoint，统一去掉协议前缀和结尾的/
+	ep := strings.TrimRight(strings.TrimPrefix(strings.TrimPrefix(p.Endpoint, galaxy_fds_sdk_golang.URI_HTTPS_PREFIX),
+		galaxy_fds_sdk_golang.URI_HTTP_PREFIX), "/")
+
+	c := &cli{
+		client: galaxy_fds_sdk_golang.NEWFDSClient(p.AccessKeyId, p.SecretAccessKey,
+			p.Region, ep, enableHttps, enableCDN),
+		jsonOutput: *jsonOutput,
+		quiet:      *quiet,
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+	if err := cmd(c, fs.Args()[1:]); err != nil {
+		if err == errUsage || err == flag.ErrHelp {
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintf(stderr, "fdscli %s: %v\n", fs.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// output 按-json参数输出结果，text为nil时文本模式下不输出
+func (c *cli) output(v interface{}, text func(w io.Writer)) error {
+	if c.jsonOutput {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	if text != nil {
+		text(c.stdout)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFDS 在内存中模拟命令行测试用到的object上传、下载、复制和回收站列表接口，key为bucket/object
+type fakeFDS struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+	trash   []string
+	// copyStatus 不为0时服务端复制返回该状态码
+	copyStatus int
+	requests   []string
+}
+
+func newFakeFDS(t *testing.T) (*fakeFDS, []string) {
+	f := &fakeFDS{objects: map[string][]byte{}, types: map[string]string{}}
+	for _, env := range []string{ENV_ACCESS_KEY_ID, ENV_SECRET_ACCESS_KEY, ENV_REGION, ENV_FDS_ENDPOINT} {
+		t.Setenv(env, "")
+	}
+	ts := httptest.NewServer(f)
+	t.Cleanup(ts.Close)
+	config := filepath.Join(t.TempDir(), "config")
+	data := fmt.Sprintf(`{"xiaomi_access_key_id": "ak", "xiaomi_secret_access_key": "sk",
+		"xiaomi_fds_endpoint": %q, "xiaomi_enable_https": false}`, ts.URL+"/")
+	if err := os.WriteFile(config, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return f, []string{"-config", config, "-quiet"}
+}
+
+func (f *fakeFDS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	q := r.URL.Query()
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case key == "trash" && r.Method == "GET":
+		f.listTrash(w, q.Get("marker"))
+	case q.Has("cpFrom"):
+		if f.copyStatus != 0 {
+			w.WriteHeader(f.copyStatus)
+			return
+		}
+		var source map[string]string
+		json.Unmarshal(body, &source)
+		data, ok := f.objects[source["srcBucketName"]+"/"+source["srcObjectName"]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.objects[key] = data
+		w.Write([]byte("{}"))
+	case r.Method == "PUT":
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("content-type")
+		w.Write([]byte("{}"))
+	case r.Method == "GET":
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("content-type", f.types[key])
+		w.Header().Set("x-xiaomi-meta-content-length", fmt.Sprint(len(data)))
+		if !q.Has("metadata") {
+			w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// listTrash 每页最多返回两个object，用于检查分页
+func (f *fakeFDS) listTrash(w http.ResponseWriter, marker string) {
+	sort.Strings(f.trash)
+	names := []string{}
+	for _, name := range f.trash {
+		if name > marker {
+			names = append(names, name)
+		}
+	}
+	truncated := len(names) > 2
+	if truncated {
+		names = names[:2]
+	}
+	objects, next := []map[string]interface{}{}, ""
+	for _, name := range names {
+		objects = append(objects, map[string]interface{}{"name": name, "size": 1,
+			"lastModified": time.Now().Format(time.RFC3339Nano)})
+		next = name
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"name": "trash", "objects": objects,
+		"truncated": truncated, "nextMarker": next})
+}
+
+func (f *fakeFDS) count(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func runCLI(t *testing.T, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUsage(t *testing.T) {
+	if code, _, stderr := runCLI(t, "nosuchcommand"); code != 2 || !strings.Contains(stderr, "unknown command") {
+		t.Error(code, stderr)
+	}
+	t.Setenv(ENV_ACCESS_KEY_ID, "")
+	t.Setenv(ENV_SECRET_ACCESS_KEY, "")
+	config := filepath.Join(t.TempDir(), "missing")
+	if code, _, stderr := runCLI(t, "-config", config, "ls"); code != 1 || !strings.Contains(stderr, "missing credentials") {
+		t.Error(code, stderr)
+	}
+}
+
+func TestCopyUploadDownload(t *testing.T) {
+	f, global := newFakeFDS(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	os.WriteFile(src, []byte("hello"), 0644)
+
+	if code, _, stderr := runCLI(t, append(global, "cp", src, "fds://b/p/")...); code != 0 {
+		t.Fatal(stderr)
+	}
+	if string(f.objects["b/p/a.txt"]) != "hello" || !strings.HasPrefix(f.types["b/p/a.txt"], "text/plain") {
+		t.Fatal(f.objects, f.types)
+	}
+	if code, stdout, stderr := runCLI(t, append(global, "cat", "fds://b/p/a.txt")...); code != 0 || stdout != "hello" {
+		t.Fatal(stdout, stderr)
+	}
+	dst := filepath.Join(dir, "out")
+	os.Mkdir(dst, 0755)
+	if code, _, stderr := runCLI(t, append(global, "cp", "fds://b/p/a.txt", dst)...); code != 0 {
+		t.Fatal(stderr)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "a.txt")); err != nil || string(b) != "hello" {
+		t.Error(string(b), err)
+	}
+}
+
+func TestCopyRemote(t *testing.T) {
+	f, global := newFakeFDS(t)
+	f.objects["b/src"] = []byte("data")
+
+	if code, _, stderr := runCLI(t, append(global, "cp", "fds://b/src", "fds://c/dst")...); code != 0 {
+		t.Fatal(stderr)
+	}
+	if string(f.objects["c/dst"]) != "data" || f.count("GET /b/src") != 0 {
+		t.Fatal("server side copy should be used", f.requests)
+	}
+
+	// 权限等错误直接返回，不下载后重新上传
+	f.copyStatus = http.StatusForbidden
+	if code, _, stderr := runCLI(t, append(global, "cp", "fds://b/src", "fds://c/dst2")...); code != 1 ||
+		!strings.Contains(stderr, "403") {
+		t.Fatal(code, stderr)
+	}
+	if _, ok := f.objects["c/dst2"]; ok || f.count("GET /b/src") != 0 {
+		t.Fatal("copy should not fall back on 403", f.requests)
+	}
+
+	f.copyStatus = http.StatusNotImplemented
+	if code, _, stderr := runCLI(t, append(global, "cp", "fds://b/src", "fds://c/dst3")...); code != 0 {
+		t.Fatal(stderr)
+	}
+	if string(f.objects["c/dst3"]) != "data" || f.count("GET /b/src") == 0 {
+		t.Fatal("copy should fall back to streaming when unsupported", f.requests)
+	}
+}
+
+func TestTrashLs(t *testing.T) {
+	f, global := newFakeFDS(t)
+	f.trash = []string{"b/1", "b/2", "b/3", "b/4", "b/5"}
+
+	code, stdout, stderr := runCLI(t, append(global, "-json", "trash", "ls")...)
+	if code != 0 {
+		t.Fatal(stderr)
+	}
+	var entries []listEntry
+	if err := json.Unmarshal([]byte(stdout), &entries); err != nil || len(entries) != 5 || entries[4].Name != "b/5" {
+		t.Fatal(stdout, err)
+	}
+	if n := f.count("GET /trash"); n != 3 {
+		t.Error("pages", n)
+	}
+
+	code, stdout, _ = runCLI(t, append(global, "-json", "trash", "ls", "-max", "3")...)
+	if json.Unmarshal([]byte(stdout), &entries); code != 0 || len(entries) != 3 {
+		t.Error(stdout)
+	}
+}
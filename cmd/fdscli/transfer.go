@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	FDS_SCHEME = "fds://"
+	// 超过该大小使用分片上传
+	MULTIPART_THRESHOLD int64 = 64 * 1024 * 1024
+	PART_SIZE           int64 = 32 * 1024 * 1024
+	// 超过该大小时显示进度
+	PROGRESS_THRESHOLD int64 = 8 * 1024 * 1024
+)
+
+// location 表示本地路径或者fds://bucket/object
+type location struct {
+	remote bool
+	bucket string
+	object string
+	local  string
+}
+
+func parseLocation(s string) location {
+	if !strings.HasPrefix(s, FDS_SCHEME) {
+		return location{local: s}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(s, FDS_SCHEME), "/", 2)
+	l := location{remote: true, bucket: parts[0]}
+	if len(parts) == 2 {
+		l.object = parts[1]
+	}
+	return l
+}
+
+func (l location) String() string {
+	if !l.remote {
+		return l.local
+	}
+	return FDS_SCHEME + l.bucket + "/" + l.object
+}
+
+func parseRemote(s string, needObject bool) (location, error) {
+	l := parseLocation(s)
+	if !l.remote || len(l.bucket) == 0 {
+		return l, fmt.Errorf("%q is not an fds:// path", s)
+	}
+	if needObject && len(l.object) == 0 {
+		return l, fmt.Errorf("%q has no object name", s)
+	}
+	return l, nil
+}
+
+// progress 统计写入的字节数并定期在stderr上刷新进度
+type progress struct {
+	w     io.Writer
+	label string
+	total int64
+	done  int64
+	last  time.Time
+}
+
+func (c *cli) newProgress(label string, total int64) *progress {
+	if c.quiet || c.jsonOutput || total < PROGRESS_THRESHOLD {
+		return nil
+	}
+	if f, ok := c.stderr.(*os.File); ok {
+		if fi, err := f.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+			return nil
+		}
+	}
+	return &progress{w: c.stderr, label: label, total: total}
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.done += int64(len(b))
+	if now := time.Now(); now.Sub(p.last) > 200*time.Millisecond || p.done == p.total {
+		p.last = now
+		fmt.Fprintf(p.w, "\r%s  %s / %s (%d%%)", p.label, humanSize(p.done), humanSize(p.total),
+			p.done*100/p.total)
+	}
+	return len(b), nil
+}
+
+func (p *progress) finish() {
+	fmt.Fprintln(p.w)
+}
+
+// track 返回会更新进度的reader，p为nil时原样返回
+func (p *progress) track(r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return io.TeeReader(r, p)
+}
+
+func (p *progress) close() {
+	if p != nil {
+		p.finish()
+	}
+}
+
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// putStream 上传大小为size的数据，超过MULTIPART_THRESHOLD时使用分片上传
+func (c *cli) putStream(r io.Reader, size int64, dst location, contentType string) error {
+	p := c.newProgress(dst.String(), size)
+	defer p.close()
+	r = p.track(r)
+
+	if size <= MULTIPART_THRESHOLD {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		_, err = c.client.Put_Object(dst.bucket, dst.object, data, contentType, nil)
+		return err
+	}
+
+	initResult, err := c.client.Init_MultiPart_Upload(dst.bucket, dst.object, contentType)
+	if err != nil {
+		return err
+	}
+	var parts Model.UploadPartList
+	buf := make([]byte, PART_SIZE)
+	for partNumber := 1; ; partNumber++ {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			partResult, err := c.client.Upload_Part(initResult, partNumber, buf[:n])
+			if err != nil {
+				c.client.Abort_MultipartUpload(initResult)
+				return err
+			}
+			parts.AddUploadPartResult(partResult)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			c.client.Abort_MultipartUpload(initResult)
+			return readErr
+		}
+	}
+	_, err = c.client.Complete_Multipart_Upload(initResult, &parts)
+	return err
+}
+
+func (c *cli) upload(src string, dst location) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%s is a directory", src)
+	}
+	if len(dst.object) == 0 || strings.HasSuffix(dst.object, "/") {
+		dst.object += filepath.Base(src)
+	}
+	return c.putStream(f, fi.Size(), dst, mime.TypeByExtension(filepath.Ext(src)))
+}
+
+func (c *cli) download(src location, dst string) error {
+	if fi, err := os.Stat(dst); (err == nil && fi.IsDir()) || strings.HasSuffix(dst, string(os.PathSeparator)) {
+		dst = filepath.Join(dst, path.Base(src.object))
+	}
+	meta, err := c.client.Get_Object_Meta(src.bucket, src.object)
+	if err != nil {
+		return err
+	}
+	size := objectSize(meta)
+	reader, err := c.client.Get_Object_Reader(src.bucket, src.object, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer (*reader).Close()
+
+	tmp := dst + ".fdscli-tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	p := c.newProgress(src.String(), size)
+	_, err = io.Copy(f, p.track(*reader))
+	p.close()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func (c *cli) copyRemote(src, dst location) error {
+	if len(dst.object) == 0 || strings.HasSuffix(dst.object, "/") {
+		dst.object += path.Base(src.object)
+	}
+	// 源和目标使用同一个client，优先使用服务端复制，只有服务端不支持时才下载后重新上传
+	_, err := c.client.Copy_Object(src.bucket, src.object, dst.bucket, dst.object)
+	if !galaxy_fds_sdk_golang.IsCopyUnsupported(err) {
+		return err
+	}
+	meta, err := c.client.Get_Object_Meta(src.bucket, src.object)
+	if err != nil {
+		return err
+	}
+	size := objectSize(meta)
+	reader, err := c.client.Get_Object_Reader(src.bucket, src.object, 0, -1)
+	if err != nil {
+		return err
+	}
+	defer (*reader).Close()
+	contentType, _ := meta.GetContentType()
+	return c.putStream(*reader, size, dst, contentType)
+}
+
+// objectSize 从Get_Object_Meta的结果中取得object大小
+func objectSize(meta *Model.FDSMetaData) int64 {
+	if size, err := meta.GetMetadataContentLength(); err == nil {
+		return size
+	}
+	size, _ := meta.GetContentLength()
+	return size
+}
+
+func (c *cli) copy(src, dst location) error {
+	switch {
+	case !src.remote && dst.remote:
+		return c.upload(src.local, dst)
+	case src.remote && !dst.remote:
+		return c.download(src, dst.local)
+	case src.remote && dst.remote:
+		return c.copyRemote(src, dst)
+	}
+	return fmt.Errorf("at least one of %s and %s must be an fds:// path", src, dst)
+}
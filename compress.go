@@ -0,0 +1,188 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/zstd"
+)
+
+const (
+	// COMPRESSION_GZIP、COMPRESSION_ZSTD 内置的压缩方式，其它content-encoding需要通过RegisterCompressionCodec注册
+	COMPRESSION_GZIP = "gzip"
+	COMPRESSION_ZSTD = "zstd"
+)
+
+// CompressionCodec 一种content-encoding的压缩和解压实现
+type CompressionCodec interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// zstdCodec 使用zstd子包的实现，压缩率低于zstd命令行工具的默认级别，可以解压其它实现生成的数据
+type zstdCodec struct{}
+
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w), nil
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	z, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+var (
+	compressionMu     sync.RWMutex
+	compressionCodecs = map[string]CompressionCodec{COMPRESSION_GZIP: gzipCodec{}, COMPRESSION_ZSTD: zstdCodec{}}
+)
+
+// RegisterCompressionCodec 注册encoding对应的压缩实现，已经存在时覆盖
+func RegisterCompressionCodec(encoding string, codec CompressionCodec) {
+	compressionMu.Lock()
+	defer compressionMu.Unlock()
+	compressionCodecs[strings.ToLower(encoding)] = codec
+}
+
+func compressionCodec(encoding string) (CompressionCodec, bool) {
+	compressionMu.RLock()
+	defer compressionMu.RUnlock()
+	codec, ok := compressionCodecs[strings.ToLower(strings.TrimSpace(encoding))]
+	return codec, ok
+}
+
+func requireCompressionCodec(encoding string) (CompressionCodec, error) {
+	codec, ok := compressionCodec(encoding)
+	if !ok {
+		return nil, Model.NewFDSError("unsupported compression: "+encoding+
+			", register a codec with RegisterCompressionCodec", -1)
+	}
+	return codec, nil
+}
+
+// compressedMetadata 返回设置了content-encoding和原始长度的metadata副本，size小于0时不记录原始长度
+func compressedMetadata(metadata *Model.ObjectMetadata, encoding string, size int64) *Model.ObjectMetadata {
+	m := Model.NewObjectMetadata()
+	if metadata != nil {
+		m = metadata.Clone()
+	}
+	m.SetContentEncoding(encoding)
+	if size >= 0 {
+		m.SetUserMetadata(Model.UncompressedLength, strconv.FormatInt(size, 10))
+	}
+	return m
+}
+
+// Put_Object_Compressed 使用encoding压缩data后上传，设置content-encoding并在x-xiaomi-meta-uncompressed-length中记录原始长度。
+// 下载时使用Get_Object_Decompressed等方法或开启AutoDecompress得到原始内容
+func (c *FDSClient) Put_Object_Compressed(bucketname, objectname string, data []byte, encoding string,
+	metadata *Model.ObjectMetadata) (*Model.PutObjectResult, error) {
+	codec, err := requireCompressionCodec(encoding)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := codec.NewWriter(&buf)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if err := w.Close(); err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return c.Put_Object_With_Metadata(bucketname, objectname, buf.Bytes(),
+		compressedMetadata(metadata, encoding, int64(len(data))))
+}
+
+// Put_Reader_Compressed 边读边压缩上传r中的内容，压缩后超过MULTIPART_UPLOAD_THRESHOLD时使用分片上传。
+// size为r的原始长度，小于0表示未知，此时不记录原始长度
+func (c *FDSClient) Put_Reader_Compressed(bucketname, objectname string, r io.Reader, size int64, encoding string,
+	metadata *Model.ObjectMetadata) error {
+	codec, err := requireCompressionCodec(encoding)
+	if err != nil {
+		return err
+	}
+	contentType, headers, err := metadataHeaders(compressedMetadata(metadata, encoding, size))
+	if err != nil {
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		w, err := codec.NewWriter(pw)
+		if err == nil {
+			_, err = io.Copy(w, r)
+			if closeErr := w.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	err = c.putReader(bucketname, objectname, pr, -1, contentType, headers)
+	pr.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+// decompressReadCloser 关闭时同时关闭解压器和原始的body
+type decompressReadCloser struct {
+	io.ReadCloser
+	body io.Closer
+}
+
+func (d *decompressReadCloser) Close() error {
+	err := d.ReadCloser.Close()
+	if bodyErr := d.body.Close(); err == nil {
+		err = bodyErr
+	}
+	return err
+}
+
+// decompressBody 按照content-encoding包装body，encoding为空或无法识别时原样返回
+func decompressBody(header map[string][]string, body io.ReadCloser) (io.ReadCloser, error) {
+	encoding, _ := Model.NewFDSMetaData(header).GetContentEncoding()
+	codec, ok := compressionCodec(encoding)
+	if len(encoding) == 0 || !ok {
+		return body, nil
+	}
+	r, err := codec.NewReader(body)
+	if err != nil {
+		body.Close()
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return &decompressReadCloser{ReadCloser: r, body: body}, nil
+}
+
+// Get_Object_Decompressed 获取整个object，content-encoding可以识别时返回解压后的内容，不受AutoDecompress影响
+func (c *FDSClient) Get_Object_Decompressed(bucketname, objectname string) (*Model.FDSObject, error) {
+	return c.getObjectContent(bucketname, objectname, "", 0, -1, true, nil)
+}
+
+// Get_Object_Reader_Decompressed 读取整个object，content-encoding可以识别时返回解压后的内容，不受AutoDecompress影响
+func (c *FDSClient) Get_Object_Reader_Decompressed(bucketname, objectname string) (io.ReadCloser, error) {
+	reader, _, err := c.getObjectResponse(bucketname, objectname, "", 0, -1, true, nil)
+	return reader, err
+}
+
+// Download_Object_Decompressed 与Download_Object相同，content-encoding可以识别时写入解压后的内容，不受AutoDecompress影响，
+// 此时返回的md5是解压后内容的md5，与服务端的content-md5不同
+func (c *FDSClient) Download_Object_Decompressed(bucketname, objectname, filename string) (*string, error) {
+	return c.downloadObject(bucketname, objectname, filename, true, nil)
+}
@@ -0,0 +1,193 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	// DELETE_OBJECTS_BATCH_SIZE 服务端一次批量删除请求最多允许的object数量
+	DELETE_OBJECTS_BATCH_SIZE = 1000
+
+	DEFAULT_DELETE_CONCURRENCY = 4
+	DEFAULT_DELETE_RETRIES     = 2
+)
+
+// BulkDeleteOptions Bulk_Delete_Objects和Bulk_Delete_Prefix的参数
+type BulkDeleteOptions struct {
+	// BatchSize 每个请求删除的object数量，默认并且最大为DELETE_OBJECTS_BATCH_SIZE
+	BatchSize   int
+	Concurrency int
+	// Retries 删除失败的object重试的次数，默认DEFAULT_DELETE_RETRIES，小于0时不重试
+	Retries int
+	// AllowEmptyPrefix Bulk_Delete_Prefix默认拒绝空前缀，避免误删整个bucket
+	AllowEmptyPrefix bool
+	// MaxObjects 大于0时，要删除的object超过该数量则不删除任何object并返回错误
+	MaxObjects int
+	// DryRun 为true时只列出将要删除的object
+	DryRun bool
+	// Progress 每完成一个请求调用一次，不会并发调用
+	Progress func(deleted, failed, total int)
+}
+
+// DeleteFailure 删除失败的object，Code和Message为最后一次尝试的错误
+type DeleteFailure struct {
+	Key     string
+	Code    int
+	Message string
+}
+
+type BulkDeleteResult struct {
+	Deleted  []string
+	Failed   []DeleteFailure
+	Requests int
+}
+
+// Summary 返回一行可读的统计信息
+func (r *BulkDeleteResult) Summary() string {
+	return fmt.Sprintf("deleted %d, failed %d, requests %d", len(r.Deleted), len(r.Failed), r.Requests)
+}
+
+func (o *BulkDeleteOptions) normalize() {
+	if o.BatchSize <= 0 || o.BatchSize > DELETE_OBJECTS_BATCH_SIZE {
+		o.BatchSize = DELETE_OBJECTS_BATCH_SIZE
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DEFAULT_DELETE_CONCURRENCY
+	}
+	if o.Retries == 0 {
+		o.Retries = DEFAULT_DELETE_RETRIES
+	} else if o.Retries < 0 {
+		o.Retries = 0
+	}
+}
+
+// deleteBatches 并发删除keys，返回仍然失败的object
+func (c *FDSClient) deleteBatches(bucketname string, keys []string, o *BulkDeleteOptions,
+	result *BulkDeleteResult, total int) map[string]DeleteFailure {
+	var batches [][]string
+	for start := 0; start < len(keys); start += o.BatchSize {
+		end := start + o.BatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batches = append(batches, keys[start:end])
+	}
+
+	failed := map[string]DeleteFailure{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan []string)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range ch {
+				errs, err := c.Delete_Objects_Result(bucketname, batch)
+				mu.Lock()
+				result.Requests++
+				batchFailed := map[string]bool{}
+				if err != nil {
+					code, msg := -1, err.Error()
+					var fdsErr *Model.FDSError
+					if errors.As(err, &fdsErr) {
+						code, msg = fdsErr.Code(), fdsErr.Message()
+					}
+					for _, key := range batch {
+						failed[key] = DeleteFailure{Key: key, Code: code, Message: msg}
+						batchFailed[key] = true
+					}
+				}
+				for _, e := range errs {
+					failed[e.ObjectName] = DeleteFailure{Key: e.ObjectName, Code: e.ErrorCode, Message: e.ErrorDescription}
+					batchFailed[e.ObjectName] = true
+				}
+				for _, key := range batch {
+					if !batchFailed[key] {
+						result.Deleted = append(result.Deleted, key)
+					}
+				}
+				if o.Progress != nil {
+					o.Progress(len(result.Deleted), len(failed), total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, batch := range batches {
+		ch <- batch
+	}
+	close(ch)
+	wg.Wait()
+	return failed
+}
+
+// Bulk_Delete_Objects 按BatchSize分批并发删除keys，解析每个请求返回的删除失败的object并重试，
+// 返回已删除和最终失败的object。有object删除失败时同时返回错误
+func (c *FDSClient) Bulk_Delete_Objects(bucketname string, keys []string,
+	opts *BulkDeleteOptions) (*BulkDeleteResult, error) {
+	o := BulkDeleteOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.normalize()
+	if !checkNotEmpty(bucketname) {
+		return nil, Model.NewFDSError("empty bucket name", -1)
+	}
+	if o.MaxObjects > 0 && len(keys) > o.MaxObjects {
+		return nil, Model.NewFDSError(fmt.Sprintf("refusing to delete %d objects, more than the limit %d",
+			len(keys), o.MaxObjects), -1)
+	}
+	result := &BulkDeleteResult{}
+	if o.DryRun {
+		result.Deleted = append(result.Deleted, keys...)
+		return result, nil
+	}
+
+	pending := keys
+	var failed map[string]DeleteFailure
+	for attempt := 0; attempt <= o.Retries && len(pending) > 0; attempt++ {
+		failed = c.deleteBatches(bucketname, pending, &o, result, len(keys))
+		pending = pending[:0:0]
+		for key := range failed {
+			pending = append(pending, key)
+		}
+		sort.Strings(pending)
+	}
+	for _, key := range pending {
+		result.Failed = append(result.Failed, failed[key])
+	}
+	sort.Strings(result.Deleted)
+	if len(result.Failed) > 0 {
+		first := result.Failed[0]
+		return result, Model.NewFDSError(result.Summary()+", first error: "+first.Key+": "+first.Message, first.Code)
+	}
+	return result, nil
+}
+
+// Bulk_Delete_Prefix 列出prefix下的所有object后调用Bulk_Delete_Objects删除。
+// 空前缀(整个bucket)需要设置AllowEmptyPrefix，设置MaxObjects时object过多不会删除任何object
+func (c *FDSClient) Bulk_Delete_Prefix(bucketname, prefix string,
+	opts *BulkDeleteOptions) (*BulkDeleteResult, error) {
+	if len(prefix) == 0 && (opts == nil || !opts.AllowEmptyPrefix) {
+		return nil, Model.NewFDSError("refusing to delete every object in bucket "+bucketname+
+			" without AllowEmptyPrefix", -1)
+	}
+	keys := []string{}
+	err := c.walkObjects(bucketname, prefix, func(summary Model.FDSObjectSummary) error {
+		keys = append(keys, summary.ObjectName)
+		if opts != nil && opts.MaxObjects > 0 && len(keys) > opts.MaxObjects {
+			return Model.NewFDSError(fmt.Sprintf("refusing to delete more than %d objects under %s/%s",
+				opts.MaxObjects, bucketname, prefix), -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.Bulk_Delete_Objects(bucketname, keys, opts)
+}
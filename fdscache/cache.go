@@ -0,0 +1,506 @@
+// Package fdscache 为object读取提供本地磁盘缓存。缓存按大小限制，超出时按LRU淘汰，超过上限的object不缓存，直接从FDS读取；
+// 超过TTL后通过Get_Object_Meta比较content-md5和last-modified重新验证，FDS暂时不可用时继续使用过期的缓存；
+// 同一个object的并发请求只会下载一次；索引保存在磁盘上，进程重启后缓存仍然有效
+package fdscache
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	INDEX_FILE       = "index.json"
+	DATA_DIR         = "data"
+	DEFAULT_TTL      = 5 * time.Minute
+	DEFAULT_MAX_SIZE = 10 * 1024 * 1024 * 1024
+	// INDEX_SAVE_INTERVAL 命中缓存时更新的最近访问时间最多间隔该时间写入索引，Flush会立即写入
+	INDEX_SAVE_INTERVAL = 10 * time.Second
+)
+
+type Options struct {
+	// Dir 缓存目录，不存在时自动创建
+	Dir string
+	// MaxBytes 缓存总大小上限，默认10GB，大于该值的object不缓存
+	MaxBytes int64
+	// TTL 缓存在该时间内直接使用，超过后重新验证，默认5分钟
+	TTL time.Duration
+}
+
+// entry 缓存条目，File为空时表示object太大没有缓存
+type entry struct {
+	Bucket       string              `json:"bucket"`
+	Object       string              `json:"object"`
+	File         string              `json:"file"`
+	Size         int64               `json:"size"`
+	ContentMD5   string              `json:"contentMd5"`
+	LastModified string              `json:"lastModified"`
+	Metadata     map[string][]string `json:"metadata"`
+	Validated    time.Time           `json:"validated"`
+	LastAccess   time.Time           `json:"lastAccess"`
+}
+
+type call struct {
+	wg  sync.WaitGroup
+	e   *entry
+	err error
+}
+
+// Cache 包装FDSClient的读取接口，可以在多个goroutine中使用
+type Cache struct {
+	client *galaxy_fds_sdk_golang.FDSClient
+	opts   Options
+
+	mu       sync.Mutex
+	entries  map[string]*entry
+	total    int64
+	inflight map[string]*call
+	// dirty 最近访问时间有更新但还没有写入索引
+	dirty bool
+	saved time.Time
+}
+
+// New 创建缓存并加载磁盘上已有的索引
+func New(client *galaxy_fds_sdk_golang.FDSClient, opts Options) (*Cache, error) {
+	if len(opts.Dir) == 0 {
+		return nil, Model.NewFDSError("cache dir is required", -1)
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DEFAULT_MAX_SIZE
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = DEFAULT_TTL
+	}
+	if err := os.MkdirAll(filepath.Join(opts.Dir, DATA_DIR), 0755); err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	c := &Cache{
+		client:   client,
+		opts:     opts,
+		entries:  map[string]*entry{},
+		inflight: map[string]*call{},
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func cacheKey(bucketname, objectname string) string {
+	return bucketname + galaxy_fds_sdk_golang.DELIMITER + objectname
+}
+
+func (c *Cache) dataPath(file string) string {
+	return filepath.Join(c.opts.Dir, DATA_DIR, file)
+}
+
+// load 读取索引，丢弃数据文件已经不存在的条目，删除没有被索引引用的数据文件
+func (c *Cache) load() error {
+	data, err := ioutil.ReadFile(filepath.Join(c.opts.Dir, INDEX_FILE))
+	if err != nil && !os.IsNotExist(err) {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	if err == nil {
+		var entries []*entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			// 索引损坏时当作空缓存
+			entries = nil
+		}
+		for _, e := range entries {
+			fi, err := os.Stat(c.dataPath(e.File))
+			if err != nil || fi.Size() != e.Size {
+				continue
+			}
+			c.entries[cacheKey(e.Bucket, e.Object)] = e
+			c.total += e.Size
+		}
+	}
+	referenced := map[string]bool{}
+	for _, e := range c.entries {
+		referenced[e.File] = true
+	}
+	files, err := ioutil.ReadDir(filepath.Join(c.opts.Dir, DATA_DIR))
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	for _, fi := range files {
+		if !referenced[fi.Name()] {
+			os.Remove(c.dataPath(fi.Name()))
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictLocked("")
+	return c.saveLocked()
+}
+
+// saveLocked 将索引写入磁盘，调用时需要持有c.mu
+func (c *Cache) saveLocked() error {
+	entries := make([]*entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	index := filepath.Join(c.opts.Dir, INDEX_FILE)
+	if err := ioutil.WriteFile(index+".tmp", data, 0644); err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	if err := os.Rename(index+".tmp", index); err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	c.dirty = false
+	c.saved = time.Now()
+	return nil
+}
+
+// Flush 将命中缓存时更新的最近访问时间写入索引，进程退出前调用可以使重启后的淘汰顺序不变
+func (c *Cache) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	return c.saveLocked()
+}
+
+// evictLocked 按最近访问时间淘汰，直到总大小不超过MaxBytes，keep指定的条目不会被淘汰；
+// 超过MaxBytes的object不会缓存，所以只保留keep时总大小也不会超过上限
+func (c *Cache) evictLocked(keep string) {
+	if c.total <= c.opts.MaxBytes {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for k := range c.entries {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].LastAccess.Before(c.entries[keys[j]].LastAccess)
+	})
+	for _, k := range keys {
+		if c.total <= c.opts.MaxBytes {
+			break
+		}
+		if k == keep {
+			continue
+		}
+		c.removeLocked(k)
+	}
+}
+
+func (c *Cache) removeLocked(key string) {
+	e, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	c.total -= e.Size
+	// 已经打开的reader在unix上仍然可以继续读取
+	os.Remove(c.dataPath(e.File))
+}
+
+// Invalidate 删除指定object的缓存
+func (c *Cache) Invalidate(bucketname, objectname string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(cacheKey(bucketname, objectname))
+	return c.saveLocked()
+}
+
+// Purge 删除所有缓存
+func (c *Cache) Purge() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		c.removeLocked(k)
+	}
+	return c.saveLocked()
+}
+
+// Size 返回当前缓存的总大小
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// ensure 返回可用的缓存条目，必要时重新验证或下载；同一个key同时只有一个请求访问FDS
+func (c *Cache) ensure(bucketname, objectname string) (*entry, error) {
+	key := cacheKey(bucketname, objectname)
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && time.Since(e.Validated) < c.opts.TTL {
+		e.LastAccess = time.Now()
+		c.dirty = true
+		if time.Since(c.saved) >= INDEX_SAVE_INTERVAL {
+			c.saveLocked()
+		}
+		c.mu.Unlock()
+		return e, nil
+	}
+	if cl, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		cl.wg.Wait()
+		return cl.e, cl.err
+	}
+	cl := &call{}
+	cl.wg.Add(1)
+	c.inflight[key] = cl
+	cached := c.entries[key]
+	c.mu.Unlock()
+
+	cl.e, cl.err = c.refresh(bucketname, objectname, cached)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	cl.wg.Done()
+	return cl.e, cl.err
+}
+
+func sameVersion(e *entry, meta *Model.FDSMetaData) bool {
+	md5sum, _ := meta.GetContentMD5()
+	lastModified, _ := meta.GetLastModified()
+	return md5sum == e.ContentMD5 && lastModified == e.LastModified
+}
+
+// transient 判断是否为网络错误、限流或服务端错误，此时可以继续使用过期的缓存
+func transient(err error) bool {
+	var fdsErr *Model.FDSError
+	if !errors.As(err, &fdsErr) {
+		return false
+	}
+	code := fdsErr.Code()
+	return code == -1 || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// refresh 重新验证或下载object。FDS暂时不可用时返回过期的缓存，不更新验证时间，下一次读取会再次验证
+func (c *Cache) refresh(bucketname, objectname string, cached *entry) (*entry, error) {
+	key := cacheKey(bucketname, objectname)
+	meta, err := c.client.Get_Object_Meta(bucketname, objectname)
+	if err != nil {
+		var fdsErr *Model.FDSError
+		if errors.As(err, &fdsErr) && fdsErr.Code() == http.StatusNotFound {
+			c.Invalidate(bucketname, objectname)
+		}
+		return c.stale(cached, err)
+	}
+	now := time.Now()
+	if cached != nil && sameVersion(cached, meta) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		cached.Validated = now
+		cached.LastAccess = now
+		return cached, c.saveLocked()
+	}
+
+	if size, err := meta.GetMetadataContentLength(); err == nil && size > c.opts.MaxBytes {
+		return c.uncached(bucketname, objectname)
+	}
+	file := fmt.Sprintf("%x-%d", sha1.Sum([]byte(key)), now.UnixNano())
+	// 验证信息以GET响应为准，object可能在获取metadata之后被修改
+	size, meta, err := c.fetch(bucketname, objectname, c.dataPath(file))
+	if err == errTooLarge {
+		return c.uncached(bucketname, objectname)
+	}
+	if err != nil {
+		return c.stale(cached, err)
+	}
+	md5sum, _ := meta.GetContentMD5()
+	lastModified, _ := meta.GetLastModified()
+	e := &entry{
+		Bucket:       bucketname,
+		Object:       objectname,
+		File:         file,
+		Size:         size,
+		ContentMD5:   md5sum,
+		LastModified: lastModified,
+		Metadata:     meta.GetRawMetadata(),
+		Validated:    now,
+		LastAccess:   now,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+	c.entries[key] = e
+	c.total += size
+	c.evictLocked(key)
+	return e, c.saveLocked()
+}
+
+// uncached 删除object之前的缓存，返回不缓存的条目，读取时直接从FDS获取
+func (c *Cache) uncached(bucketname, objectname string) (*entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(cacheKey(bucketname, objectname))
+	return &entry{Bucket: bucketname, Object: objectname}, c.saveLocked()
+}
+
+// stale 重新验证失败时，错误是暂时的并且有缓存则返回缓存，否则返回err
+func (c *Cache) stale(cached *entry, err error) (*entry, error) {
+	if cached == nil || !transient(err) {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[cacheKey(cached.Bucket, cached.Object)]; !ok {
+		return nil, err
+	}
+	cached.LastAccess = time.Now()
+	return cached, nil
+}
+
+var errTooLarge = errors.New("fdscache: object is larger than MaxBytes")
+
+// fetch 下载object到临时文件后rename到filename，返回大小和响应中的metadata，超过MaxBytes时返回errTooLarge
+func (c *Cache) fetch(bucketname, objectname, filename string) (int64, *Model.FDSMetaData, error) {
+	reader, meta, err := c.client.Get_Object_Reader_With_Metadata(bucketname, objectname, 0, -1)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer reader.Close()
+	tmp := filename + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, nil, Model.NewFDSError(err.Error(), -1)
+	}
+	size, err := io.Copy(f, io.LimitReader(reader, c.opts.MaxBytes+1))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > c.opts.MaxBytes {
+		os.Remove(tmp)
+		return 0, nil, errTooLarge
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return size, meta, nil
+}
+
+func checkRange(position, size int64) error {
+	if position < 0 {
+		return Model.NewFDSError("Seek position should be no less than 0", -1)
+	}
+	if size == 0 {
+		return Model.NewFDSError("Request size should be larger than 0", -1)
+	}
+	return nil
+}
+
+// Get_Object 与FDSClient.Get_Object相同，内容从缓存中读取
+func (c *Cache) Get_Object(bucketname, objectname string, position int64, size int64) (*Model.FDSObject, error) {
+	if err := checkRange(position, size); err != nil {
+		return nil, err
+	}
+	reader, e, err := c.open(bucketname, objectname, position, size)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return &Model.FDSObject{
+		BucketName:    bucketname,
+		ObjectName:    objectname,
+		Metadata:      *Model.NewFDSMetaData(e.Metadata),
+		ObjectContent: content,
+	}, nil
+}
+
+// Get_Object_Reader 与FDSClient.Get_Object_Reader相同，返回缓存文件的reader
+func (c *Cache) Get_Object_Reader(bucketname, objectname string, position int64, size int64) (*io.ReadCloser, error) {
+	if err := checkRange(position, size); err != nil {
+		return nil, err
+	}
+	reader, _, err := c.open(bucketname, objectname, position, size)
+	if err != nil {
+		return nil, err
+	}
+	return &reader, nil
+}
+
+// Download_Object 与FDSClient.Download_Object相同，从缓存复制到filename
+func (c *Cache) Download_Object(bucketname, objectname, filename string) (*string, error) {
+	reader, e, err := c.open(bucketname, objectname, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	_, err = io.Copy(f, reader)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	md5sum := e.ContentMD5
+	return &md5sum, nil
+}
+
+type limitedFile struct {
+	io.Reader
+	f *os.File
+}
+
+func (l *limitedFile) Close() error {
+	return l.f.Close()
+}
+
+func (c *Cache) open(bucketname, objectname string, position, size int64) (io.ReadCloser, *entry, error) {
+	e, err := c.ensure(bucketname, objectname)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(e.File) == 0 {
+		// 没有缓存，metadata取自这次GET的响应
+		reader, meta, err := c.client.Get_Object_Reader_With_Metadata(bucketname, objectname, position, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		md5sum, _ := meta.GetContentMD5()
+		return reader, &entry{Bucket: bucketname, Object: objectname, ContentMD5: md5sum,
+			Metadata: meta.GetRawMetadata()}, nil
+	}
+	f, err := os.Open(c.dataPath(e.File))
+	if err != nil {
+		// 文件被淘汰，直接从FDS读取
+		reader, _, err := c.client.Get_Object_Reader_With_Metadata(bucketname, objectname, position, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, e, nil
+	}
+	if _, err := f.Seek(position, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, Model.NewFDSError(err.Error(), -1)
+	}
+	var r io.Reader = f
+	if size > 0 {
+		r = io.LimitReader(f, size)
+	}
+	return &limitedFile{Reader: r, f: f}, e, nil
+}
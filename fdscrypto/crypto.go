@@ -0,0 +1,444 @@
+// Package fdscrypto 提供客户端加密。每个object使用随机生成的数据密钥通过AES-256-GCM加密，
+// 数据密钥由KeyProvider加密后和加密参数一起保存在x-xiaomi-meta-encryption-*中。
+// 内容按ChunkSize分块加密，每块带有独立的认证标签，可以边读边解密，范围读取时只下载需要的块；
+// 块序号和是否为最后一块参与认证，重排或截断都会导致解密失败
+package fdscrypto
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	ALGORITHM          = "AES256-GCM-CHUNKED"
+	DEFAULT_CHUNK_SIZE = 64 * 1024
+	MAX_CHUNK_SIZE     = 16 * 1024 * 1024
+
+	META_ALGORITHM   = Model.UserMetadataPrefix + "encryption-algorithm"
+	META_WRAPPED_KEY = Model.UserMetadataPrefix + "encryption-key"
+	META_KEY_ID      = Model.UserMetadataPrefix + "encryption-key-id"
+	META_NONCE       = Model.UserMetadataPrefix + "encryption-nonce"
+	META_CHUNK_SIZE  = Model.UserMetadataPrefix + "encryption-chunk-size"
+
+	dataKeySize = 32
+	tagSize     = 16
+	nonceSize   = 12
+)
+
+type Options struct {
+	// KeyProvider 加密和解密数据密钥，必须设置
+	KeyProvider KeyProvider
+	// ChunkSize 加密块的大小，默认DEFAULT_CHUNK_SIZE，只影响上传，下载时使用object中记录的值
+	ChunkSize int
+}
+
+// Client 包装FDSClient的上传和下载接口，可以在多个goroutine中使用
+type Client struct {
+	client *galaxy_fds_sdk_golang.FDSClient
+	opts   Options
+}
+
+func New(client *galaxy_fds_sdk_golang.FDSClient, opts Options) (*Client, error) {
+	if opts.KeyProvider == nil {
+		return nil, Model.NewFDSError("key provider is required", -1)
+	}
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = DEFAULT_CHUNK_SIZE
+	}
+	if opts.ChunkSize < 0 || opts.ChunkSize > MAX_CHUNK_SIZE {
+		return nil, Model.NewFDSError("invalid chunk size: "+strconv.Itoa(opts.ChunkSize), -1)
+	}
+	return &Client{client: client, opts: opts}, nil
+}
+
+// EncryptedSize 返回plainSize字节的内容加密后的长度
+func EncryptedSize(plainSize int64, chunkSize int) int64 {
+	chunks := (plainSize + int64(chunkSize) - 1) / int64(chunkSize)
+	if chunks == 0 {
+		chunks = 1
+	}
+	return plainSize + chunks*tagSize
+}
+
+// plainSize 由加密后的长度计算块数和原始长度
+func plainSize(encSize int64, chunkSize int) (int64, int64, error) {
+	encChunk := int64(chunkSize + tagSize)
+	chunks := (encSize + encChunk - 1) / encChunk
+	if chunks == 0 || encSize-(chunks-1)*encChunk < tagSize {
+		return 0, 0, Model.NewFDSError("invalid encrypted object size: "+strconv.FormatInt(encSize, 10), -1)
+	}
+	return encSize - chunks*tagSize, chunks, nil
+}
+
+// chunkCipher 按块序号计算nonce和附加数据：nonce为基础nonce的后8字节异或块序号，
+// 附加数据为块序号和最后一块的标记
+type chunkCipher struct {
+	aead  cipher.AEAD
+	nonce []byte
+}
+
+func (c *chunkCipher) params(index int64, final bool) ([]byte, []byte) {
+	nonce := make([]byte, nonceSize)
+	copy(nonce, c.nonce)
+	binary.BigEndian.PutUint64(nonce[4:], binary.BigEndian.Uint64(nonce[4:])^uint64(index))
+	ad := make([]byte, 9)
+	binary.BigEndian.PutUint64(ad, uint64(index))
+	if final {
+		ad[8] = 1
+	}
+	return nonce, ad
+}
+
+func (c *chunkCipher) seal(index int64, final bool, plain []byte) []byte {
+	nonce, ad := c.params(index, final)
+	return c.aead.Seal(nil, nonce, plain, ad)
+}
+
+func (c *chunkCipher) open(index int64, final bool, data []byte) ([]byte, error) {
+	nonce, ad := c.params(index, final)
+	plain, err := c.aead.Open(nil, nonce, data, ad)
+	if err != nil {
+		return nil, Model.NewFDSError("failed to decrypt chunk "+strconv.FormatInt(index, 10)+": "+err.Error(), -1)
+	}
+	return plain, nil
+}
+
+// encryptReader 边读边加密，多读一块用来判断当前块是否为最后一块
+type encryptReader struct {
+	src       io.Reader
+	cipher    *chunkCipher
+	chunkSize int
+	index     int64
+	next      []byte
+	nextErr   error
+	started   bool
+	done      bool
+	out       []byte
+}
+
+func (e *encryptReader) fill() {
+	buf := make([]byte, e.chunkSize)
+	n, err := io.ReadFull(e.src, buf)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	e.next, e.nextErr = buf[:n], err
+}
+
+func (e *encryptReader) sealNext() error {
+	if !e.started {
+		e.fill()
+		e.started = true
+	}
+	cur, err := e.next, e.nextErr
+	if err != nil && err != io.EOF {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	final := err == io.EOF
+	if !final {
+		e.fill()
+		final = e.nextErr == io.EOF && len(e.next) == 0
+	}
+	e.out = e.cipher.seal(e.index, final, cur)
+	e.index++
+	e.done = final
+	return nil
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if err := e.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// newEnvelope 生成数据密钥和nonce，返回加密后的reader和带有加密参数的metadata副本
+func (c *Client) newEnvelope(r io.Reader, metadata *Model.ObjectMetadata) (io.Reader, *Model.ObjectMetadata, error) {
+	dataKey := make([]byte, dataKeySize)
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, Model.NewFDSError(err.Error(), -1)
+	}
+	wrapped, keyId, err := c.opts.KeyProvider.WrapKey(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := Model.NewObjectMetadata()
+	if metadata != nil {
+		m = metadata.Clone()
+	}
+	if len(m.Headers()[Model.ContentEncoding]) > 0 {
+		return nil, nil, Model.NewFDSError("content-encoding can not be set on encrypted objects", -1)
+	}
+	m.SetUserMetadata(META_ALGORITHM, ALGORITHM).
+		SetUserMetadata(META_WRAPPED_KEY, base64.StdEncoding.EncodeToString(wrapped)).
+		SetUserMetadata(META_KEY_ID, keyId).
+		SetUserMetadata(META_NONCE, base64.StdEncoding.EncodeToString(nonce)).
+		SetUserMetadata(META_CHUNK_SIZE, strconv.Itoa(c.opts.ChunkSize))
+	return &encryptReader{
+		src:       r,
+		cipher:    &chunkCipher{aead: aead, nonce: nonce},
+		chunkSize: c.opts.ChunkSize,
+	}, m, nil
+}
+
+// Put_Object 加密data后上传，metadata可以为nil
+func (c *Client) Put_Object(bucketname, objectname string, data []byte,
+	metadata *Model.ObjectMetadata) (*Model.PutObjectResult, error) {
+	r, m, err := c.newEnvelope(bytes.NewReader(data), metadata)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return c.client.Put_Object_With_Metadata(bucketname, objectname, encrypted, m)
+}
+
+// Put_Reader 边读边加密上传r中的内容，加密后超过MULTIPART_UPLOAD_THRESHOLD时使用分片上传。
+// size为r的长度，小于0表示未知
+func (c *Client) Put_Reader(bucketname, objectname string, r io.Reader, size int64,
+	metadata *Model.ObjectMetadata) error {
+	encrypted, m, err := c.newEnvelope(r, metadata)
+	if err != nil {
+		return err
+	}
+	if size >= 0 {
+		size = EncryptedSize(size, c.opts.ChunkSize)
+	}
+	return c.client.Put_Reader_With_Metadata(bucketname, objectname, encrypted, size, m)
+}
+
+// envelope 一个加密object的解密参数
+type envelope struct {
+	cipher    *chunkCipher
+	chunkSize int
+	plainSize int64
+	encSize   int64
+	chunks    int64
+}
+
+func (c *Client) openEnvelope(meta *Model.FDSMetaData) (*envelope, error) {
+	get := func(k string) string {
+		v, _ := meta.GetKey(k)
+		return v
+	}
+	if alg := get(META_ALGORITHM); alg != ALGORITHM {
+		if len(alg) == 0 {
+			return nil, Model.NewFDSError("object is not encrypted", -1)
+		}
+		return nil, Model.NewFDSError("unsupported encryption algorithm: "+alg, -1)
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(get(META_WRAPPED_KEY))
+	if err != nil {
+		return nil, Model.NewFDSError("invalid "+META_WRAPPED_KEY, -1)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(get(META_NONCE))
+	if err != nil || len(nonce) != nonceSize {
+		return nil, Model.NewFDSError("invalid "+META_NONCE, -1)
+	}
+	chunkSize, err := strconv.Atoi(get(META_CHUNK_SIZE))
+	if err != nil || chunkSize <= 0 || chunkSize > MAX_CHUNK_SIZE {
+		return nil, Model.NewFDSError("invalid "+META_CHUNK_SIZE, -1)
+	}
+	encSize, err := meta.GetMetadataContentLength()
+	if err != nil {
+		return nil, err
+	}
+	plain, chunks, err := plainSize(encSize, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := c.opts.KeyProvider.UnwrapKey(wrapped, get(META_KEY_ID))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope{
+		cipher:    &chunkCipher{aead: aead, nonce: nonce},
+		chunkSize: chunkSize,
+		plainSize: plain,
+		encSize:   encSize,
+		chunks:    chunks,
+	}, nil
+}
+
+// decryptReader 按块读取并解密，丢弃第一块中position之前的内容，最多返回remaining字节
+type decryptReader struct {
+	src       io.ReadCloser
+	env       *envelope
+	index     int64
+	skip      int64
+	remaining int64
+	buf       []byte
+	out       []byte
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.remaining <= 0 {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.src, d.buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, Model.NewFDSError(err.Error(), -1)
+		}
+		plain, err := d.env.cipher.open(d.index, d.index == d.env.chunks-1, d.buf[:n])
+		if err != nil {
+			return 0, err
+		}
+		d.index++
+		plain = plain[d.skip:]
+		d.skip = 0
+		if int64(len(plain)) > d.remaining {
+			plain = plain[:d.remaining]
+		}
+		d.remaining -= int64(len(plain))
+		d.out = plain
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decryptReader) Close() error {
+	return d.src.Close()
+}
+
+// sameVersion 比较两次响应的content-md5和last-modified，判断是否为object的同一个版本
+func sameVersion(a, b *Model.FDSMetaData) bool {
+	md5a, _ := a.GetContentMD5()
+	md5b, _ := b.GetContentMD5()
+	modifiedA, _ := a.GetLastModified()
+	modifiedB, _ := b.GetLastModified()
+	return md5a == md5b && modifiedA == modifiedB
+}
+
+// Get_Object_Reader 读取并解密从position开始的size字节，size小于0时读取到结尾。
+// 只下载覆盖该范围的加密块，每块在返回前都会校验；获取metadata之后object被覆盖时返回状态码为409的错误，可以重试
+func (c *Client) Get_Object_Reader(bucketname, objectname string, position, size int64) (io.ReadCloser, error) {
+	meta, err := c.client.Get_Object_Meta(bucketname, objectname)
+	if err != nil {
+		return nil, err
+	}
+	env, err := c.openEnvelope(meta)
+	if err != nil {
+		return nil, err
+	}
+	if position < 0 || position > env.plainSize {
+		return nil, Model.NewFDSError("invalid position: "+strconv.FormatInt(position, 10), -1)
+	}
+	end := env.plainSize
+	if size >= 0 && position+size < end {
+		end = position + size
+	}
+	chunk := int64(env.chunkSize)
+	first := position / chunk
+	if end == position {
+		if env.plainSize > 0 {
+			return ioutil.NopCloser(bytes.NewReader(nil)), nil
+		}
+		// 空object只有一个认证标签，仍然读取并校验
+		end, first = 0, 0
+	}
+	last := first
+	if end > position {
+		last = (end - 1) / chunk
+	}
+	encChunk := chunk + tagSize
+	encStart := first * encChunk
+	encEnd := (last + 1) * encChunk
+	if encEnd > env.encSize {
+		encEnd = env.encSize
+	}
+	reader, encMeta, err := c.client.Get_Object_Reader_With_Metadata(bucketname, objectname, encStart, encEnd-encStart)
+	if err != nil {
+		return nil, err
+	}
+	// 获取metadata之后object可能被覆盖，新内容不能用之前的密钥和长度解密
+	if !sameVersion(meta, encMeta) {
+		reader.Close()
+		return nil, Model.NewFDSError("object was modified while reading, retry", http.StatusConflict)
+	}
+	d := &decryptReader{
+		src:       reader,
+		env:       env,
+		index:     first,
+		skip:      position - first*chunk,
+		remaining: end - position,
+		buf:       make([]byte, encChunk),
+	}
+	if env.plainSize == 0 {
+		if _, err := io.ReadFull(d.src, d.buf[:tagSize]); err != nil {
+			d.Close()
+			return nil, Model.NewFDSError(err.Error(), -1)
+		}
+		if _, err := env.cipher.open(0, true, d.buf[:tagSize]); err != nil {
+			d.Close()
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Get_Object 读取并解密从position开始的size字节，size小于0时读取到结尾
+func (c *Client) Get_Object(bucketname, objectname string, position, size int64) (*Model.FDSObject, error) {
+	reader, err := c.Get_Object_Reader(bucketname, objectname, position, size)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return &Model.FDSObject{
+		BucketName:    bucketname,
+		ObjectName:    objectname,
+		ObjectContent: data,
+	}, nil
+}
+
+// Get_Object_Size 返回加密object的原始长度
+func (c *Client) Get_Object_Size(bucketname, objectname string) (int64, error) {
+	meta, err := c.client.Get_Object_Meta(bucketname, objectname)
+	if err != nil {
+		return 0, err
+	}
+	env, err := c.openEnvelope(meta)
+	if err != nil {
+		return 0, err
+	}
+	return env.plainSize, nil
+}
@@ -0,0 +1,110 @@
+package fdscrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// KeyProvider 使用密钥加密密钥(KEK)加密和解密每个object的数据密钥
+type KeyProvider interface {
+	// WrapKey 加密数据密钥，返回密文以及解密时需要的KEK id
+	WrapKey(dataKey []byte) (wrapped []byte, keyId string, err error)
+	// UnwrapKey 使用keyId对应的KEK解密数据密钥
+	UnwrapKey(wrapped []byte, keyId string) ([]byte, error)
+}
+
+// LocalKeyProvider 使用本地的AES-256密钥作为KEK，keyId为密钥sha256的前16个16进制字符
+type LocalKeyProvider struct {
+	keyId string
+	aead  cipher.AEAD
+}
+
+func NewLocalKeyProvider(key []byte) (*LocalKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, Model.NewFDSError("local key must be 32 bytes", -1)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &LocalKeyProvider{keyId: hex.EncodeToString(sum[:])[:16], aead: aead}, nil
+}
+
+// NewLocalKeyProviderFromFile 从文件中读取KEK，文件内容为32字节的原始密钥或其base64编码
+func NewLocalKeyProviderFromFile(filename string) (*LocalKeyProvider, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if len(data) != 32 {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil {
+			return nil, Model.NewFDSError("key file must contain 32 raw bytes or base64", -1)
+		}
+		data = decoded
+	}
+	return NewLocalKeyProvider(data)
+}
+
+func (p *LocalKeyProvider) KeyId() string {
+	return p.keyId
+}
+
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) ([]byte, string, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, "", Model.NewFDSError(err.Error(), -1)
+	}
+	return p.aead.Seal(nonce, nonce, dataKey, []byte(p.keyId)), p.keyId, nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(wrapped []byte, keyId string) ([]byte, error) {
+	if keyId != p.keyId {
+		return nil, Model.NewFDSError("object was encrypted with another key: "+keyId, -1)
+	}
+	n := p.aead.NonceSize()
+	if len(wrapped) < n {
+		return nil, Model.NewFDSError("invalid wrapped key", -1)
+	}
+	dataKey, err := p.aead.Open(nil, wrapped[:n], wrapped[n:], []byte(p.keyId))
+	if err != nil {
+		return nil, Model.NewFDSError("failed to unwrap data key: "+err.Error(), -1)
+	}
+	return dataKey, nil
+}
+
+// CallbackKeyProvider 通过回调加密和解密数据密钥，用于对接KMS等外部服务
+type CallbackKeyProvider struct {
+	Wrap   func(dataKey []byte) ([]byte, string, error)
+	Unwrap func(wrapped []byte, keyId string) ([]byte, error)
+}
+
+func (p *CallbackKeyProvider) WrapKey(dataKey []byte) ([]byte, string, error) {
+	return p.Wrap(dataKey)
+}
+
+func (p *CallbackKeyProvider) UnwrapKey(wrapped []byte, keyId string) ([]byte, error) {
+	return p.Unwrap(wrapped, keyId)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return aead, nil
+}
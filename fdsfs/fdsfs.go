@@ -0,0 +1,343 @@
+// Package fdsfs 将bucket中的object以io/fs.FS的形式提供，object名字按/划分目录，
+// 可以直接用于template.ParseFS、http.FS和fs.WalkDir
+package fdsfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// FS 实现fs.FS、fs.ReadDirFS、fs.StatFS和fs.ReadFileFS
+type FS struct {
+	client *galaxy_fds_sdk_golang.FDSClient
+	bucket string
+	prefix string
+}
+
+var (
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+)
+
+// New 返回以bucket中prefix为根目录的FS，prefix非空时自动补上/
+func New(client *galaxy_fds_sdk_golang.FDSClient, bucket, prefix string) *FS {
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, galaxy_fds_sdk_golang.DELIMITER) {
+		prefix += galaxy_fds_sdk_golang.DELIMITER
+	}
+	return &FS{client: client, bucket: bucket, prefix: prefix}
+}
+
+func (f *FS) key(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name
+}
+
+func isNotFound(err error) bool {
+	var e *Model.FDSError
+	return errors.As(err, &e) && e.Code() == http.StatusNotFound
+}
+
+func pathError(op, name string, err error) error {
+	if isNotFound(err) {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// stat 先按object查找，不存在时按目录查找
+func (f *FS) stat(op, name string) (*fileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &fileInfo{name: ".", dir: true}, nil
+	}
+	meta, err := f.client.Get_Object_Meta(f.bucket, f.key(name))
+	if err == nil {
+		return newFileInfo(path.Base(name), meta), nil
+	}
+	if !isNotFound(err) {
+		return nil, pathError(op, name, err)
+	}
+	listing, err := f.client.List_Object(f.bucket, f.key(name)+galaxy_fds_sdk_golang.DELIMITER,
+		galaxy_fds_sdk_golang.DELIMITER, 1)
+	if err != nil {
+		return nil, pathError(op, name, err)
+	}
+	if len(listing.ObjectSummaries) == 0 && len(listing.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return &fileInfo{name: path.Base(name), dir: true}, nil
+}
+
+func (f *FS) Open(name string) (fs.File, error) {
+	fi, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if fi.dir {
+		return &dir{fs: f, name: name, info: fi}, nil
+	}
+	return &file{fs: f, name: name, info: fi}, nil
+}
+
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	fi, err := f.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fi, nil
+}
+
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	// 不受FDSClient.AutoDecompress影响，读到的总是服务端保存的内容
+	reader, _, err := f.client.Get_Object_Reader_With_Metadata(f.bucket, f.key(name), 0, -1)
+	if err != nil {
+		return nil, pathError("readfile", name, err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, pathError("readfile", name, err)
+	}
+	return data, nil
+}
+
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	fi, err := f.stat("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	d := &dir{fs: f, name: name, info: fi}
+	return d.ReadDir(-1)
+}
+
+// fileInfo 同时实现fs.FileInfo和fs.DirEntry
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func newFileInfo(name string, meta *Model.FDSMetaData) *fileInfo {
+	fi := &fileInfo{name: name}
+	if size, err := meta.GetMetadataContentLength(); err == nil {
+		fi.size = size
+	} else if size, err := meta.GetContentLength(); err == nil {
+		fi.size = size
+	}
+	if s, err := meta.GetLastModified(); err == nil {
+		if t, err := http.ParseTime(s); err == nil {
+			fi.modTime = t
+		}
+	}
+	return fi
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+func (fi *fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (fi *fileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi *fileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+// file 按需使用range请求读取object，支持Seek和ReadAt
+type file struct {
+	fs     *FS
+	name   string
+	info   *fileInfo
+	offset int64
+	reader io.ReadCloser
+	closed bool
+}
+
+var (
+	_ io.Seeker   = (*file)(nil)
+	_ io.ReaderAt = (*file)(nil)
+)
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+	if f.reader == nil {
+		reader, _, err := f.fs.client.Get_Object_Reader_With_Metadata(f.fs.bucket, f.fs.key(f.name), f.offset, -1)
+		if err != nil {
+			return 0, pathError("read", f.name, err)
+		}
+		f.reader = reader
+	}
+	n, err := f.reader.Read(p)
+	f.offset += int64(n)
+	if err == io.EOF && f.offset < f.info.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset && f.reader != nil {
+		f.reader.Close()
+		f.reader = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	size := int64(len(p))
+	if off+size > f.info.size {
+		size = f.info.size - off
+	}
+	object, err := f.fs.client.Get_Object(f.fs.bucket, f.fs.key(f.name), off, size)
+	if err != nil {
+		return 0, pathError("read", f.name, err)
+	}
+	n := copy(p, object.ObjectContent)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *file) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	if f.reader != nil {
+		return f.reader.Close()
+	}
+	return nil
+}
+
+// dir 第一次ReadDir时列出目录下的所有object和子目录
+type dir struct {
+	fs      *FS
+	name    string
+	info    *fileInfo
+	entries []fs.DirEntry
+	loaded  bool
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error { return nil }
+
+func (d *dir) load() error {
+	prefix := d.fs.key(d.name)
+	if d.name != "." {
+		prefix += galaxy_fds_sdk_golang.DELIMITER
+	}
+	listing, err := d.fs.client.List_Object(d.fs.bucket, prefix, galaxy_fds_sdk_golang.DELIMITER,
+		galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS)
+	for err == nil {
+		for _, p := range listing.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(p, prefix), galaxy_fds_sdk_golang.DELIMITER)
+			if len(name) > 0 {
+				d.entries = append(d.entries, &fileInfo{name: name, dir: true})
+			}
+		}
+		for _, o := range listing.ObjectSummaries {
+			name := strings.TrimPrefix(o.ObjectName, prefix)
+			// 跳过目录占位object
+			if len(name) == 0 {
+				continue
+			}
+			d.entries = append(d.entries, &fileInfo{name: name, size: o.Size, modTime: o.LastModified})
+		}
+		if !listing.Truncated {
+			break
+		}
+		listing, err = d.fs.client.List_Next_Batch_Of_Objects(listing)
+	}
+	if err != nil {
+		return pathError("readdir", d.name, err)
+	}
+	sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+	d.loaded = true
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		if err := d.load(); err != nil {
+			return nil, err
+		}
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,308 @@
+// Package fdshttp 提供将请求路径映射到bucket中object的http.Handler，
+// 支持Range/206、ETag和Last-Modified条件请求(304)，内容直接从Get_Object_Reader流式返回
+package fdshttp
+
+import (
+	"errors"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	galaxy_fds_sdk_golang "github.com/qkzsky/galaxy-fds-sdk-golang"
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const DEFAULT_REDIRECT_EXPIRES = 10 * time.Minute
+
+// Handler 将请求路径映射为Prefix下的object
+type Handler struct {
+	Client *galaxy_fds_sdk_golang.FDSClient
+	Bucket string
+	Prefix string
+
+	// IndexFile 非空时，请求目录会返回目录下的该文件，例如index.html
+	IndexFile string
+	// IndexListing 为true时，请求目录且没有IndexFile时返回目录列表
+	IndexListing bool
+	// RedirectThreshold 大于0时，超过该大小的object会302跳转到有效期为RedirectExpires的预签名url
+	RedirectThreshold int64
+	RedirectExpires   time.Duration
+	// CacheControl object没有设置cache-control时使用的默认值
+	CacheControl string
+	// Authorize 返回false时拒绝访问(403)，key为object名字或者以/结尾的目录前缀
+	Authorize func(r *http.Request, key string) bool
+}
+
+// New 返回以bucket中prefix为根目录的Handler，prefix非空时自动补上/
+func New(client *galaxy_fds_sdk_golang.FDSClient, bucket, prefix string) *Handler {
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, galaxy_fds_sdk_golang.DELIMITER) {
+		prefix += galaxy_fds_sdk_golang.DELIMITER
+	}
+	return &Handler{Client: client, Bucket: bucket, Prefix: prefix}
+}
+
+func fdsStatus(err error) int {
+	var e *Model.FDSError
+	if errors.As(err, &e) && e.Code() == http.StatusNotFound {
+		return http.StatusNotFound
+	}
+	if errors.As(err, &e) && e.Code() == http.StatusForbidden {
+		return http.StatusForbidden
+	}
+	return http.StatusBadGateway
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	isDir := name == "" || strings.HasSuffix(r.URL.Path, "/")
+	if isDir {
+		dirKey := h.Prefix + name
+		if len(name) > 0 {
+			dirKey += "/"
+		}
+		h.serveDir(w, r, dirKey)
+		return
+	}
+	h.serveObject(w, r, h.Prefix+name, true)
+}
+
+func (h *Handler) authorized(r *http.Request, key string) bool {
+	return h.Authorize == nil || h.Authorize(r, key)
+}
+
+func (h *Handler) serveDir(w http.ResponseWriter, r *http.Request, dirKey string) {
+	if !h.authorized(r, dirKey) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if len(h.IndexFile) > 0 && h.serveObject(w, r, dirKey+h.IndexFile, false) {
+		return
+	}
+	if !h.IndexListing {
+		http.NotFound(w, r)
+		return
+	}
+	h.serveListing(w, r, dirKey)
+}
+
+// serveObject 返回object内容，notFound为false时object不存在返回false且不写入任何内容
+func (h *Handler) serveObject(w http.ResponseWriter, r *http.Request, key string, notFound bool) bool {
+	if !h.authorized(r, key) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return true
+	}
+	// GET直接打开object，大小和ETag、Last-Modified等都取自这次响应，与返回的内容一致
+	var meta *Model.FDSMetaData
+	var body io.ReadCloser
+	var err error
+	if r.Method == http.MethodHead {
+		meta, err = h.Client.Get_Object_Meta(h.Bucket, key)
+	} else {
+		body, meta, err = h.Client.Get_Object_Reader_With_Metadata(h.Bucket, key, 0, -1)
+	}
+	if err != nil {
+		status := fdsStatus(err)
+		if status == http.StatusNotFound && !notFound {
+			return false
+		}
+		if status == http.StatusNotFound && h.IndexListing && h.isDir(key+"/") {
+			http.Redirect(w, r, path.Base(key)+"/", http.StatusMovedPermanently)
+			return true
+		}
+		http.Error(w, http.StatusText(status), status)
+		return true
+	}
+
+	size, err := meta.GetMetadataContentLength()
+	if err != nil {
+		size, _ = meta.GetContentLength()
+	}
+	content := &objectReader{client: h.Client, bucket: h.Bucket, key: key, size: size, body: body}
+	defer content.Close()
+	if h.RedirectThreshold > 0 && size > h.RedirectThreshold {
+		h.redirect(w, r, key)
+		return true
+	}
+
+	header := w.Header()
+	contentType, _ := meta.GetContentType()
+	if len(contentType) == 0 {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	header.Set("Content-Type", contentType)
+	if md5sum, err := meta.GetContentMD5(); err == nil && len(md5sum) > 0 {
+		header.Set("ETag", `"`+md5sum+`"`)
+		content.md5sum = md5sum
+	}
+	if encoding, err := meta.GetContentEncoding(); err == nil && len(encoding) > 0 {
+		header.Set("Content-Encoding", encoding)
+	}
+	if cacheControl, err := meta.GetCacheControl(); err == nil && len(cacheControl) > 0 {
+		header.Set("Cache-Control", cacheControl)
+	} else if len(h.CacheControl) > 0 {
+		header.Set("Cache-Control", h.CacheControl)
+	}
+	var modTime time.Time
+	if s, err := meta.GetLastModified(); err == nil {
+		modTime, _ = http.ParseTime(s)
+		content.lastModified = s
+	}
+
+	http.ServeContent(w, r, path.Base(key), modTime, content)
+	return true
+}
+
+func (h *Handler) redirect(w http.ResponseWriter, r *http.Request, key string) {
+	expires := h.RedirectExpires
+	if expires <= 0 {
+		expires = DEFAULT_REDIRECT_EXPIRES
+	}
+	expiration := time.Now().Add(expires).UnixNano() / int64(time.Millisecond)
+	u, err := h.Client.Generate_Presigned_URI(h.Bucket, key, http.MethodGet, expiration, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+func (h *Handler) isDir(dirKey string) bool {
+	listing, err := h.Client.List_Object(h.Bucket, dirKey, galaxy_fds_sdk_golang.DELIMITER, 1)
+	return err == nil && (len(listing.ObjectSummaries) > 0 || len(listing.CommonPrefixes) > 0)
+}
+
+type listingEntry struct {
+	Name         string
+	Size         int64
+	LastModified time.Time
+	Dir          bool
+}
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Index of /{{.Path}}</title></head>
+<body><h1>Index of /{{.Path}}</h1><table>
+{{if .Path}}<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{end}}{{range .Entries}}<tr><td><a href="{{.Name}}{{if .Dir}}/{{end}}">{{.Name}}{{if .Dir}}/{{end}}</a></td>
+<td>{{if not .Dir}}{{.Size}}{{end}}</td><td>{{if not .Dir}}{{.LastModified.Format "2006-01-02 15:04:05"}}{{end}}</td></tr>
+{{end}}</table></body></html>
+`))
+
+func (h *Handler) serveListing(w http.ResponseWriter, r *http.Request, dirKey string) {
+	entries := []listingEntry{}
+	listing, err := h.Client.List_Object(h.Bucket, dirKey, galaxy_fds_sdk_golang.DELIMITER,
+		galaxy_fds_sdk_golang.DEFAULT_LIST_MAX_KEYS)
+	for err == nil {
+		for _, p := range listing.CommonPrefixes {
+			entries = append(entries, listingEntry{Name: strings.TrimSuffix(strings.TrimPrefix(p, dirKey), "/"), Dir: true})
+		}
+		for _, o := range listing.ObjectSummaries {
+			if name := strings.TrimPrefix(o.ObjectName, dirKey); len(name) > 0 {
+				entries = append(entries, listingEntry{Name: name, Size: o.Size, LastModified: o.LastModified})
+			}
+		}
+		if !listing.Truncated {
+			break
+		}
+		listing, err = h.Client.List_Next_Batch_Of_Objects(listing)
+	}
+	if err != nil {
+		status := fdsStatus(err)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if len(entries) == 0 && dirKey != h.Prefix {
+		http.NotFound(w, r)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+	listingTemplate.Execute(w, map[string]interface{}{
+		"Path":    strings.TrimPrefix(dirKey, h.Prefix),
+		"Entries": entries,
+	})
+}
+
+// objectReader 实现io.ReadSeeker，body从0开始读取，Seek到其它位置后Read时再从当前位置发起range请求，
+// 新请求的content-md5和last-modified与第一次响应不同时说明object已被覆盖，返回错误而不是拼接两个版本的内容
+type objectReader struct {
+	client       *galaxy_fds_sdk_golang.FDSClient
+	bucket       string
+	key          string
+	size         int64
+	md5sum       string
+	lastModified string
+	offset       int64
+	// body当前读到的位置
+	position int64
+	body     io.ReadCloser
+}
+
+func (o *objectReader) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body != nil && o.position != o.offset {
+		o.Close()
+	}
+	if o.body == nil {
+		body, meta, err := o.client.Get_Object_Reader_With_Metadata(o.bucket, o.key, o.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		if md5sum, _ := meta.GetContentMD5(); md5sum != o.md5sum {
+			body.Close()
+			return 0, errObjectChanged
+		}
+		if lastModified, _ := meta.GetLastModified(); lastModified != o.lastModified {
+			body.Close()
+			return 0, errObjectChanged
+		}
+		o.body = body
+		o.position = o.offset
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	o.position = o.offset
+	return n, err
+}
+
+var errObjectChanged = errors.New("fdshttp: object was modified while being served")
+
+func (o *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("fdshttp: negative position")
+	}
+	o.offset = offset
+	return offset, nil
+}
+
+func (o *objectReader) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
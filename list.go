@@ -0,0 +1,27 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// walkObjects 分页列出prefix下的所有object，对每个object调用fn，fn返回错误时停止
+func (c *FDSClient) walkObjects(bucketname, prefix string, fn func(summary Model.FDSObjectSummary) error) error {
+	listObjectResult, err := c.List_Object(bucketname, prefix, "", DEFAULT_LIST_MAX_KEYS)
+	if err != nil {
+		return err
+	}
+	for {
+		for _, summary := range listObjectResult.ObjectSummaries {
+			if err := fn(summary); err != nil {
+				return err
+			}
+		}
+		if !listObjectResult.Truncated {
+			return nil
+		}
+		listObjectResult, err = c.List_Next_Batch_Of_Objects(listObjectResult)
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const REDACTED = "[REDACTED]"
+
+// LogValue 实现slog.LogValuer，保证直接记录FDSClient时不会输出AppSecret
+func (c *FDSClient) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("app_key", c.AppKey),
+		slog.String("app_secret", REDACTED),
+		slog.String("region", c.RegionName),
+		slog.String("endpoint", c.EndPoint),
+		slog.Bool("https", c.EnableHttps),
+		slog.Bool("cdn", c.EnableCDN),
+	)
+}
+
+// redact 去掉字符串中出现的AppSecret
+func (c *FDSClient) redact(s string) string {
+	if len(c.AppSecret) == 0 {
+		return s
+	}
+	return strings.Replace(s, c.AppSecret, REDACTED, -1)
+}
+
+// redactUrl 去掉url中Signature参数的值
+func redactUrl(u string) string {
+	urlParsed, err := url.Parse(u)
+	if err != nil {
+		return REDACTED
+	}
+	params := urlParsed.Query()
+	if _, ok := params[SIGNATURE]; !ok {
+		return u
+	}
+	params.Set(SIGNATURE, REDACTED)
+	urlParsed.RawQuery = params.Encode()
+	return urlParsed.String()
+}
+
+// redactStringToSign 去掉待签名字符串中SSE客户密钥header的值以及AppSecret
+func (c *FDSClient) redactStringToSign(stringToSign []byte) string {
+	lines := strings.Split(string(stringToSign), "\n")
+	for i, line := range lines {
+		for _, k := range []string{Model.SSECustomerKey, Model.CopySourceSSECustomerKey} {
+			if strings.HasPrefix(line, k+":") {
+				lines[i] = k + ":" + REDACTED
+			}
+		}
+	}
+	return c.redact(strings.Join(lines, "\n"))
+}
+
+// redactHeaders 返回去掉authorization、SSE客户密钥以及AppSecret等敏感信息后的header
+func (c *FDSClient) redactHeaders(headers http.Header) map[string]string {
+	r := map[string]string{}
+	for k, v := range headers {
+		if strings.EqualFold(k, "authorization") || strings.EqualFold(k, Model.SSECustomerKey) ||
+			strings.EqualFold(k, Model.CopySourceSSECustomerKey) {
+			r[strings.ToLower(k)] = REDACTED
+			continue
+		}
+		r[strings.ToLower(k)] = c.redact(strings.Join(v, ","))
+	}
+	return r
+}
+
+// logRequest 在Logger为nil但设置了SignatureDebug时，只把签名不一致的403输出到slog.Default()
+func (c *FDSClient) logRequest(req *http.Request, stringToSign []byte, res *http.Response,
+	err error, duration time.Duration) {
+	logger := c.Logger
+	if logger == nil {
+		if !c.SignatureDebug || err != nil || res.StatusCode != http.StatusForbidden {
+			return
+		}
+		logger = slog.Default()
+	}
+	ctx := context.Background()
+	resource, _ := canonicalizeResource(req.URL.String())
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", c.redact(redactUrl(req.URL.String()))),
+		slog.String("resource", c.redact(string(resource))),
+		slog.Duration("duration", duration),
+	}
+	debug := logger.Enabled(ctx, slog.LevelDebug)
+	if debug {
+		attrs = append(attrs,
+			slog.String("string_to_sign", c.redactStringToSign(stringToSign)),
+			slog.Any("headers", c.redactHeaders(req.Header)))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", c.redact(err.Error())))
+		logger.LogAttrs(ctx, slog.LevelError, "fds request failed", attrs...)
+		return
+	}
+
+	attrs = append(attrs, slog.Int("status", res.StatusCode))
+	if c.SignatureDebug && res.StatusCode == http.StatusForbidden {
+		// 读出服务端的错误信息后重新放回body，调用方仍然可以正常读取
+		body, readErr := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if readErr == nil {
+			if !debug {
+				attrs = append(attrs, slog.String("string_to_sign", c.redactStringToSign(stringToSign)))
+			}
+			attrs = append(attrs, slog.String("server_error", c.redact(string(body))))
+			logger.LogAttrs(ctx, slog.LevelWarn, "fds signature mismatch", attrs...)
+			return
+		}
+	}
+	if c.Logger == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	if res.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	c.Logger.LogAttrs(ctx, level, "fds request", attrs...)
+}
+
+func (c *FDSClient) logRetry(method, urlStr string, statusCode, attempt int, delay time.Duration) {
+	if c.Logger == nil {
+		return
+	}
+	c.Logger.Warn("fds request throttled, retrying",
+		"method", method,
+		"url", c.redact(redactUrl(urlStr)),
+		"status", statusCode,
+		"attempt", attempt,
+		"delay", delay)
+}
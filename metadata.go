@@ -0,0 +1,60 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"io"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// metadataHeaders 检查metadata并返回content-type和其它header，metadata为nil时都为空
+func metadataHeaders(metadata *Model.ObjectMetadata) (string, map[string]string, error) {
+	if metadata == nil {
+		return "", nil, nil
+	}
+	if err := metadata.Validate(); err != nil {
+		return "", nil, err
+	}
+	return metadata.GetContentType(), metadata.Headers(), nil
+}
+
+// Put_Object_With_Metadata 与Put_Object相同，content-type和其它header从metadata中获取
+func (c *FDSClient) Put_Object_With_Metadata(bucketname, objectname string, data []byte,
+	metadata *Model.ObjectMetadata) (*Model.PutObjectResult, error) {
+	contentType, headers, err := metadataHeaders(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return c.Put_Object(bucketname, objectname, data, contentType, &headers)
+}
+
+// Put_Reader_With_Metadata 从r中读取内容上传，超过MULTIPART_UPLOAD_THRESHOLD时使用分片上传，失败时abort。
+// size为r的长度，小于0表示未知，读取到EOF为止
+func (c *FDSClient) Put_Reader_With_Metadata(bucketname, objectname string, r io.Reader, size int64,
+	metadata *Model.ObjectMetadata) error {
+	contentType, headers, err := metadataHeaders(metadata)
+	if err != nil {
+		return err
+	}
+	return c.putReader(bucketname, objectname, r, size, contentType, headers)
+}
+
+// Init_MultiPart_Upload_With_Metadata 与Init_MultiPart_Upload相同，完成上传后的object带有metadata
+func (c *FDSClient) Init_MultiPart_Upload_With_Metadata(bucketname, objectname string,
+	metadata *Model.ObjectMetadata) (*Model.InitMultipartUploadResult, error) {
+	contentType, headers, err := metadataHeaders(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return c.initMultipartUpload(bucketname, objectname, contentType, headers)
+}
+
+// Set_Object_Metadata 检查metadata后调用SetObjectMetadata修改object的metadata，不需要重新上传object
+func (c *FDSClient) Set_Object_Metadata(bucketname, objectname string, metadata *Model.ObjectMetadata) (bool, error) {
+	if metadata == nil {
+		return false, Model.NewFDSError("empty metadata", -1)
+	}
+	if err := metadata.Validate(); err != nil {
+		return false, err
+	}
+	return c.SetObjectMetadata(bucketname, objectname, *metadata.ToFDSMetaData())
+}
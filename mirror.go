@@ -0,0 +1,324 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	MIRROR_ACTION_COPY   = "copy"
+	MIRROR_ACTION_DELETE = "delete"
+
+	MIRROR_METHOD_SERVER_SIDE = "server-side"
+	MIRROR_METHOD_STREAM      = "stream"
+
+	DEFAULT_MIRROR_CONCURRENCY = 4
+)
+
+// MirrorOptions Mirror的参数
+type MirrorOptions struct {
+	Prefix      string // 只同步该前缀下的object
+	Delete      bool   // 删除源bucket中已经不存在的object
+	PreserveACL bool   // 复制object的ACL
+	// DisableServerSideCopy 为true时即使在同一个region也通过下载再上传的方式复制
+	DisableServerSideCopy bool
+	Concurrency           int
+	DryRun                bool
+	// Checkpoint 记录已完成复制的文件路径，中断后使用同一个文件重新执行会跳过已经复制过且源object未变化的key
+	Checkpoint string
+}
+
+// MirrorAction 一项复制或删除操作，Method为实际使用的复制方式
+type MirrorAction struct {
+	Action string
+	Key    string
+	Size   int64
+	Reason string
+	Method string
+	Err    error `json:"-"`
+}
+
+type MirrorResult struct {
+	Actions []MirrorAction
+	Skipped int
+	Copied  int
+	Deleted int
+	Bytes   int64
+	Failed  []MirrorAction
+}
+
+// Summary 返回一行可读的统计信息
+func (r *MirrorResult) Summary() string {
+	return fmt.Sprintf("planned %d, copied %d (%d bytes), deleted %d, skipped %d, failed %d",
+		len(r.Actions), r.Copied, r.Bytes, r.Deleted, r.Skipped, len(r.Failed))
+}
+
+type mirrorCheckpoint struct {
+	mu   sync.Mutex
+	done map[string]string
+	file *os.File
+}
+
+func openMirrorCheckpoint(path string) (*mirrorCheckpoint, error) {
+	cp := &mirrorCheckpoint{done: map[string]string{}}
+	if len(path) == 0 {
+		return cp, nil
+	}
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.SplitN(scanner.Text(), "\t", 2)
+			if len(fields) == 2 {
+				cp.done[fields[1]] = fields[0]
+			}
+		}
+		f.Close()
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	cp.file = f
+	return cp, nil
+}
+
+func (cp *mirrorCheckpoint) add(key, etag string) {
+	if cp.file == nil {
+		return
+	}
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	fmt.Fprintf(cp.file, "%s\t%s\n", etag, key)
+}
+
+func (cp *mirrorCheckpoint) close() {
+	if cp.file != nil {
+		cp.file.Close()
+	}
+}
+
+func listObjectSummaries(c *FDSClient, bucketname, prefix string) (map[string]Model.FDSObjectSummary, error) {
+	objects := map[string]Model.FDSObjectSummary{}
+	err := c.walkObjects(bucketname, prefix, func(summary Model.FDSObjectSummary) error {
+		objects[summary.ObjectName] = summary
+		return nil
+	})
+	return objects, err
+}
+
+// mirrorUnchanged 大小相同时判断object是否没有变化。分片上传或流式复制得到的etag不是md5，
+// 无法直接比较，此时以checkpoint中记录的源etag为准；没有记录时与Sync一样比较metadata中的content-md5，
+// 任一端取不到md5时认为有变化
+func mirrorUnchanged(src *FDSClient, srcBucket string, dst *FDSClient, dstBucket string,
+	cp *mirrorCheckpoint, key, srcEtag, dstEtag string) (bool, error) {
+	if srcEtag == dstEtag {
+		return true, nil
+	}
+	if e, found := cp.done[key]; found {
+		return e == srcEtag, nil
+	}
+	srcMD5, err := mirrorContentMD5(src, srcBucket, key, srcEtag)
+	if err != nil {
+		return false, err
+	}
+	dstMD5, err := mirrorContentMD5(dst, dstBucket, key, dstEtag)
+	if err != nil {
+		return false, err
+	}
+	return len(srcMD5) > 0 && strings.EqualFold(srcMD5, dstMD5), nil
+}
+
+// mirrorContentMD5 etag不是md5时从metadata中获取content-md5
+func mirrorContentMD5(c *FDSClient, bucketname, key, etag string) (string, error) {
+	if isMD5Hex(etag) {
+		return etag, nil
+	}
+	meta, err := c.Get_Object_Meta(bucketname, key)
+	if err != nil {
+		return "", err
+	}
+	md5, _ := meta.GetContentMD5()
+	return md5, nil
+}
+
+// sameRegion 判断两个client是否访问同一个FDS集群，可以使用服务端复制
+func sameRegion(a, b *FDSClient) bool {
+	return a.RegionName == b.RegionName && a.EndPoint == b.EndPoint
+}
+
+// Mirror 将src中srcBucket的object同步到dst中的dstBucket，两个client可以属于不同的region和用户。
+// 按key、大小和etag比较，只复制缺失或变化的object并保留metadata，可选保留ACL、删除源端已删除的object。
+// 同一个region时使用服务端复制(Copy_Object)，跨region、服务端不支持复制或没有读取源object的权限(跨用户，403)时下载后再上传，
+// 其它复制错误直接记录为失败。
+// 设置Checkpoint后中断可以重新执行，已经复制过的object不会重复复制
+func Mirror(src *FDSClient, srcBucket string, dst *FDSClient, dstBucket string,
+	opts *MirrorOptions) (*MirrorResult, error) {
+	o := MirrorOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DEFAULT_MIRROR_CONCURRENCY
+	}
+
+	srcObjects, err := listObjectSummaries(src, srcBucket, o.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	dstObjects, err := listObjectSummaries(dst, dstBucket, o.Prefix)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := openMirrorCheckpoint(o.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer cp.close()
+
+	result := &MirrorResult{}
+	for key, s := range srcObjects {
+		d, ok := dstObjects[key]
+		reason := "missing"
+		if ok {
+			if s.Size == d.Size {
+				unchanged, err := mirrorUnchanged(src, srcBucket, dst, dstBucket, cp, key, s.Etag, d.Etag)
+				if err != nil {
+					return nil, err
+				}
+				if unchanged {
+					result.Skipped++
+					continue
+				}
+			}
+			reason = "changed"
+		}
+		result.Actions = append(result.Actions, MirrorAction{Action: MIRROR_ACTION_COPY,
+			Key: key, Size: s.Size, Reason: reason})
+	}
+	if o.Delete {
+		for key, d := range dstObjects {
+			if _, ok := srcObjects[key]; !ok {
+				result.Actions = append(result.Actions, MirrorAction{Action: MIRROR_ACTION_DELETE,
+					Key: key, Size: d.Size, Reason: "deleted in source"})
+			}
+		}
+	}
+	sort.Slice(result.Actions, func(i, j int) bool {
+		return result.Actions[i].Key < result.Actions[j].Key
+	})
+	if o.DryRun || len(result.Actions) == 0 {
+		return result, nil
+	}
+
+	serverSide := !o.DisableServerSideCopy && sameRegion(src, dst)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan int)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range ch {
+				action := &result.Actions[idx]
+				var err error
+				if action.Action == MIRROR_ACTION_DELETE {
+					_, err = dst.Delete_Object(dstBucket, action.Key)
+				} else {
+					action.Method, err = mirrorCopy(src, srcBucket, dst, dstBucket, action.Key,
+						action.Size, serverSide, o.PreserveACL)
+					if err == nil {
+						cp.add(action.Key, srcObjects[action.Key].Etag)
+					}
+				}
+				mu.Lock()
+				switch {
+				case err != nil:
+					action.Err = err
+					result.Failed = append(result.Failed, *action)
+				case action.Action == MIRROR_ACTION_DELETE:
+					result.Deleted++
+				default:
+					result.Copied++
+					result.Bytes += action.Size
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range result.Actions {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(result.Failed) > 0 {
+		return result, Model.NewFDSError(result.Summary()+", first error: "+result.Failed[0].Err.Error(), -1)
+	}
+	return result, nil
+}
+
+// mirrorCopyFallback 判断服务端复制失败后是否改为下载后上传：服务端不支持复制，或者目标用户没有读取源object的权限(跨用户)。
+// object不存在、quota等错误下载后上传也不会成功，直接返回
+func mirrorCopyFallback(err error) bool {
+	var fdsErr *Model.FDSError
+	if errors.As(err, &fdsErr) && fdsErr.Code() == http.StatusForbidden {
+		return true
+	}
+	return IsCopyUnsupported(err)
+}
+
+// mirrorCopy 复制一个object，返回实际使用的复制方式
+func mirrorCopy(src *FDSClient, srcBucket string, dst *FDSClient, dstBucket, key string, size int64,
+	serverSide, preserveACL bool) (string, error) {
+	method := MIRROR_METHOD_STREAM
+	copied := false
+	if serverSide {
+		_, err := dst.Copy_Object(srcBucket, key, dstBucket, key)
+		if err == nil {
+			method = MIRROR_METHOD_SERVER_SIDE
+			copied = true
+		} else if !mirrorCopyFallback(err) {
+			return MIRROR_METHOD_SERVER_SIDE, err
+		}
+	}
+	if !copied {
+		meta, err := src.Get_Object_Meta(srcBucket, key)
+		if err != nil {
+			return method, err
+		}
+		contentType, headers := uploadHeaders(meta)
+		// 不解压，content-encoding会随metadata一起复制
+		reader, _, err := src.getObjectResponse(srcBucket, key, "", 0, -1, false, nil)
+		if err != nil {
+			return method, err
+		}
+		err = dst.putReader(dstBucket, key, reader, size, contentType, headers)
+		reader.Close()
+		if err != nil {
+			return method, err
+		}
+	}
+
+	if preserveACL {
+		acl, err := src.Get_Object_ACL(srcBucket, key)
+		if err != nil {
+			return method, err
+		}
+		if acl != nil && len(acl.AccessControlLists) > 0 {
+			// owner属于源用户，只复制授权列表
+			_, err = dst.Set_Object_Acl_New(dstBucket, key, Model.ACL{AccessControlLists: acl.AccessControlLists})
+			if err != nil {
+				return method, err
+			}
+		}
+	}
+	return method, nil
+}
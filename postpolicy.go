@@ -0,0 +1,130 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	DEFAULT_POST_POLICY_EXPIRES = time.Hour
+
+	// 表单上传中文件字段的名字，必须是表单的最后一个字段
+	POST_POLICY_FILE_FIELD = "file"
+	// key中的该占位符会被服务端替换为上传文件的文件名
+	POST_POLICY_FILENAME = "${filename}"
+
+	POST_POLICY_FIELD_KEY              = "key"
+	POST_POLICY_FIELD_POLICY           = "Policy"
+	POST_POLICY_FIELD_CONTENT_TYPE     = "Content-Type"
+	POST_POLICY_FIELD_SUCCESS_REDIRECT = "success_action_redirect"
+)
+
+// PostPolicy 浏览器表单上传(POST)的限制条件
+type PostPolicy struct {
+	Bucket string
+	// Key 指定完整的object名字；为空时只限制前缀为KeyPrefix，表单key为KeyPrefix+${filename}
+	Key       string
+	KeyPrefix string
+	// ContentType 限制content-type必须等于该值，ContentTypePrefix限制content-type的前缀，例如image/
+	ContentType       string
+	ContentTypePrefix string
+	// MinContentLength和MaxContentLength限制文件大小，MaxContentLength为0时不限制
+	MinContentLength int64
+	MaxContentLength int64
+	Expires          time.Duration // 默认DEFAULT_POST_POLICY_EXPIRES
+	// SuccessRedirect 上传成功后浏览器跳转的地址
+	SuccessRedirect string
+	// Metadata x-xiaomi-meta-*等固定的表单字段，会作为等值条件写入policy
+	Metadata map[string]string
+}
+
+// PostPolicyForm 表单上传需要的url和隐藏字段，文件字段POST_POLICY_FILE_FIELD需要放在最后
+type PostPolicyForm struct {
+	URL        string
+	Fields     map[string]string
+	Policy     string // policy的json原文，便于调试
+	Expiration time.Time
+}
+
+type postPolicyDocument struct {
+	Expiration string        `json:"expiration"`
+	Conditions []interface{} `json:"conditions"`
+}
+
+// Generate_Post_Policy 生成签名的POST policy以及对应的表单字段，浏览器可以直接以multipart/form-data
+// 的方式上传到FDS。policy使用base64编码，签名与Signature相同，使用HMAC-SHA1
+func (c *FDSClient) Generate_Post_Policy(policy *PostPolicy) (*PostPolicyForm, error) {
+	if policy == nil || len(policy.Bucket) == 0 {
+		return nil, Model.NewFDSError("bucket name is required", -1)
+	}
+	if len(policy.Key) > 0 && len(policy.KeyPrefix) > 0 {
+		return nil, Model.NewFDSError("only one of key and key prefix can be set", -1)
+	}
+	if policy.MaxContentLength > 0 && policy.MinContentLength > policy.MaxContentLength {
+		return nil, Model.NewFDSError("invalid content length range", -1)
+	}
+	expires := policy.Expires
+	if expires <= 0 {
+		expires = DEFAULT_POST_POLICY_EXPIRES
+	}
+	expiration := time.Now().Add(expires).UTC()
+
+	fields := map[string]string{}
+	conditions := []interface{}{map[string]string{"bucket": policy.Bucket}}
+	if len(policy.Key) > 0 {
+		fields[POST_POLICY_FIELD_KEY] = policy.Key
+		conditions = append(conditions, []interface{}{"eq", "$key", policy.Key})
+	} else {
+		fields[POST_POLICY_FIELD_KEY] = policy.KeyPrefix + POST_POLICY_FILENAME
+		conditions = append(conditions, []interface{}{"starts-with", "$key", policy.KeyPrefix})
+	}
+	if len(policy.ContentType) > 0 {
+		fields[POST_POLICY_FIELD_CONTENT_TYPE] = policy.ContentType
+		conditions = append(conditions, []interface{}{"eq", "$" + POST_POLICY_FIELD_CONTENT_TYPE, policy.ContentType})
+	} else if len(policy.ContentTypePrefix) > 0 {
+		conditions = append(conditions,
+			[]interface{}{"starts-with", "$" + POST_POLICY_FIELD_CONTENT_TYPE, policy.ContentTypePrefix})
+	}
+	if policy.MinContentLength > 0 || policy.MaxContentLength > 0 {
+		max := policy.MaxContentLength
+		if max <= 0 {
+			max = 1<<63 - 1
+		}
+		conditions = append(conditions, []interface{}{"content-length-range", policy.MinContentLength, max})
+	}
+	if len(policy.SuccessRedirect) > 0 {
+		fields[POST_POLICY_FIELD_SUCCESS_REDIRECT] = policy.SuccessRedirect
+		conditions = append(conditions,
+			map[string]string{POST_POLICY_FIELD_SUCCESS_REDIRECT: policy.SuccessRedirect})
+	}
+	for k, v := range policy.Metadata {
+		fields[k] = v
+		conditions = append(conditions, map[string]string{k: v})
+	}
+
+	doc, err := json.Marshal(postPolicyDocument{
+		Expiration: expiration.Format("2006-01-02T15:04:05.000Z"),
+		Conditions: conditions,
+	})
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	encoded := base64.StdEncoding.EncodeToString(doc)
+	signature, err := signStringToSign(c.AppSecret, []byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+	fields[POST_POLICY_FIELD_POLICY] = encoded
+	fields[GALAXY_ACCESS_KEY_ID] = c.AppKey
+	fields[SIGNATURE] = signature
+
+	return &PostPolicyForm{
+		URL:        c.GetUploadURL() + policy.Bucket,
+		Fields:     fields,
+		Policy:     string(doc),
+		Expiration: expiration,
+	}, nil
+}
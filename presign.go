@@ -0,0 +1,145 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	DEFAULT_PRESIGN_EXPIRES = 15 * time.Minute
+
+	// PresignOptions.Host的取值，默认跟随client的EnableCDN
+	PRESIGN_HOST_DEFAULT = ""
+	PRESIGN_HOST_ORIGIN  = "origin"
+	PRESIGN_HOST_CDN     = "cdn"
+)
+
+// PresignOptions Generate_Presigned_Request的参数，ContentType、ContentMD5和Headers都会参与签名，
+// 使用预签名url时必须原样发送
+type PresignOptions struct {
+	Method      string        // 默认GET
+	Expires     time.Duration // 有效期，默认DEFAULT_PRESIGN_EXPIRES
+	ContentType string
+	ContentMD5  string
+	// Headers 额外需要绑定的x-xiaomi-*头，例如x-xiaomi-meta-*
+	Headers map[string]string
+	// SubResources 参与签名的子资源，例如uploadId和partNumber，key必须在SUB_RESOURCE_MAP中
+	SubResources map[string]string
+	// Host 使用源站(PRESIGN_HOST_ORIGIN)还是CDN(PRESIGN_HOST_CDN)域名，设置了EndPoint时总是使用EndPoint
+	Host string
+}
+
+// PresignedRequest 预签名的结果，Headers是客户端请求时必须携带的header
+type PresignedRequest struct {
+	URL        string
+	Method     string
+	Headers    map[string]string
+	Expiration int64 // 过期时间，unix毫秒
+}
+
+func (c *FDSClient) presignBaseUri(host string) (string, error) {
+	switch host {
+	case PRESIGN_HOST_DEFAULT:
+		return c.GetBaseUri(), nil
+	case PRESIGN_HOST_ORIGIN, PRESIGN_HOST_CDN:
+		cc := *c
+		cc.EnableCDN = host == PRESIGN_HOST_CDN
+		return cc.GetBaseUri(), nil
+	}
+	return "", Model.NewFDSError("invalid presign host: "+host, -1)
+}
+
+// Generate_Presigned_Request 生成预签名请求，可以绑定content-type、content-md5、x-xiaomi-*头以及
+// uploadId/partNumber等子资源，用于浏览器等不持有密钥的客户端直接上传或下载。
+// opts为nil时生成有效期为DEFAULT_PRESIGN_EXPIRES的GET请求，返回url以及客户端必须发送的header
+func (c *FDSClient) Generate_Presigned_Request(bucketname, objectname string,
+	opts *PresignOptions) (*PresignedRequest, error) {
+	o := PresignOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	method := strings.ToUpper(o.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete:
+	default:
+		return nil, Model.NewFDSError("invalid presign method: "+o.Method, -1)
+	}
+	if o.Expires <= 0 {
+		o.Expires = DEFAULT_PRESIGN_EXPIRES
+	}
+	baseUri, err := c.presignBaseUri(o.Host)
+	if err != nil {
+		return nil, err
+	}
+	urlParsed, err := url.Parse(baseUri + bucketname + DELIMITER + objectname)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+
+	headers := map[string]string{}
+	if len(o.ContentType) > 0 {
+		headers[Model.ContentType] = o.ContentType
+	}
+	if len(o.ContentMD5) > 0 {
+		headers[Model.ContentMD5] = o.ContentMD5
+	}
+	for k, v := range o.Headers {
+		key := strings.ToLower(k)
+		if !strings.HasPrefix(key, "x-xiaomi-") {
+			return nil, Model.NewFDSError("only x-xiaomi-* headers can be presigned: "+k, -1)
+		}
+		headers[key] = v
+	}
+	signHeaders := map[string][]string{}
+	for k, v := range headers {
+		signHeaders[k] = []string{v}
+	}
+
+	expiration := time.Now().Add(o.Expires).UnixNano() / int64(time.Millisecond)
+	params := url.Values{}
+	if method == http.MethodHead {
+		params.Set("metadata", "")
+	}
+	for k, v := range o.SubResources {
+		if _, ok := SUB_RESOURCE_MAP[k]; !ok {
+			return nil, Model.NewFDSError("unknown sub-resource: "+k, -1)
+		}
+		params.Set(k, v)
+	}
+	params.Set(GALAXY_ACCESS_KEY_ID, c.AppKey)
+	params.Set(EXPIRES, fmt.Sprintf("%d", expiration))
+	urlParsed.RawQuery = params.Encode()
+	signature, err := Signature(c.AppSecret, method, urlParsed.String(), signHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedRequest{
+		URL:        urlParsed.String() + "&" + SIGNATURE + "=" + url.QueryEscape(signature),
+		Method:     method,
+		Headers:    headers,
+		Expiration: expiration,
+	}, nil
+}
+
+// Generate_Presigned_Upload_Part 为分片上传中的一个分片生成预签名PUT请求，浏览器可以直接上传分片，
+// 完成后由服务端调用Complete_Multipart_Upload。expires<=0时使用DEFAULT_PRESIGN_EXPIRES
+func (c *FDSClient) Generate_Presigned_Upload_Part(initResult *Model.InitMultipartUploadResult,
+	partNumber int, expires time.Duration) (*PresignedRequest, error) {
+	return c.Generate_Presigned_Request(initResult.BucketName, initResult.ObjectName, &PresignOptions{
+		Method:  http.MethodPut,
+		Expires: expires,
+		SubResources: map[string]string{
+			"uploadId":   initResult.UploadId,
+			"partNumber": strconv.Itoa(partNumber),
+		},
+	})
+}
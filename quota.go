@@ -0,0 +1,96 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	// 使用量达到quota的该比例时告警
+	DEFAULT_QUOTA_WARN_RATIO = 0.8
+
+	QUOTA_STATUS_OK       = "OK"
+	QUOTA_STATUS_WARN     = "WARN"
+	QUOTA_STATUS_EXCEEDED = "EXCEEDED"
+)
+
+// QuotaUsage 一项quota的使用情况
+type QuotaUsage struct {
+	Type   string
+	Used   int64
+	Limit  int64
+	Ratio  float64
+	Status string
+}
+
+// QuotaReport bucket的quota使用报告，Status为所有项中最严重的状态
+type QuotaReport struct {
+	BucketName string
+	Usages     []QuotaUsage
+	Status     string
+}
+
+var quotaStatusLevel = map[string]int{QUOTA_STATUS_OK: 0, QUOTA_STATUS_WARN: 1, QUOTA_STATUS_EXCEEDED: 2}
+
+// NewQuotaReport 比较BucketInfo中的UsedSpace/ObjectNum与SPACE/OBJECTS quota，
+// 使用量达到warnRatio时为WARN，超过quota时为EXCEEDED，warnRatio<=0时使用DEFAULT_QUOTA_WARN_RATIO。
+// QPS quota没有对应的使用量，不会出现在报告中，值为0的quota视为不限制
+func NewQuotaReport(info *Model.BucketInfo, policy *Model.QuotaPolicy, warnRatio float64) *QuotaReport {
+	if warnRatio <= 0 {
+		warnRatio = DEFAULT_QUOTA_WARN_RATIO
+	}
+	report := &QuotaReport{BucketName: info.BucketName, Status: QUOTA_STATUS_OK}
+	used := map[string]int64{
+		Model.QUOTA_TYPE_SPACE:   info.UsedSpace,
+		Model.QUOTA_TYPE_OBJECTS: info.ObjectNum,
+	}
+	for _, quotaType := range []string{Model.QUOTA_TYPE_SPACE, Model.QUOTA_TYPE_OBJECTS} {
+		limit, ok := policy.GetQuota(quotaType, "")
+		if !ok || limit == 0 {
+			continue
+		}
+		usage := QuotaUsage{Type: quotaType, Used: used[quotaType], Limit: limit, Status: QUOTA_STATUS_OK}
+		usage.Ratio = float64(usage.Used) / float64(limit)
+		switch {
+		case usage.Used > limit:
+			usage.Status = QUOTA_STATUS_EXCEEDED
+		case usage.Ratio >= warnRatio:
+			usage.Status = QUOTA_STATUS_WARN
+		}
+		if quotaStatusLevel[usage.Status] > quotaStatusLevel[report.Status] {
+			report.Status = usage.Status
+		}
+		report.Usages = append(report.Usages, usage)
+	}
+	return report
+}
+
+// String 返回一行便于告警的描述，例如 bucket: WARN, WARN SPACE 85.0% (850/1000)
+func (r *QuotaReport) String() string {
+	parts := []string{r.BucketName + ": " + r.Status}
+	for _, u := range r.Usages {
+		parts = append(parts, fmt.Sprintf("%s %s %.1f%% (%d/%d)", u.Status, u.Type, u.Ratio*100, u.Used, u.Limit))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Get_Bucket_Quota_Report 获取bucket信息和quota设置，生成使用报告，参考NewQuotaReport
+func (c *FDSClient) Get_Bucket_Quota_Report(bucketname string, warnRatio float64) (*QuotaReport, error) {
+	info, err := c.Get_Bucket(bucketname)
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		info = &Model.BucketInfo{}
+	}
+	if len(info.BucketName) == 0 {
+		info.BucketName = bucketname
+	}
+	policy, err := c.Get_Bucket_Quota(bucketname)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuotaReport(info, policy, warnRatio), nil
+}
@@ -0,0 +1,236 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_RATE_LIMIT_MAX_RETRIES = 3
+	DEFAULT_RATE_LIMIT_BACKOFF     = 500 * time.Millisecond
+	DEFAULT_RATE_LIMIT_MAX_BACKOFF = 30 * time.Second
+	rateLimitChunkSize             = 32 * 1024
+	rateLimitMaxShift              = 16
+)
+
+// tokenBucket 令牌桶，rate<=0表示不限制；允许透支，透支部分通过等待偿还
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) setRate(rate float64) {
+	b.rate = rate
+	b.burst = rate
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	} else {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// RateLimiter 限制FDSClient的请求频率和上传、下载带宽，可以在多个FDSClient之间共享，
+// 所有限制都可以在运行时通过Set*方法调整；遇到429/503时会暂停所有请求并按指数退避重试
+type RateLimiter struct {
+	// MaxRetries、Backoff和MaxBackoff只能在开始使用前直接修改，使用后通过SetRetryPolicy调整
+	// MaxRetries 遇到429/503时的最大重试次数
+	MaxRetries int
+	// Backoff 第一次退避的时间，之后每次翻倍，不超过MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	mu          sync.Mutex
+	requests    tokenBucket
+	upload      tokenBucket
+	download    tokenBucket
+	pausedUntil time.Time
+	throttled   int
+}
+
+// NewRateLimiter 创建RateLimiter，参数小于等于0表示不限制
+//
+//	requestsPerSecond: 每秒请求数
+//	uploadBytesPerSecond: 每秒上传字节数
+//	downloadBytesPerSecond: 每秒下载字节数
+func NewRateLimiter(requestsPerSecond float64, uploadBytesPerSecond, downloadBytesPerSecond int64) *RateLimiter {
+	l := &RateLimiter{
+		MaxRetries: DEFAULT_RATE_LIMIT_MAX_RETRIES,
+		Backoff:    DEFAULT_RATE_LIMIT_BACKOFF,
+		MaxBackoff: DEFAULT_RATE_LIMIT_MAX_BACKOFF,
+	}
+	l.SetRequestRate(requestsPerSecond)
+	l.SetUploadRate(uploadBytesPerSecond)
+	l.SetDownloadRate(downloadBytesPerSecond)
+	return l
+}
+
+func (l *RateLimiter) SetRequestRate(requestsPerSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests.setRate(requestsPerSecond)
+}
+
+func (l *RateLimiter) SetUploadRate(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.upload.setRate(float64(bytesPerSecond))
+}
+
+func (l *RateLimiter) SetDownloadRate(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.download.setRate(float64(bytesPerSecond))
+}
+
+// SetRetryPolicy 调整遇到429/503时的最大重试次数和退避时间，可以在运行时调用
+func (l *RateLimiter) SetRetryPolicy(maxRetries int, backoff, maxBackoff time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.MaxRetries = maxRetries
+	l.Backoff = backoff
+	l.MaxBackoff = maxBackoff
+}
+
+// WaitRequest 阻塞直到允许发出下一个请求
+func (l *RateLimiter) WaitRequest() {
+	l.mu.Lock()
+	now := time.Now()
+	d := l.requests.reserve(1, now)
+	if pause := l.pausedUntil.Sub(now); pause > d {
+		d = pause
+	}
+	l.mu.Unlock()
+	time.Sleep(d)
+}
+
+func (l *RateLimiter) waitBytes(b *tokenBucket, n int) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	d := b.reserve(float64(n), time.Now())
+	l.mu.Unlock()
+	time.Sleep(d)
+}
+
+// retryAfter 解析Retry-After，支持秒数和HTTP日期两种格式
+func retryAfter(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res == nil {
+		return 0, false
+	}
+	v := res.Header.Get("Retry-After")
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Duration(s) * time.Second, s > 0
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := t.Sub(now)
+		return d, d > 0
+	}
+	return 0, false
+}
+
+// Throttled 记录一次429/503，返回重试前需要等待的时间；期间所有请求都会被暂停。
+// 响应中有Retry-After时使用该时间，但不超过MaxBackoff
+func (l *RateLimiter) Throttled(res *http.Response) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	shift := l.throttled
+	if shift > rateLimitMaxShift {
+		shift = rateLimitMaxShift
+	}
+	d := l.Backoff << uint(shift)
+	if d <= 0 || (l.MaxBackoff > 0 && d > l.MaxBackoff) {
+		d = l.MaxBackoff
+	}
+	now := time.Now()
+	if after, ok := retryAfter(res, now); ok {
+		d = after
+		if l.MaxBackoff > 0 && d > l.MaxBackoff {
+			d = l.MaxBackoff
+		}
+	}
+	l.throttled++
+	if until := now.Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	return d
+}
+
+// Succeeded 请求成功后重置退避次数
+func (l *RateLimiter) Succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.throttled = 0
+}
+
+func (l *RateLimiter) shouldRetry(statusCode, attempt int) bool {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return attempt < l.MaxRetries
+}
+
+type rateLimitedReader struct {
+	r       io.Reader
+	limiter *RateLimiter
+	bucket  *tokenBucket
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunkSize {
+		p = p[:rateLimitChunkSize]
+	}
+	n, err := r.r.Read(p)
+	r.limiter.waitBytes(r.bucket, n)
+	return n, err
+}
+
+type rateLimitedReadCloser struct {
+	rateLimitedReader
+	c io.Closer
+}
+
+func (r *rateLimitedReadCloser) Close() error {
+	return r.c.Close()
+}
+
+// UploadReader 返回受上传带宽限制的reader
+func (l *RateLimiter) UploadReader(r io.Reader) io.Reader {
+	return &rateLimitedReader{r: r, limiter: l, bucket: &l.upload}
+}
+
+// DownloadReadCloser 返回受下载带宽限制的ReadCloser
+func (l *RateLimiter) DownloadReadCloser(rc io.ReadCloser) io.ReadCloser {
+	return &rateLimitedReadCloser{
+		rateLimitedReader: rateLimitedReader{r: rc, limiter: l, bucket: &l.download},
+		c:                 rc,
+	}
+}
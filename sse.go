@@ -0,0 +1,142 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"io"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// sseCustomerHeaders 从上传的header中取出客户密钥相关的header，分片上传时每个分片都需要带上
+func sseCustomerHeaders(headers map[string]string) map[string]string {
+	h := map[string]string{}
+	for _, k := range []string{Model.SSECustomerAlgorithm, Model.SSECustomerKey, Model.SSECustomerKeyMD5} {
+		if v, ok := headers[k]; ok {
+			h[k] = v
+		}
+	}
+	return h
+}
+
+// sseUploadHeaders 检查metadata和sse，返回content-type以及合并了服务端加密参数的header
+func sseUploadHeaders(metadata *Model.ObjectMetadata, sse *Model.SSEOptions) (string, map[string]string, error) {
+	contentType, headers, err := metadataHeaders(metadata)
+	if err != nil {
+		return "", nil, err
+	}
+	if sse == nil {
+		return "", nil, Model.NewFDSError("empty server side encryption options", -1)
+	}
+	if err := sse.Validate(); err != nil {
+		return "", nil, err
+	}
+	h := sse.Headers()
+	for k, v := range headers {
+		h[k] = v
+	}
+	return contentType, h, nil
+}
+
+// Put_Object_With_SSE 与Put_Object_With_Metadata相同，object在服务端使用sse指定的密钥加密
+func (c *FDSClient) Put_Object_With_SSE(bucketname, objectname string, data []byte,
+	metadata *Model.ObjectMetadata, sse *Model.SSEOptions) (*Model.PutObjectResult, error) {
+	contentType, headers, err := sseUploadHeaders(metadata, sse)
+	if err != nil {
+		return nil, err
+	}
+	return c.Put_Object(bucketname, objectname, data, contentType, &headers)
+}
+
+// Put_Reader_With_SSE 与Put_Reader_With_Metadata相同，使用分片上传时每个分片都会带上客户密钥
+func (c *FDSClient) Put_Reader_With_SSE(bucketname, objectname string, r io.Reader, size int64,
+	metadata *Model.ObjectMetadata, sse *Model.SSEOptions) error {
+	contentType, headers, err := sseUploadHeaders(metadata, sse)
+	if err != nil {
+		return err
+	}
+	return c.putReader(bucketname, objectname, r, size, contentType, headers)
+}
+
+// Init_MultiPart_Upload_With_SSE 与Init_MultiPart_Upload_With_Metadata相同，完成上传后的object使用sse加密。
+// 使用客户密钥时需要通过Upload_Part_With_SSE上传分片
+func (c *FDSClient) Init_MultiPart_Upload_With_SSE(bucketname, objectname string,
+	metadata *Model.ObjectMetadata, sse *Model.SSEOptions) (*Model.InitMultipartUploadResult, error) {
+	contentType, headers, err := sseUploadHeaders(metadata, sse)
+	if err != nil {
+		return nil, err
+	}
+	return c.initMultipartUpload(bucketname, objectname, contentType, headers)
+}
+
+// Upload_Part_With_SSE 与Upload_Part相同，带上初始化时使用的客户密钥；使用服务端管理的密钥时与Upload_Part相同
+func (c *FDSClient) Upload_Part_With_SSE(initUploadPartResult *Model.InitMultipartUploadResult, partnumber int,
+	data []byte, sse *Model.SSEOptions) (*Model.UploadPartResult, error) {
+	return c.uploadPart(initUploadPartResult, partnumber, data, sse.ReadHeaders())
+}
+
+// Copy_Object_With_SSE 与Copy_Object相同，srcSSE为源object的客户密钥，源object没有使用客户密钥时为nil；
+// dstSSE为目标object的加密参数，为nil时不加密
+func (c *FDSClient) Copy_Object_With_SSE(src_bucketname, src_objectname, dst_bucketname, dst_objectname string,
+	srcSSE, dstSSE *Model.SSEOptions) (*Model.PutObjectResult, error) {
+	for _, sse := range []*Model.SSEOptions{srcSSE, dstSSE} {
+		if sse == nil {
+			continue
+		}
+		if err := sse.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return c.copyObject(src_bucketname, src_objectname, "", dst_bucketname, dst_objectname, srcSSE, dstSSE)
+}
+
+// Get_Object_With_SSE 与Get_Object相同，读取使用客户密钥加密的object。
+// 客户密钥错误时服务端返回错误，可以用Get_Object_Meta返回的GetSSECustomerKeyMD5判断应该使用哪个密钥
+func (c *FDSClient) Get_Object_With_SSE(bucketname, objectname string, position, size int64,
+	sse *Model.SSEOptions) (*Model.FDSObject, error) {
+	return c.getObjectContent(bucketname, objectname, "", position, size,
+		c.AutoDecompress && position == 0 && size < 0, sse)
+}
+
+// Get_Object_Reader_With_SSE 与Get_Object_Reader相同，读取使用客户密钥加密的object
+func (c *FDSClient) Get_Object_Reader_With_SSE(bucketname, objectname string, position, size int64,
+	sse *Model.SSEOptions) (io.ReadCloser, error) {
+	reader, _, err := c.getObjectResponse(bucketname, objectname, "", position, size,
+		c.AutoDecompress && position == 0 && size < 0, sse)
+	return reader, err
+}
+
+// Get_Object_Meta_With_SSE 与Get_Object_Meta相同，获取使用客户密钥加密的object的metadata
+func (c *FDSClient) Get_Object_Meta_With_SSE(bucketname, objectname string,
+	sse *Model.SSEOptions) (*Model.FDSMetaData, error) {
+	return c.getObjectMeta(bucketname, objectname, "", sse)
+}
+
+// Download_Object_With_SSE 与Download_Object相同，下载使用客户密钥加密的object
+func (c *FDSClient) Download_Object_With_SSE(bucketname, objectname, filename string,
+	sse *Model.SSEOptions) (*string, error) {
+	return c.downloadObject(bucketname, objectname, filename, c.AutoDecompress, sse)
+}
+
+// Append_Object_With_SSE 与Append_Object相同，追加到使用sse加密的object，重写后的object使用相同的加密。
+// object不存在时使用sse创建
+func (c *FDSClient) Append_Object_With_SSE(bucketname, objectname string, position int64, data []byte,
+	sse *Model.SSEOptions) (int64, error) {
+	if sse == nil {
+		return 0, Model.NewFDSError("empty server side encryption options", -1)
+	}
+	if err := sse.Validate(); err != nil {
+		return 0, err
+	}
+	return c.appendObject(bucketname, objectname, position, data, sse)
+}
+
+// Append_Writer_With_SSE 与Append_Writer相同，通过Append_Object_With_SSE追加
+func (c *FDSClient) Append_Writer_With_SSE(bucketname, objectname string, bufferSize int,
+	sse *Model.SSEOptions) (*AppendWriter, error) {
+	if sse == nil {
+		return nil, Model.NewFDSError("empty server side encryption options", -1)
+	}
+	if err := sse.Validate(); err != nil {
+		return nil, err
+	}
+	return c.appendWriter(bucketname, objectname, bufferSize, sse)
+}
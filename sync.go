@@ -0,0 +1,427 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	SYNC_UPLOAD   = "upload"   // 本地目录 -> FDS prefix
+	SYNC_DOWNLOAD = "download" // FDS prefix -> 本地目录
+
+	SYNC_COMPARE_MD5   = "md5"   // 大小相同时比较md5与etag(默认)
+	SYNC_COMPARE_MTIME = "mtime" // 大小相同时比较user metadata中记录的mtime，没有记录时退化为md5
+	SYNC_COMPARE_SIZE  = "size"  // 只比较大小
+
+	SYNC_ACTION_UPLOAD   = "upload"
+	SYNC_ACTION_DOWNLOAD = "download"
+	SYNC_ACTION_DELETE   = "delete"
+
+	// 上传时记录本地文件的mtime(unix秒)
+	SYNC_MTIME_METADATA      = USER_DEFINED_METADATA_PREFIX + "mtime"
+	DEFAULT_SYNC_CONCURRENCY = 4
+)
+
+// SyncOptions Sync的参数，Include/Exclude使用path.Match语法，匹配相对路径；
+// 不包含/的pattern同时匹配文件名，以/结尾的pattern匹配整个目录。被过滤掉的文件不会被传输也不会被删除
+type SyncOptions struct {
+	Direction   string
+	Compare     string
+	Delete      bool // 删除目标端多余的文件
+	Include     []string
+	Exclude     []string
+	Concurrency int
+	DryRun      bool // 只生成计划，不做任何修改
+}
+
+// SyncAction 同步计划中的一项操作，Key为object名字，Err为执行失败时的错误
+type SyncAction struct {
+	Action    string
+	Key       string
+	LocalPath string
+	Size      int64
+	Reason    string
+	Err       error `json:"-"`
+}
+
+// SyncResult Actions为计划执行的操作(DryRun时不会执行)，Failed为执行失败的操作，
+// 以及下载时因为object名字包含..等会写到本地目录之外而跳过的object
+type SyncResult struct {
+	Actions []SyncAction
+	Skipped int
+	Failed  []SyncAction
+}
+
+type syncLocalFile struct {
+	path  string
+	size  int64
+	mtime time.Time
+}
+
+func syncMatch(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(rel, p) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p, rel); ok {
+			return true
+		}
+		if !strings.Contains(p, "/") {
+			if ok, _ := path.Match(p, path.Base(rel)); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (o *SyncOptions) accept(rel string) bool {
+	if len(o.Include) > 0 && !syncMatch(o.Include, rel) {
+		return false
+	}
+	return !syncMatch(o.Exclude, rel)
+}
+
+func (o *SyncOptions) validate() error {
+	switch o.Direction {
+	case SYNC_UPLOAD, SYNC_DOWNLOAD:
+	default:
+		return Model.NewFDSError("unknown sync direction: "+o.Direction, -1)
+	}
+	switch o.Compare {
+	case SYNC_COMPARE_MD5, SYNC_COMPARE_MTIME, SYNC_COMPARE_SIZE:
+	default:
+		return Model.NewFDSError("unknown sync compare mode: "+o.Compare, -1)
+	}
+	for _, p := range append(append([]string{}, o.Include...), o.Exclude...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return Model.NewFDSError("bad pattern "+p+": "+err.Error(), -1)
+		}
+	}
+	return nil
+}
+
+// Sync 在本地目录和FDS的prefix之间同步文件，只传输大小或md5(mtime)不同的文件。
+// prefix非空时视为目录，自动补上/；opts为nil时为上传、比较md5、不删除多余文件。
+// 有操作失败时返回的SyncResult中Failed记录了失败的操作
+func (c *FDSClient) Sync(localDir, bucketname, prefix string, opts *SyncOptions) (*SyncResult, error) {
+	o := SyncOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Direction) == 0 {
+		o.Direction = SYNC_UPLOAD
+	}
+	if len(o.Compare) == 0 {
+		o.Compare = SYNC_COMPARE_MD5
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DEFAULT_SYNC_CONCURRENCY
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, DELIMITER) {
+		prefix += DELIMITER
+	}
+
+	locals, err := syncListLocal(localDir, &o)
+	if err != nil {
+		return nil, err
+	}
+	remotes := map[string]Model.FDSObjectSummary{}
+	err = c.walkObjects(bucketname, prefix, func(summary Model.FDSObjectSummary) error {
+		rel := strings.TrimPrefix(summary.ObjectName, prefix)
+		if len(rel) == 0 || strings.HasSuffix(rel, DELIMITER) || !o.accept(rel) {
+			return nil
+		}
+		remotes[rel] = summary
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{}
+	if o.Direction == SYNC_UPLOAD {
+		for rel, local := range locals {
+			remote, ok := remotes[rel]
+			reason := "new"
+			if ok {
+				changed, r, err := c.syncChanged(bucketname, local, remote, o.Compare)
+				if err != nil {
+					return nil, err
+				}
+				if !changed {
+					result.Skipped++
+					continue
+				}
+				reason = r
+			}
+			result.Actions = append(result.Actions, SyncAction{Action: SYNC_ACTION_UPLOAD,
+				Key: prefix + rel, LocalPath: local.path, Size: local.size, Reason: reason})
+		}
+		if o.Delete {
+			for rel, remote := range remotes {
+				if _, ok := locals[rel]; !ok {
+					result.Actions = append(result.Actions, SyncAction{Action: SYNC_ACTION_DELETE,
+						Key: remote.ObjectName, Size: remote.Size, Reason: "not in source"})
+				}
+			}
+		}
+	} else {
+		for rel, remote := range remotes {
+			if !filepath.IsLocal(filepath.FromSlash(rel)) {
+				result.Failed = append(result.Failed, SyncAction{Action: SYNC_ACTION_DOWNLOAD,
+					Key: remote.ObjectName, Size: remote.Size, Reason: "unsafe path",
+					Err: Model.NewFDSError("object key escapes local directory: "+remote.ObjectName, -1)})
+				continue
+			}
+			local, ok := locals[rel]
+			reason := "new"
+			if ok {
+				changed, r, err := c.syncChanged(bucketname, local, remote, o.Compare)
+				if err != nil {
+					return nil, err
+				}
+				if !changed {
+					result.Skipped++
+					continue
+				}
+				reason = r
+			}
+			result.Actions = append(result.Actions, SyncAction{Action: SYNC_ACTION_DOWNLOAD,
+				Key: remote.ObjectName, LocalPath: filepath.Join(localDir, filepath.FromSlash(rel)),
+				Size: remote.Size, Reason: reason})
+		}
+		if o.Delete {
+			for rel, local := range locals {
+				if _, ok := remotes[rel]; !ok {
+					result.Actions = append(result.Actions, SyncAction{Action: SYNC_ACTION_DELETE,
+						LocalPath: local.path, Size: local.size, Reason: "not in source"})
+				}
+			}
+		}
+	}
+
+	sort.Slice(result.Actions, func(i, j int) bool {
+		a, b := result.Actions[i], result.Actions[j]
+		if a.Action != b.Action {
+			return a.Action > b.Action
+		}
+		return a.Key+a.LocalPath < b.Key+b.LocalPath
+	})
+
+	if o.DryRun || len(result.Actions) == 0 {
+		return result, result.failedError()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ch := make(chan int)
+	for i := 0; i < o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range ch {
+				action := result.Actions[idx]
+				if err := c.syncExecute(bucketname, action); err != nil {
+					action.Err = err
+					mu.Lock()
+					result.Failed = append(result.Failed, action)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range result.Actions {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+
+	return result, result.failedError()
+}
+
+func (r *SyncResult) failedError() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	sort.Slice(r.Failed, func(i, j int) bool {
+		return r.Failed[i].Key+r.Failed[i].LocalPath < r.Failed[j].Key+r.Failed[j].LocalPath
+	})
+	return Model.NewFDSError(fmt.Sprintf("%d sync actions failed, first error: %s",
+		len(r.Failed), r.Failed[0].Err.Error()), -1)
+}
+
+func syncListLocal(localDir string, o *SyncOptions) (map[string]syncLocalFile, error) {
+	locals := map[string]syncLocalFile{}
+	err := filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 下载时本地目录可以不存在
+			if p == localDir && os.IsNotExist(err) && o.Direction == SYNC_DOWNLOAD {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !o.accept(rel) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		locals[rel] = syncLocalFile{path: p, size: fi.Size(), mtime: fi.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	return locals, nil
+}
+
+func isMD5Hex(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncChanged 判断本地文件与object是否不同，返回不同的原因
+func (c *FDSClient) syncChanged(bucketname string, local syncLocalFile, remote Model.FDSObjectSummary,
+	compare string) (bool, string, error) {
+	if local.size != remote.Size {
+		return true, "size differs", nil
+	}
+	if compare == SYNC_COMPARE_SIZE {
+		return false, "", nil
+	}
+
+	var meta *Model.FDSMetaData
+	if compare == SYNC_COMPARE_MTIME {
+		m, err := c.Get_Object_Meta(bucketname, remote.ObjectName)
+		if err != nil {
+			return false, "", err
+		}
+		meta = m
+		if s, err := meta.GetKey(SYNC_MTIME_METADATA); err == nil {
+			mtime, err := strconv.ParseInt(s, 10, 64)
+			if err == nil {
+				if mtime == local.mtime.Unix() {
+					return false, "", nil
+				}
+				return true, "mtime differs", nil
+			}
+		}
+	}
+
+	remoteMD5 := remote.Etag
+	if !isMD5Hex(remoteMD5) {
+		// 分片上传的object，etag不是md5
+		if meta == nil {
+			m, err := c.Get_Object_Meta(bucketname, remote.ObjectName)
+			if err != nil {
+				return false, "", err
+			}
+			meta = m
+		}
+		remoteMD5, _ = meta.GetContentMD5()
+	}
+	localMD5, err := fileMD5(local.path)
+	if err != nil {
+		return false, "", Model.NewFDSError(err.Error(), -1)
+	}
+	if !strings.EqualFold(localMD5, remoteMD5) {
+		return true, "md5 differs", nil
+	}
+	return false, "", nil
+}
+
+func (c *FDSClient) syncExecute(bucketname string, action SyncAction) error {
+	switch action.Action {
+	case SYNC_ACTION_UPLOAD:
+		fi, err := os.Stat(action.LocalPath)
+		if err != nil {
+			return Model.NewFDSError(err.Error(), -1)
+		}
+		headers := map[string]string{
+			SYNC_MTIME_METADATA: strconv.FormatInt(fi.ModTime().Unix(), 10),
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(action.LocalPath))
+		return c.putFile(bucketname, action.Key, action.LocalPath, contentType, headers)
+	case SYNC_ACTION_DOWNLOAD:
+		return c.syncDownload(bucketname, action)
+	case SYNC_ACTION_DELETE:
+		if len(action.Key) > 0 {
+			_, err := c.Delete_Object(bucketname, action.Key)
+			return err
+		}
+		if err := os.Remove(action.LocalPath); err != nil {
+			return Model.NewFDSError(err.Error(), -1)
+		}
+		return nil
+	}
+	return Model.NewFDSError("unknown sync action: "+action.Action, -1)
+}
+
+// syncDownload 先下载到临时文件再rename，mtime设置为上传时记录的mtime或object的修改时间
+func (c *FDSClient) syncDownload(bucketname string, action SyncAction) error {
+	if err := os.MkdirAll(filepath.Dir(action.LocalPath), 0755); err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	meta, err := c.Get_Object_Meta(bucketname, action.Key)
+	if err != nil {
+		return err
+	}
+	tmp := action.LocalPath + ".fds-sync-tmp"
+	// 与上传对称，不受AutoDecompress影响，本地文件与object的内容一致
+	if _, err := c.downloadObject(bucketname, action.Key, tmp, false, nil); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, action.LocalPath); err != nil {
+		os.Remove(tmp)
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	mtime := time.Now()
+	if s, err := meta.GetKey(SYNC_MTIME_METADATA); err == nil {
+		if sec, err := strconv.ParseInt(s, 10, 64); err == nil {
+			mtime = time.Unix(sec, 0)
+		}
+	} else if s, err := meta.GetLastModified(); err == nil {
+		if t, err := time.Parse(time.RFC1123, s); err == nil {
+			mtime = t
+		}
+	}
+	if err := os.Chtimes(action.LocalPath, mtime, mtime); err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	return nil
+}
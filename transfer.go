@@ -0,0 +1,114 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	// 超过该大小时使用分片上传，每个分片大小为MULTIPART_UPLOAD_PART_SIZE
+	MULTIPART_UPLOAD_THRESHOLD int64 = SLICE_SIZE
+	MULTIPART_UPLOAD_PART_SIZE int64 = SLICE_SIZE
+)
+
+// putReader 从r中读取size字节上传到指定object，超过MULTIPART_UPLOAD_THRESHOLD时使用分片上传，
+// 分片上传失败时会abort。size小于0表示长度未知，读取到EOF为止
+func (c *FDSClient) putReader(bucketname, objectname string, r io.Reader, size int64,
+	contentType string, headers map[string]string) error {
+	if size >= 0 && size <= MULTIPART_UPLOAD_THRESHOLD {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return Model.NewFDSError(err.Error(), -1)
+		}
+		return c.putData(bucketname, objectname, data, contentType, headers)
+	}
+
+	buf := make([]byte, MULTIPART_UPLOAD_PART_SIZE)
+	n, readErr := io.ReadFull(r, buf)
+	if size < 0 && (readErr == io.EOF || readErr == io.ErrUnexpectedEOF) {
+		// 长度未知但不超过一个分片，直接上传
+		return c.putData(bucketname, objectname, buf[:n], contentType, headers)
+	}
+	if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+		return Model.NewFDSError(readErr.Error(), -1)
+	}
+
+	initResult, err := c.initMultipartUpload(bucketname, objectname, contentType, headers)
+	if err != nil {
+		return err
+	}
+	partHeaders := sseCustomerHeaders(headers)
+	var uploadPartList Model.UploadPartList
+	for partNumber := 1; ; partNumber++ {
+		if partNumber > 1 {
+			n, readErr = io.ReadFull(r, buf)
+		}
+		if n > 0 {
+			uploadPartResult, err := c.uploadPart(initResult, partNumber, buf[:n], partHeaders)
+			if err != nil {
+				c.Abort_MultipartUpload(initResult)
+				return err
+			}
+			uploadPartList.AddUploadPartResult(uploadPartResult)
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			c.Abort_MultipartUpload(initResult)
+			return Model.NewFDSError(readErr.Error(), -1)
+		}
+	}
+	_, err = c.Complete_Multipart_Upload(initResult, &uploadPartList)
+	return err
+}
+
+func (c *FDSClient) putData(bucketname, objectname string, data []byte,
+	contentType string, headers map[string]string) error {
+	h := map[string]string{}
+	for k, v := range headers {
+		h[k] = v
+	}
+	_, err := c.Put_Object(bucketname, objectname, data, contentType, &h)
+	return err
+}
+
+// putFile 上传本地文件
+func (c *FDSClient) putFile(bucketname, objectname, filename, contentType string,
+	headers map[string]string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	return c.putReader(bucketname, objectname, f, fi.Size(), contentType, headers)
+}
+
+// fileMD5 计算本地文件的md5，返回小写16进制字符串
+func fileMD5(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// uploadHeaders 从Get_Object_Meta的结果中取出上传时可以设置的header，用于复制object时保留metadata
+func uploadHeaders(meta *Model.FDSMetaData) (string, map[string]string) {
+	m := Model.NewObjectMetadataFromFDSMetaData(meta)
+	return m.GetContentType(), m.Headers()
+}
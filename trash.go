@@ -0,0 +1,278 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+const (
+	TRASH_BUCKET = "trash"
+
+	// 恢复时原位置已经存在object的处理方式
+	RESTORE_CONFLICT_SKIP      = "skip"      // 跳过，保留回收站中的object
+	RESTORE_CONFLICT_OVERWRITE = "overwrite" // 覆盖原位置的object
+	RESTORE_CONFLICT_RENAME    = "rename"    // 恢复到原名字加后缀的新位置
+
+	DEFAULT_RESTORE_SUFFIX = ".restored"
+
+	TRASH_ACTION_RESTORE = "restore"
+	TRASH_ACTION_SKIP    = "skip"
+	TRASH_ACTION_PURGE   = "purge"
+)
+
+// TrashObject 回收站中的一个object，回收站列出的名字为bucket_name/object_name
+type TrashObject struct {
+	BucketName string
+	ObjectName string
+	Summary    Model.FDSObjectSummary
+}
+
+func newTrashObject(summary Model.FDSObjectSummary) TrashObject {
+	parts := strings.SplitN(summary.ObjectName, DELIMITER, 2)
+	o := TrashObject{BucketName: parts[0], Summary: summary}
+	if len(parts) == 2 {
+		o.ObjectName = parts[1]
+	}
+	return o
+}
+
+// TrashIterator 分页遍历回收站，用法与bufio.Scanner相同：
+//
+//	it := c.Trash_Iterator("bucket/prefix")
+//	for it.Next() {
+//		o := it.Object()
+//	}
+//	if err := it.Err(); err != nil {...}
+type TrashIterator struct {
+	c       *FDSClient
+	prefix  string
+	listing *Model.FDSObjectListing
+	index   int
+	current TrashObject
+	err     error
+}
+
+// Trash_Iterator 返回遍历回收站中prefix(bucket_name/object_name的前缀)下所有object的迭代器
+func (c *FDSClient) Trash_Iterator(prefix string) *TrashIterator {
+	return &TrashIterator{c: c, prefix: prefix}
+}
+
+// Next 移动到下一个object，没有更多object或出错时返回false
+func (it *TrashIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	for it.listing == nil || it.index >= len(it.listing.ObjectSummaries) {
+		if it.listing != nil && !it.listing.Truncated {
+			return false
+		}
+		marker := ""
+		if it.listing != nil {
+			marker = it.listing.NextMarker
+		}
+		it.listing, it.err = it.c.listTrashObject(it.prefix, "", marker, DEFAULT_LIST_MAX_KEYS)
+		if it.err != nil {
+			return false
+		}
+		it.index = 0
+	}
+	it.current = newTrashObject(it.listing.ObjectSummaries[it.index])
+	it.index++
+	return true
+}
+
+// Object 返回当前的object
+func (it *TrashIterator) Object() TrashObject {
+	return it.current
+}
+
+// Err 返回遍历过程中的错误
+func (it *TrashIterator) Err() error {
+	return it.err
+}
+
+// List_Next_Batch_Of_Trash_Objects 返回List_Trash_Object的下一页
+func (c *FDSClient) List_Next_Batch_Of_Trash_Objects(previous *Model.FDSObjectListing) (*Model.FDSObjectListing, error) {
+	if !previous.Truncated {
+		return nil, errors.New("No more objects")
+	}
+	return c.listTrashObject(previous.Prefix, previous.Delimiter, previous.NextMarker, previous.MaxKeys)
+}
+
+// RestoreOptions Restore_Trash_Objects的参数
+type RestoreOptions struct {
+	// Conflict 原位置已经存在object时的处理方式，默认RESTORE_CONFLICT_SKIP
+	Conflict string
+	// Suffix RESTORE_CONFLICT_RENAME时新名字的后缀，默认DEFAULT_RESTORE_SUFFIX，新名字也存在时再追加-1、-2...
+	Suffix string
+	DryRun bool
+	// Progress 每处理完一个object调用一次
+	Progress func(action TrashAction)
+}
+
+// TrashAction 对回收站中一个object的操作，Target为恢复到的object名字
+type TrashAction struct {
+	Action     string
+	BucketName string
+	ObjectName string
+	Target     string
+	Reason     string
+	Err        error `json:"-"`
+}
+
+type TrashResult struct {
+	Actions  []TrashAction
+	Restored int
+	Skipped  int
+	Purged   int
+	Failed   []TrashAction
+}
+
+// Summary 返回一行可读的统计信息
+func (r *TrashResult) Summary() string {
+	return fmt.Sprintf("objects %d, restored %d, skipped %d, purged %d, failed %d",
+		len(r.Actions), r.Restored, r.Skipped, r.Purged, len(r.Failed))
+}
+
+func (r *TrashResult) add(action TrashAction, progress func(TrashAction)) {
+	switch {
+	case action.Err != nil:
+		r.Failed = append(r.Failed, action)
+	case action.Action == TRASH_ACTION_RESTORE:
+		r.Restored++
+	case action.Action == TRASH_ACTION_PURGE:
+		r.Purged++
+	default:
+		r.Skipped++
+	}
+	r.Actions = append(r.Actions, action)
+	if progress != nil {
+		progress(action)
+	}
+}
+
+func (r *TrashResult) err() error {
+	if len(r.Failed) > 0 {
+		return Model.NewFDSError(r.Summary()+", first error: "+r.Failed[0].Err.Error(), -1)
+	}
+	return nil
+}
+
+// restoreTarget 返回RESTORE_CONFLICT_RENAME时可用的新名字
+func (c *FDSClient) restoreTarget(bucketname, objectname, suffix string) (string, error) {
+	target := objectname + suffix
+	for i := 1; ; i++ {
+		exists, err := c.Is_Object_Exists(bucketname, target)
+		if err != nil || !exists {
+			return target, err
+		}
+		target = objectname + suffix + "-" + strconv.Itoa(i)
+	}
+}
+
+// restoreAs 将回收站中的object恢复为bucketname/target。服务端只能恢复到原位置，
+// 所以先把原位置的object临时改名，恢复后再把两个object改到各自的位置
+func (c *FDSClient) restoreAs(bucketname, objectname, target string) error {
+	tmp := objectname + ".restoring-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if _, err := c.Rename_Object(bucketname, objectname, tmp); err != nil {
+		return err
+	}
+	err := c.Restore_Object(bucketname, objectname)
+	if err == nil {
+		if _, err = c.Rename_Object(bucketname, objectname, target); err != nil {
+			// 恢复出的object仍在原位置，不能再把临时object改回去覆盖它
+			return Model.NewFDSError("restored to "+objectname+", original object kept as "+tmp+": "+err.Error(), -1)
+		}
+	}
+	if _, e := c.Rename_Object(bucketname, tmp, objectname); e != nil && err == nil {
+		err = Model.NewFDSError("restored to "+target+" but failed to move "+tmp+" back: "+e.Error(), -1)
+	}
+	return err
+}
+
+// Restore_Trash_Objects 恢复回收站中bucketname下prefix开头的所有object，恢复前使用Is_Object_Exists检查原位置，
+// 已经存在时按opts.Conflict跳过、覆盖或恢复到新名字。单个object失败不会中断其它object，失败的object记录在Failed中并返回错误
+func (c *FDSClient) Restore_Trash_Objects(bucketname, prefix string, opts *RestoreOptions) (*TrashResult, error) {
+	o := RestoreOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Conflict) == 0 {
+		o.Conflict = RESTORE_CONFLICT_SKIP
+	}
+	if o.Conflict != RESTORE_CONFLICT_SKIP && o.Conflict != RESTORE_CONFLICT_OVERWRITE &&
+		o.Conflict != RESTORE_CONFLICT_RENAME {
+		return nil, Model.NewFDSError("invalid restore conflict policy: "+o.Conflict, -1)
+	}
+	if len(o.Suffix) == 0 {
+		o.Suffix = DEFAULT_RESTORE_SUFFIX
+	}
+	if !checkNotEmpty(bucketname) {
+		return nil, errors.New("empty argument")
+	}
+
+	result := &TrashResult{}
+	it := c.Trash_Iterator(bucketname + DELIMITER + prefix)
+	for it.Next() {
+		t := it.Object()
+		action := TrashAction{Action: TRASH_ACTION_RESTORE, BucketName: t.BucketName,
+			ObjectName: t.ObjectName, Target: t.ObjectName}
+		exists, err := c.Is_Object_Exists(t.BucketName, t.ObjectName)
+		if err != nil {
+			action.Err = err
+			result.add(action, o.Progress)
+			continue
+		}
+		if exists {
+			switch o.Conflict {
+			case RESTORE_CONFLICT_SKIP:
+				action.Action = TRASH_ACTION_SKIP
+				action.Reason = "object exists"
+			case RESTORE_CONFLICT_OVERWRITE:
+				action.Reason = "overwrite existing object"
+			case RESTORE_CONFLICT_RENAME:
+				action.Target, action.Err = c.restoreTarget(t.BucketName, t.ObjectName, o.Suffix)
+				action.Reason = "object exists"
+			}
+		}
+		if action.Action == TRASH_ACTION_RESTORE && action.Err == nil && !o.DryRun {
+			if action.Target == t.ObjectName {
+				action.Err = c.Restore_Object(t.BucketName, t.ObjectName)
+			} else {
+				action.Err = c.restoreAs(t.BucketName, t.ObjectName, action.Target)
+			}
+		}
+		result.add(action, o.Progress)
+	}
+	if err := it.Err(); err != nil {
+		return result, err
+	}
+	return result, result.err()
+}
+
+// Purge_Trash 永久删除回收站中prefix(bucket_name/object_name的前缀)下在before之前进入回收站的object，
+// 以列出的LastModified为准。dryRun为true时只返回将要删除的object
+func (c *FDSClient) Purge_Trash(prefix string, before time.Time, dryRun bool) (*TrashResult, error) {
+	result := &TrashResult{}
+	it := c.Trash_Iterator(prefix)
+	for it.Next() {
+		t := it.Object()
+		if !t.Summary.LastModified.Before(before) {
+			continue
+		}
+		action := TrashAction{Action: TRASH_ACTION_PURGE, BucketName: t.BucketName, ObjectName: t.ObjectName}
+		if !dryRun {
+			action.Err = c.Purge_Trash_Object(t.BucketName, t.ObjectName)
+		}
+		result.add(action, nil)
+	}
+	if err := it.Err(); err != nil {
+		return result, err
+	}
+	return result, result.err()
+}
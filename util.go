@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log/slog"
 	"math"
 	"mime"
 	"net/http"
@@ -72,6 +74,17 @@ type FDSClient struct {
 	EndPoint    string
 	EnableHttps bool
 	EnableCDN   bool
+
+	// Logger 为nil时不输出任何日志；authorization、Signature参数以及AppSecret始终会被脱敏
+	Logger *slog.Logger
+	// RateLimiter 为nil时不做任何限制
+	RateLimiter *RateLimiter
+	// SignatureDebug 为true时，服务端返回403会将本地的string-to-sign与服务端错误信息一起输出，
+	// Logger为nil时输出到slog.Default()
+	SignatureDebug bool
+	// AutoDecompress 为true时，Get_Object、Get_Object_Reader读取整个object以及Download_Object
+	// 会按照content-encoding(gzip、zstd或通过RegisterCompressionCodec注册的编码)解压内容
+	AutoDecompress bool
 }
 
 type FDSAuth struct {
@@ -187,7 +200,35 @@ func (c *FDSClient) Auth(auth FDSAuth) (*http.Response, error) {
 	urlParsed.RawQuery = params.Encode()
 	urlStr := urlParsed.String()
 
-	req, _ := http.NewRequest(auth.Method, urlStr, ioutil.NopCloser(bytes.NewReader(auth.Data)))
+	for attempt := 0; ; attempt++ {
+		res, err := c.doAuth(client, auth, urlStr)
+		if err != nil || c.RateLimiter == nil {
+			return res, err
+		}
+		if !c.RateLimiter.shouldRetry(res.StatusCode, attempt) {
+			if res.StatusCode < 400 {
+				c.RateLimiter.Succeeded()
+			}
+			return res, nil
+		}
+		// 遇到429/503时退避后重试，Data为[]byte，可以重复发送
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		delay := c.RateLimiter.Throttled(res)
+		c.logRetry(auth.Method, urlStr, res.StatusCode, attempt+1, delay)
+	}
+}
+
+func (c *FDSClient) doAuth(client *http.Client, auth FDSAuth, urlStr string) (*http.Response, error) {
+	var body io.Reader = bytes.NewReader(auth.Data)
+	if c.RateLimiter != nil {
+		c.RateLimiter.WaitRequest()
+		body = c.RateLimiter.UploadReader(body)
+	}
+	req, _ := http.NewRequest(auth.Method, urlStr, ioutil.NopCloser(body))
+	if c.RateLimiter != nil && len(auth.Data) > 0 {
+		req.ContentLength = int64(len(auth.Data))
+	}
 	if auth.Headers != nil {
 		for k, v := range *auth.Headers {
 			req.Header.Add(k, v)
@@ -197,16 +238,25 @@ func (c *FDSClient) Auth(auth FDSAuth) (*http.Response, error) {
 	req.Header.Add("content-md5", auth.Content_Md5)
 	req.Header.Add("content-type", auth.Content_Type)
 
-	signature, err := Signature(c.AppSecret, req.Method, urlStr, req.Header)
+	stringToSign, err := stringToSign(req.Method, urlStr, req.Header)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signStringToSign(c.AppSecret, stringToSign)
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Add("authorization", fmt.Sprintf("Galaxy-V2 %s:%s", c.AppKey, signature))
+	start := time.Now()
 	res, err := client.Do(req)
+	c.logRequest(req, stringToSign, res, err, time.Since(start))
 	if err != nil {
 		return nil, Model.NewFDSError(err.Error(), -1)
 	}
+	if c.RateLimiter != nil {
+		res.Body = c.RateLimiter.DownloadReadCloser(res.Body)
+	}
 	return res, nil
 }
 
@@ -486,47 +536,33 @@ func (c *FDSClient) Is_Object_Exists(bucketname, objectname string) (bool, error
 //example:
 //     Not available now
 func (c *FDSClient) Get_Object(bucketname, objectname string, position int64, size int64) (*Model.FDSObject, error) {
-	if position < 0 {
-		return nil, Model.NewFDSError("Seek position should be no less than 0", -1)
-	}
-	url := c.GetBaseUri() + bucketname + DELIMITER + objectname
-	headers := map[string]string{}
-	if position >= 0 && size < 0 {
-		headers["range"] = fmt.Sprintf("bytes=%d-", position)
-	} else if position >= 0 && size > 0 {
-		headers["range"] = fmt.Sprintf("bytes=%d-%d", position, position+size-1)
-	} else if position >= 0 && size == 0 {
-		return nil, Model.NewFDSError("Request size should be larger than 0", -1)
-	} else {
-		return nil, Model.NewFDSError("position or size set error", -1)
-	}
-	auth := FDSAuth{
-		UrlBase:      url,
-		Method:       "GET",
-		Data:         nil,
-		Content_Md5:  "",
-		Content_Type: "",
-		Headers:      &headers,
-	}
-	res, err := c.Auth(auth)
+	return c.getObject(bucketname, objectname, "", position, size)
+}
+
+func (c *FDSClient) getObject(bucketname, objectname, versionId string, position int64,
+	size int64) (*Model.FDSObject, error) {
+	return c.getObjectContent(bucketname, objectname, versionId, position, size,
+		c.AutoDecompress && position == 0 && size < 0, nil)
+}
+
+// getObjectContent 与getObject相同，decompress为true时按content-encoding解压内容，sse不为nil时带上读取客户密钥加密的object需要的header
+func (c *FDSClient) getObjectContent(bucketname, objectname, versionId string, position int64,
+	size int64, decompress bool, sse *Model.SSEOptions) (*Model.FDSObject, error) {
+	reader, header, err := c.getObjectResponse(bucketname, objectname, versionId, position, size, decompress, sse)
 	if err != nil {
-		return nil, Model.NewFDSError(err.Error(), -1)
+		return nil, err
 	}
-	body, err := ioutil.ReadAll(res.Body)
-	res.Body.Close()
+	body, err := ioutil.ReadAll(reader)
+	reader.Close()
 	if err != nil {
 		return nil, Model.NewFDSError(err.Error(), -1)
 	}
-	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusPartialContent {
-		return &Model.FDSObject{
-			BucketName:    bucketname,
-			ObjectName:    objectname,
-			Metadata:      *Model.NewFDSMetaData(res.Header),
-			ObjectContent: body,
-		}, nil
-	} else {
-		return nil, Model.NewFDSError(string(body), res.StatusCode)
-	}
+	return &Model.FDSObject{
+		BucketName:    bucketname,
+		ObjectName:    objectname,
+		Metadata:      *Model.NewFDSMetaData(header),
+		ObjectContent: body,
+	}, nil
 }
 
 //name:
@@ -547,19 +583,47 @@ func (c *FDSClient) Get_Object_With_Uri(uri string, position, size int64) (*Mode
 }
 
 func (c *FDSClient) Get_Object_Reader(bucketname, objectname string, position int64, size int64) (*io.ReadCloser, error) {
+	return c.getObjectReader(bucketname, objectname, "", position, size)
+}
+
+// Get_Object_Reader_With_Metadata 与Get_Object_Reader相同，同时返回这次响应header中的metadata，
+// 其中的content-md5和last-modified与读取到的内容一致。不受AutoDecompress影响，总是返回服务端保存的内容
+func (c *FDSClient) Get_Object_Reader_With_Metadata(bucketname, objectname string, position int64,
+	size int64) (io.ReadCloser, *Model.FDSMetaData, error) {
+	reader, header, err := c.getObjectResponse(bucketname, objectname, "", position, size, false, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, Model.NewFDSMetaData(header), nil
+}
+
+func (c *FDSClient) getObjectReader(bucketname, objectname, versionId string, position int64,
+	size int64) (*io.ReadCloser, error) {
+	reader, _, err := c.getObjectResponse(bucketname, objectname, versionId, position, size,
+		c.AutoDecompress && position == 0 && size < 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &reader, nil
+}
+
+// getObjectResponse 返回object内容的reader和响应header，decompress为true时按content-encoding解压，
+// 只有读取整个object时才能解压
+func (c *FDSClient) getObjectResponse(bucketname, objectname, versionId string, position int64,
+	size int64, decompress bool, sse *Model.SSEOptions) (io.ReadCloser, http.Header, error) {
 	if position < 0 {
-		return nil, Model.NewFDSError("Seek position should be no less than 0", -1)
+		return nil, nil, Model.NewFDSError("Seek position should be no less than 0", -1)
 	}
 	url := c.GetBaseUri() + bucketname + DELIMITER + objectname
-	headers := map[string]string{}
+	headers := sse.ReadHeaders()
 	if position >= 0 && size < 0 {
 		headers["range"] = fmt.Sprintf("bytes=%d-", position)
 	} else if position >= 0 && size > 0 {
 		headers["range"] = fmt.Sprintf("bytes=%d-%d", position, position+size-1)
 	} else if position >= 0 && size == 0 {
-		return nil, Model.NewFDSError("Request size should be larger than 0", -1)
+		return nil, nil, Model.NewFDSError("Request size should be larger than 0", -1)
 	} else {
-		return nil, Model.NewFDSError("position or size set error", -1)
+		return nil, nil, Model.NewFDSError("position or size set error", -1)
 	}
 	auth := FDSAuth{
 		UrlBase:      url,
@@ -568,20 +632,25 @@ func (c *FDSClient) Get_Object_Reader(bucketname, objectname string, position in
 		Content_Md5:  "",
 		Content_Type: "",
 		Headers:      &headers,
+		Params:       versionParams(versionId),
 	}
 	res, err := c.Auth(auth)
 	if err != nil {
-		return nil, Model.NewFDSError(err.Error(), -1)
+		return nil, nil, Model.NewFDSError(err.Error(), -1)
 	}
 	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusPartialContent {
-		return &res.Body, nil
+		if !decompress {
+			return res.Body, res.Header, nil
+		}
+		reader, err := decompressBody(res.Header, res.Body)
+		return reader, res.Header, err
 	} else {
 		body, err := ioutil.ReadAll(res.Body)
 		res.Body.Close()
 		if err != nil {
-			return nil, Model.NewFDSError(err.Error(), res.StatusCode)
+			return nil, nil, Model.NewFDSError(err.Error(), res.StatusCode)
 		}
-		return nil, Model.NewFDSError(string(body), res.StatusCode)
+		return nil, nil, Model.NewFDSError(string(body), res.StatusCode)
 	}
 }
 
@@ -598,6 +667,13 @@ func (c *FDSClient) Get_Object_Reader(bucketname, objectname string, position in
 //example:
 //     Not available now
 func (c *FDSClient) Download_Object(bucketname, objectname, filename string) (*string, error) {
+	return c.downloadObject(bucketname, objectname, filename, c.AutoDecompress, nil)
+}
+
+// downloadObject 与Download_Object相同，decompress为true并且content-encoding可以识别时写入解压后的内容，
+// 此时返回的md5是写入文件的解压后内容的md5
+func (c *FDSClient) downloadObject(bucketname, objectname, filename string, decompress bool,
+	sse *Model.SSEOptions) (*string, error) {
 	if _, err := os.Stat(filename); os.IsExist(err) {
 		return nil, Model.NewFDSError("File exists", -1)
 	}
@@ -607,7 +683,7 @@ func (c *FDSClient) Download_Object(bucketname, objectname, filename string) (*s
 
 	bufferdWriter := bufio.NewWriter(file)
 
-	meta, err := c.Get_Object_Meta(bucketname, objectname)
+	meta, err := c.getObjectMeta(bucketname, objectname, "", sse)
 	if err != nil {
 		return nil, Model.NewFDSError(err.Error(), -1)
 	}
@@ -621,11 +697,31 @@ func (c *FDSClient) Download_Object(bucketname, objectname, filename string) (*s
 		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 
-	// log.Printf("contentLength %d\n", contentLength)
-	// log.Printf("Slices %d\n", slices)
+	if c.Logger != nil {
+		c.Logger.Debug("fds download object", "bucket", bucketname, "object", objectname,
+			"content_length", contentLength, "slices", slices)
+	}
+	if encoding, _ := meta.GetContentEncoding(); decompress && len(encoding) > 0 {
+		if _, ok := compressionCodec(encoding); ok {
+			reader, _, err := c.getObjectResponse(bucketname, objectname, "", 0, -1, true, sse)
+			if err != nil {
+				return nil, err
+			}
+			defer reader.Close()
+			hash := md5.New()
+			if _, err := io.Copy(io.MultiWriter(bufferdWriter, hash), reader); err != nil {
+				return nil, Model.NewFDSError(err.Error(), -1)
+			}
+			if err := bufferdWriter.Flush(); err != nil {
+				return nil, Model.NewFDSError(err.Error(), -1)
+			}
+			md5sum = hex.EncodeToString(hash.Sum(nil))
+			return &md5sum, nil
+		}
+	}
 
 	url := c.GetBaseUri() + bucketname + DELIMITER + objectname
-	headers := map[string]string{}
+	headers := sse.ReadHeaders()
 	auth := FDSAuth{
 		UrlBase:      url,
 		Method:       "GET",
@@ -749,7 +845,19 @@ func (c *FDSClient) List_Object(bucketname, prefix, delimiter string, maxKeys in
 //example:
 //     Not available now
 func (c *FDSClient) List_Trash_Object(prefix, delimiter string, maxKeys int) (*Model.FDSObjectListing, error) {
-	urlStr := c.GetBaseUri() + "trash" //+ "?authorizedObjects"
+	return c.listTrashObject(prefix, delimiter, "", maxKeys)
+}
+
+func (c *FDSClient) listTrashObject(prefix, delimiter, marker string, maxKeys int) (*Model.FDSObjectListing, error) {
+	urlStr := c.GetBaseUri() + TRASH_BUCKET //+ "?authorizedObjects"
+	params := map[string]string{
+		"prefix":    prefix,
+		"delimiter": delimiter,
+		"maxKeys":   strconv.Itoa(maxKeys),
+	}
+	if len(marker) > 0 {
+		params["marker"] = marker
+	}
 	auth := FDSAuth{
 		UrlBase:      urlStr,
 		Method:       "GET",
@@ -757,11 +865,7 @@ func (c *FDSClient) List_Trash_Object(prefix, delimiter string, maxKeys int) (*M
 		Content_Md5:  "",
 		Content_Type: "",
 		Headers:      nil,
-		Params: &map[string]string{
-			"prefix":    prefix,
-			"delimiter": delimiter,
-			"maxKeys":   strconv.Itoa(maxKeys),
-		},
+		Params:       &params,
 	}
 	res, err := c.Auth(auth)
 	if err != nil {
@@ -992,6 +1096,10 @@ func checkNotEmpty(s string) bool {
 }
 
 func (c *FDSClient) Delete_Object(bucketname, objectname string) (bool, error) {
+	return c.deleteObject(bucketname, objectname, "")
+}
+
+func (c *FDSClient) deleteObject(bucketname, objectname, versionId string) (bool, error) {
 	if !checkNotEmpty(bucketname) || !checkNotEmpty(objectname) {
 		return false, errors.New("empty argument")
 	}
@@ -1003,6 +1111,7 @@ func (c *FDSClient) Delete_Object(bucketname, objectname string) (bool, error) {
 		Content_Md5:  "",
 		Content_Type: "",
 		Headers:      nil,
+		Params:       versionParams(versionId),
 	}
 	res, err := c.Auth(auth)
 	if err != nil {
@@ -1048,6 +1157,90 @@ func (c *FDSClient) Rename_Object(bucketname, src_objectname, dst_objectname str
 	}
 }
 
+//name:
+//     Copy_Object
+//description:
+//     在服务端复制object，源object与目标object需要在同一个region，并且当前用户对源object有读权限
+//param:
+//     src_bucketname: 源object所在的bucket
+//     src_objectname: 源object名字
+//     dst_bucketname: 目标bucket
+//     dst_objectname: 目标object名字
+//return:
+//     *Model.PutObjectResult: 复制结果信息
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     Not available now
+func (c *FDSClient) Copy_Object(src_bucketname, src_objectname,
+	dst_bucketname, dst_objectname string) (*Model.PutObjectResult, error) {
+	return c.copyObject(src_bucketname, src_objectname, "", dst_bucketname, dst_objectname, nil, nil)
+}
+
+// copyObject 与Copy_Object相同，src_versionId非空时复制源object的指定版本，
+// srcSSE为源object的客户密钥，dstSSE为目标object的服务端加密参数
+func (c *FDSClient) copyObject(src_bucketname, src_objectname, src_versionId,
+	dst_bucketname, dst_objectname string, srcSSE, dstSSE *Model.SSEOptions) (*Model.PutObjectResult, error) {
+	url := c.GetUploadURL() + dst_bucketname + DELIMITER + dst_objectname
+	source := map[string]string{
+		"srcBucketName": src_bucketname,
+		"srcObjectName": src_objectname,
+	}
+	if len(src_versionId) > 0 {
+		source["srcVersionId"] = src_versionId
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	headers := dstSSE.Headers()
+	for k, v := range srcSSE.CopySourceHeaders() {
+		headers[k] = v
+	}
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "PUT",
+		Data:         data,
+		Content_Md5:  "",
+		Content_Type: "application/json",
+		Headers:      &headers,
+		Params: &map[string]string{
+			"cpFrom": "",
+		},
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return Model.NewPutObjectResult(body)
+	} else {
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+// IsCopyUnsupported 判断Copy_Object失败是否因为服务端不支持(未实现或跨region)，此时可以改为下载后重新上传；
+// 权限、object不存在、quota等错误重新上传也不会成功，需要直接返回
+func IsCopyUnsupported(err error) bool {
+	var fdsErr *Model.FDSError
+	if !errors.As(err, &fdsErr) {
+		return false
+	}
+	switch fdsErr.Code() {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	case http.StatusBadRequest:
+		msg := strings.ToLower(fdsErr.Message())
+		return strings.Contains(msg, "region") || strings.Contains(msg, "not support") ||
+			strings.Contains(msg, "unsupported")
+	}
+	return false
+}
+
 func (c *FDSClient) Prefetch_Object(bucketname, objectname string) (bool, error) {
 	url := c.GetUploadURL() + bucketname + DELIMITER + objectname + "?prefetch"
 	auth := FDSAuth{
@@ -1375,6 +1568,318 @@ func (c *FDSClient) Get_Bucket_ACL(bucketname string) (*Model.ACL, error) {
 	}
 }
 
+//name:
+//     Get_Bucket_Quota
+//description:
+//     获取指定bucket的quota设置
+//param:
+//     bucketname:  要获取quota的bucket
+//return:
+//     *Model.QuotaPolicy:  quota设置，没有设置时Quotas为空
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     policy, err := client.Get_Bucket_Quota("bucket")
+func (c *FDSClient) Get_Bucket_Quota(bucketname string) (*Model.QuotaPolicy, error) {
+	url := c.GetUploadURL() + bucketname + "?quota"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "GET",
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return Model.NewQuotaPolicy(body)
+	} else {
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Set_Bucket_Quota
+//description:
+//     设置指定bucket的quota，会覆盖原有设置，发送前会校验quota
+//param:
+//     bucketname:  要设置quota的bucket
+//     quota:       quota设置
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     policy := Model.QuotaPolicy{}
+//     policy.SetQuota(Model.QUOTA_TYPE_SPACE, "", 100<<30)
+//     client.Set_Bucket_Quota("bucket", policy)
+func (c *FDSClient) Set_Bucket_Quota(bucketname string, quota Model.QuotaPolicy) (bool, error) {
+	if err := quota.Validate(); err != nil {
+		return false, err
+	}
+	jsonString, _ := json.Marshal(quota)
+	url := c.GetUploadURL() + bucketname + "?quota"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "PUT",
+		Data:         jsonString,
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return true, nil
+	} else {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Get_Bucket_Lifecycle
+//description:
+//     获取指定bucket的lifecycle规则
+//param:
+//     bucketname:  要获取lifecycle规则的bucket
+//return:
+//     *Model.LifecycleConfig:  lifecycle规则，没有设置时Rules为空
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     config, err := client.Get_Bucket_Lifecycle("bucket")
+func (c *FDSClient) Get_Bucket_Lifecycle(bucketname string) (*Model.LifecycleConfig, error) {
+	url := c.GetUploadURL() + bucketname + "?lifecycle"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "GET",
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return Model.NewLifecycleConfig(body)
+	} else {
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Set_Bucket_Lifecycle
+//description:
+//     设置指定bucket的lifecycle规则，会覆盖原有规则，发送前会检查规则是否合法以及规则之间是否冲突
+//param:
+//     bucketname:  要设置lifecycle规则的bucket
+//     config:      lifecycle规则
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     config := Model.LifecycleConfig{}
+//     config.SetRule(Model.LifecycleRule{Id: "logs", Prefix: "logs/", Enabled: true,
+//         Actions: Model.LifecycleActions{Expiration: &Model.LifecycleDays{Days: 30}}})
+//     client.Set_Bucket_Lifecycle("bucket", config)
+func (c *FDSClient) Set_Bucket_Lifecycle(bucketname string, config Model.LifecycleConfig) (bool, error) {
+	if err := config.Validate(); err != nil {
+		return false, err
+	}
+	jsonString, _ := json.Marshal(config)
+	url := c.GetUploadURL() + bucketname + "?lifecycle"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "PUT",
+		Data:         jsonString,
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return true, nil
+	} else {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Delete_Bucket_Lifecycle
+//description:
+//     删除指定bucket的所有lifecycle规则
+//param:
+//     bucketname:  要删除lifecycle规则的bucket
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     client.Delete_Bucket_Lifecycle("bucket")
+func (c *FDSClient) Delete_Bucket_Lifecycle(bucketname string) (bool, error) {
+	url := c.GetUploadURL() + bucketname + "?lifecycle"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "DELETE",
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return true, nil
+	} else {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Get_Bucket_CORS
+//description:
+//     获取指定bucket的CORS规则
+//param:
+//     bucketname:  要获取CORS规则的bucket
+//return:
+//     *Model.CORSConfig:  CORS规则，没有设置时Rules为空
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     config, err := client.Get_Bucket_CORS("bucket")
+func (c *FDSClient) Get_Bucket_CORS(bucketname string) (*Model.CORSConfig, error) {
+	url := c.GetUploadURL() + bucketname + "?cors"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "GET",
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return Model.NewCORSConfig(body)
+	} else {
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Set_Bucket_CORS
+//description:
+//     设置指定bucket的CORS规则，会覆盖原有规则，发送前会检查规则是否合法
+//param:
+//     bucketname:  要设置CORS规则的bucket
+//     config:      CORS规则
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     client.Set_Bucket_CORS("bucket", Model.CORSConfig{Rules: []Model.CORSRule{{
+//         AllowedOrigins: []string{"https://*.example.com"}, AllowedMethods: []string{"GET", "PUT"}}}})
+func (c *FDSClient) Set_Bucket_CORS(bucketname string, config Model.CORSConfig) (bool, error) {
+	if err := config.Validate(); err != nil {
+		return false, err
+	}
+	jsonString, _ := json.Marshal(config)
+	url := c.GetUploadURL() + bucketname + "?cors"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "PUT",
+		Data:         jsonString,
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return true, nil
+	} else {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
+//name:
+//     Delete_Bucket_CORS
+//description:
+//     删除指定bucket的所有CORS规则
+//param:
+//     bucketname:  要删除CORS规则的bucket
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     client.Delete_Bucket_CORS("bucket")
+func (c *FDSClient) Delete_Bucket_CORS(bucketname string) (bool, error) {
+	url := c.GetUploadURL() + bucketname + "?cors"
+	auth := FDSAuth{
+		UrlBase:      url,
+		Method:       "DELETE",
+		Content_Md5:  "",
+		Content_Type: "",
+		Headers:      nil,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode == 200 {
+		return true, nil
+	} else {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+}
+
 func (c *FDSClient) Set_Public(bucketname, objectname string, disable_prefetch bool) (bool, error) {
 	grant := map[string]interface{}{
 		"grantee":    ALL_USERS,
@@ -1398,19 +1903,61 @@ func (c *FDSClient) Set_Public(bucketname, objectname string, disable_prefetch b
 	return true, nil
 }
 
+//name:
+//     Set_Private
+//description:
+//     Set_Public的逆操作，删除object对ALL_USERS的READ授权
+//param:
+//     bucketname:       object所在的bucket
+//     objectname:       要取消公开的object
+//     disable_refresh:  为false时同时刷新CDN缓存，避免CDN上的副本仍然可以访问
+//return:
+//     bool:  如果执行正常则返回true，发生错误是返回false
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     client.Set_Private("bucket", "object", false)
+func (c *FDSClient) Set_Private(bucketname, objectname string, disable_refresh bool) (bool, error) {
+	acl := Model.ACL{AccessControlLists: []Model.AccessControlList{{
+		Grantees:   Model.Grantee{Id: ALL_USERS["id"]},
+		Type:       PERMISSION_GROUP,
+		Permission: PERMISSION_READ,
+	}}}
+	_, err := c.Delete_Object_ACL(bucketname, objectname, acl)
+	if err != nil {
+		return false, err
+	}
+	if !disable_refresh {
+		_, err := c.Refresh_Object(bucketname, objectname)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (c *FDSClient) Init_MultiPart_Upload(bucketname, objectname string, contentType string) (*Model.InitMultipartUploadResult, error) {
+	return c.initMultipartUpload(bucketname, objectname, contentType, nil)
+}
+
+// initMultipartUpload 与Init_MultiPart_Upload相同，headers中可以带上x-xiaomi-meta-*等信息
+func (c *FDSClient) initMultipartUpload(bucketname, objectname string, contentType string,
+	headers map[string]string) (*Model.InitMultipartUploadResult, error) {
 	url := c.GetUploadURL() + bucketname + DELIMITER + objectname
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
 	md5sum := fmt.Sprintf("%x", md5.Sum([]byte("")))
+	h := map[string]string{"x-xiaomi-estimated-object-size": "1000000000"}
+	for k, v := range headers {
+		h[k] = v
+	}
 	auth := FDSAuth{
 		UrlBase:      url,
 		Method:       "PUT",
 		Data:         []byte(""),
 		Content_Md5:  md5sum,
 		Content_Type: contentType,
-		Headers:      &map[string]string{"x-xiaomi-estimated-object-size": "1000000000"},
+		Headers:      &h,
 		Params: &map[string]string{
 			"uploads": "",
 		},
@@ -1432,6 +1979,12 @@ func (c *FDSClient) Init_MultiPart_Upload(bucketname, objectname string, content
 }
 
 func (c *FDSClient) Upload_Part(initUploadPartResult *Model.InitMultipartUploadResult, partnumber int, data []byte) (*Model.UploadPartResult, error) {
+	return c.uploadPart(initUploadPartResult, partnumber, data, nil)
+}
+
+// uploadPart 与Upload_Part相同，headers中可以带上客户密钥等信息
+func (c *FDSClient) uploadPart(initUploadPartResult *Model.InitMultipartUploadResult, partnumber int, data []byte,
+	headers map[string]string) (*Model.UploadPartResult, error) {
 	bucketname := initUploadPartResult.BucketName
 	objectname := initUploadPartResult.ObjectName
 	uploadId := initUploadPartResult.UploadId
@@ -1441,7 +1994,7 @@ func (c *FDSClient) Upload_Part(initUploadPartResult *Model.InitMultipartUploadR
 		Method:      "PUT",
 		Data:        data,
 		Content_Md5: "",
-		Headers:     nil,
+		Headers:     &headers,
 		Params: &map[string]string{
 			"uploadId":   uploadId,
 			"partNumber": strconv.Itoa(partnumber),
@@ -1529,14 +2082,22 @@ func (c *FDSClient) Abort_MultipartUpload(initPartuploadResult *Model.InitMultip
 }
 
 func (c *FDSClient) Get_Object_Meta(bucketname, objectname string) (*Model.FDSMetaData, error) {
+	return c.getObjectMeta(bucketname, objectname, "", nil)
+}
+
+// getObjectMeta 与Get_Object_Meta相同，sse不为nil时带上读取客户密钥加密的object需要的header
+func (c *FDSClient) getObjectMeta(bucketname, objectname, versionId string,
+	sse *Model.SSEOptions) (*Model.FDSMetaData, error) {
 	url := c.GetBaseUri() + bucketname +
 		DELIMITER + objectname + "?metadata"
+	headers := sse.ReadHeaders()
 	auth := FDSAuth{
 		UrlBase:     url,
 		Method:      "GET",
 		Data:        nil,
 		Content_Md5: "",
-		Headers:     nil,
+		Headers:     &headers,
+		Params:      versionParams(versionId),
 	}
 	res, err := c.Auth(auth)
 	if err != nil {
@@ -1614,11 +2175,45 @@ func (c *FDSClient) Generate_Presigned_URI(bucketname, objectname, method string
 	return urlParsed.String() + "&" + SIGNATURE + "=" + signature, nil
 }
 
+// Delete_Objects 批量删除object，超过DELETE_OBJECTS_BATCH_SIZE时通过Bulk_Delete_Objects分批删除
 func (c *FDSClient) Delete_Objects(bucketname string, prefix []string) error {
+	if len(prefix) > DELETE_OBJECTS_BATCH_SIZE {
+		_, err := c.Bulk_Delete_Objects(bucketname, prefix, &BulkDeleteOptions{Retries: -1})
+		return err
+	}
+	failed, err := c.Delete_Objects_Result(bucketname, prefix)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return Model.NewFDSError(fmt.Sprintf("failed to delete %d objects, first %s: %s",
+			len(failed), failed[0].ObjectName, failed[0].ErrorDescription), failed[0].ErrorCode)
+	}
+	return nil
+}
+
+//name:
+//     Delete_Objects_Result
+//description:
+//     批量删除object，返回每个删除失败的object。一次请求的object数量不能超过DELETE_OBJECTS_BATCH_SIZE，
+//     更多的object使用Bulk_Delete_Objects
+//param:
+//     bucketname： object所在的bucket
+//     prefix： 要删除的object名字
+//return:
+//     []Model.DeleteObjectError: 删除失败的object，全部成功时为空
+//     error: 请求失败时返回error Code
+//example:
+//     Not available now
+func (c *FDSClient) Delete_Objects_Result(bucketname string, prefix []string) ([]Model.DeleteObjectError, error) {
+	if len(prefix) > DELETE_OBJECTS_BATCH_SIZE {
+		return nil, Model.NewFDSError(fmt.Sprintf("too many objects in one request: %d > %d",
+			len(prefix), DELETE_OBJECTS_BATCH_SIZE), -1)
+	}
 	url := c.GetUploadURL() + bucketname
 	prefixJson, err := json.Marshal(prefix)
 	if err != nil {
-		return Model.NewFDSError(err.Error(), -1)
+		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 	auth := FDSAuth{
 		UrlBase:     url,
@@ -1632,23 +2227,23 @@ func (c *FDSClient) Delete_Objects(bucketname string, prefix []string) error {
 	}
 	res, err := c.Auth(auth)
 	if err != nil {
-		return Model.NewFDSError(err.Error(), -1)
+		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 	body, err := ioutil.ReadAll(res.Body)
 	res.Body.Close()
 	if err != nil {
-		return Model.NewFDSError(err.Error(), -1)
+		return nil, Model.NewFDSError(err.Error(), -1)
 	}
 	if res.StatusCode != 200 {
-		return Model.NewFDSError(string(body), res.StatusCode)
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
 	}
-	return nil
+	return Model.NewDeleteObjectErrors(body)
 }
 
 //name:
 //     Restore_Object
 //description:
-//     恢复指定删除的object
+//     恢复指定删除的object，不做冲突检测，原位置已有object时会被覆盖，需要冲突检测时使用Restore_Trash_Objects
 //param:
 //     bucketname： 被删除object原来所在的bucket
 //     objectname： 被删除的object名字
@@ -1656,7 +2251,6 @@ func (c *FDSClient) Delete_Objects(bucketname string, prefix []string) error {
 //     error: 正常返回nil，异常返回error Code
 //example:
 //     Not available now
-// TODO 防止restore时替换掉原来的Object的冲突检测
 func (c *FDSClient) Restore_Object(bucketname, objectname string) error {
 	url := c.GetBaseUri() + bucketname + "/" + objectname
 
@@ -1680,38 +2274,76 @@ func (c *FDSClient) Restore_Object(bucketname, objectname string) error {
 		return Model.NewFDSError(err.Error(), -1)
 	}
 	if res.StatusCode != 200 {
-		return Model.NewFDSError(string(body), -1)
+		return Model.NewFDSError(string(body), res.StatusCode)
 	}
 	return nil
 }
 
+//name:
+//     Purge_Trash_Object
+//description:
+//     从回收站中永久删除指定的object，删除后无法再恢复
+//param:
+//     bucketname： 被删除object原来所在的bucket
+//     objectname： 被删除的object名字
+//return:
+//     error: 正常返回nil，异常返回error Code
+//example:
+//     Not available now
+func (c *FDSClient) Purge_Trash_Object(bucketname, objectname string) error {
+	if !checkNotEmpty(bucketname) || !checkNotEmpty(objectname) {
+		return errors.New("empty argument")
+	}
+	url := c.GetBaseUri() + TRASH_BUCKET + DELIMITER + bucketname + DELIMITER + objectname
+
+	auth := FDSAuth{
+		UrlBase:     url,
+		Method:      "DELETE",
+		Content_Md5: "",
+		Headers:     nil,
+	}
+
+	res, err := c.Auth(auth)
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode != 200 {
+		return Model.NewFDSError(string(body), res.StatusCode)
+	}
+	return nil
+}
+
+// Delete_Objects_With_Prefix 边分页列出边删除prefix下的object，每页删除一次，不会一次加载所有object名字
 func (c *FDSClient) Delete_Objects_With_Prefix(bucketname, prefix string) error {
 	listObjectResult, err := c.List_Object(bucketname, prefix, "", DEFAULT_LIST_MAX_KEYS)
 	if err != nil {
-		return Model.NewFDSError(err.Error(), -1)
+		return err
 	}
 
-	for true {
+	for {
 		prefixArray := []string{}
 		for _, k := range listObjectResult.ObjectSummaries {
 			prefixArray = append(prefixArray, k.ObjectName)
 		}
-
-		err = c.Delete_Objects(bucketname, prefixArray)
-		if err != nil {
-			return Model.NewFDSError(err.Error(), -1)
+		if len(prefixArray) > 0 {
+			if err = c.Delete_Objects(bucketname, prefixArray); err != nil {
+				return err
+			}
 		}
 
 		if !listObjectResult.Truncated {
-			break
+			return nil
 		}
 		listObjectResult, err = c.List_Next_Batch_Of_Objects(listObjectResult)
 		if err != nil {
-			return Model.NewFDSError(err.Error(), -1)
+			return err
 		}
 	}
-
-	return nil
 }
 
 //name:
@@ -0,0 +1,158 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"crypto/hmac"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VerifyAuthorizationHeader校验date header时允许的最大时钟误差
+const VERIFY_MAX_CLOCK_SKEW = 15 * time.Minute
+
+// VerifyReason 签名校验失败的原因
+type VerifyReason string
+
+const (
+	VERIFY_MALFORMED          VerifyReason = "malformed"
+	VERIFY_MISSING_SIGNATURE  VerifyReason = "missing signature"
+	VERIFY_UNKNOWN_ACCESS_KEY VerifyReason = "unknown access key"
+	VERIFY_EXPIRED            VerifyReason = "expired"
+	VERIFY_CLOCK_SKEW         VerifyReason = "clock skew"
+	VERIFY_SIGNATURE_MISMATCH VerifyReason = "signature mismatch"
+)
+
+// VerifyError 签名校验失败时返回的错误
+type VerifyError struct {
+	Reason      VerifyReason
+	AccessKeyId string
+	Message     string
+}
+
+func (e *VerifyError) Error() string {
+	if len(e.Message) == 0 {
+		return "fds: " + string(e.Reason)
+	}
+	return "fds: " + string(e.Reason) + ": " + e.Message
+}
+
+// StatusCode 返回与FDS一致的http状态码，格式错误为400，其它为403
+func (e *VerifyError) StatusCode() int {
+	if e.Reason == VERIFY_MALFORMED {
+		return http.StatusBadRequest
+	}
+	return http.StatusForbidden
+}
+
+// SecretLookup 根据access key id返回对应的secret，不存在时返回false
+type SecretLookup func(accessKeyId string) (string, bool)
+
+func verifyFailed(reason VerifyReason, accessKeyId, message string) error {
+	return &VerifyError{Reason: reason, AccessKeyId: accessKeyId, Message: message}
+}
+
+// rawQueryParam 返回query中参数的原始值。Generate_Presigned_URI生成的Signature没有转义，
+// 其中的+不能按照query规则解码为空格
+func rawQueryParam(rawQuery, name string) (string, bool) {
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if !strings.HasPrefix(kv, name+"=") {
+			continue
+		}
+		v, err := url.PathUnescape(kv[len(name)+1:])
+		if err != nil {
+			return "", false
+		}
+		return v, true
+	}
+	return "", false
+}
+
+func verifySignature(secretLookup SecretLookup, accessKeyId, signature, method, u string,
+	headers map[string][]string) error {
+	secret, ok := secretLookup(accessKeyId)
+	if !ok {
+		return verifyFailed(VERIFY_UNKNOWN_ACCESS_KEY, accessKeyId, "")
+	}
+	expected, err := Signature(secret, method, u, headers)
+	if err != nil {
+		return verifyFailed(VERIFY_MALFORMED, accessKeyId, err.Error())
+	}
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return verifyFailed(VERIFY_SIGNATURE_MISMATCH, accessKeyId, "")
+	}
+	return nil
+}
+
+// VerifyPresignedURL 校验预签名url，使用与Signature相同的规则重新计算签名，
+// 并检查GalaxyAccessKeyId和Expires，now晚于Expires即过期，不允许时钟误差。
+// headers为请求中的header，预签名时绑定的content-type、content-md5和x-xiaomi-*头会参与校验。
+// 成功时返回access key id，失败时返回*VerifyError
+func VerifyPresignedURL(secretLookup SecretLookup, method, u string, headers map[string][]string,
+	now time.Time) (string, error) {
+	urlParsed, err := url.Parse(u)
+	if err != nil {
+		return "", verifyFailed(VERIFY_MALFORMED, "", err.Error())
+	}
+	query := urlParsed.Query()
+	accessKeyId := query.Get(GALAXY_ACCESS_KEY_ID)
+	signature, ok := rawQueryParam(urlParsed.RawQuery, SIGNATURE)
+	if len(accessKeyId) == 0 || !ok || len(signature) == 0 {
+		return accessKeyId, verifyFailed(VERIFY_MISSING_SIGNATURE, accessKeyId, "")
+	}
+	expires, err := strconv.ParseInt(query.Get(EXPIRES), 10, 64)
+	if err != nil {
+		return accessKeyId, verifyFailed(VERIFY_MALFORMED, accessKeyId, "invalid "+EXPIRES)
+	}
+	expiration := time.Unix(0, expires*int64(time.Millisecond))
+	if now.After(expiration) {
+		return accessKeyId, verifyFailed(VERIFY_EXPIRED, accessKeyId, "expired at "+expiration.Format(time.RFC3339))
+	}
+	err = verifySignature(secretLookup, accessKeyId, signature, strings.ToUpper(method), u, headers)
+	if err != nil {
+		return accessKeyId, err
+	}
+	return accessKeyId, nil
+}
+
+// VerifyAuthorizationHeader 校验authorization: Galaxy-V2 header签名的请求，
+// date header与now的误差不能超过VERIFY_MAX_CLOCK_SKEW。成功时返回access key id，失败时返回*VerifyError
+func VerifyAuthorizationHeader(secretLookup SecretLookup, method, u string, headers map[string][]string,
+	now time.Time) (string, error) {
+	authorization := getStrFromHeader(headers, "authorization")
+	if len(authorization) == 0 {
+		return "", verifyFailed(VERIFY_MISSING_SIGNATURE, "", "")
+	}
+	credential := strings.TrimPrefix(authorization, "Galaxy-V2 ")
+	sep := strings.LastIndex(credential, ":")
+	if credential == authorization || sep <= 0 {
+		return "", verifyFailed(VERIFY_MALFORMED, "", "invalid authorization header")
+	}
+	accessKeyId, signature := credential[:sep], credential[sep+1:]
+
+	date := getStrFromHeader(headers, "date")
+	t, err := http.ParseTime(date)
+	if err != nil {
+		t, err = time.Parse(time.RFC1123, date)
+	}
+	if err != nil {
+		return accessKeyId, verifyFailed(VERIFY_MALFORMED, accessKeyId, "invalid date header")
+	}
+	if skew := now.Sub(t); skew > VERIFY_MAX_CLOCK_SKEW || skew < -VERIFY_MAX_CLOCK_SKEW {
+		return accessKeyId, verifyFailed(VERIFY_CLOCK_SKEW, accessKeyId, "request date "+date)
+	}
+	err = verifySignature(secretLookup, accessKeyId, signature, strings.ToUpper(method), u, headers)
+	if err != nil {
+		return accessKeyId, err
+	}
+	return accessKeyId, nil
+}
+
+// VerifyRequest 校验服务端收到的请求，有authorization header时按Galaxy-V2校验，否则按预签名url校验
+func VerifyRequest(secretLookup SecretLookup, r *http.Request, now time.Time) (string, error) {
+	if len(r.Header.Get("authorization")) > 0 {
+		return VerifyAuthorizationHeader(secretLookup, r.Method, r.URL.RequestURI(), r.Header, now)
+	}
+	return VerifyPresignedURL(secretLookup, r.Method, r.URL.RequestURI(), r.Header, now)
+}
@@ -0,0 +1,201 @@
+package galaxy_fds_sdk_golang
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/qkzsky/galaxy-fds-sdk-golang/Model"
+)
+
+// versionParams 返回访问指定版本时的请求参数，versionId为空时访问当前版本
+func versionParams(versionId string) *map[string]string {
+	if len(versionId) == 0 {
+		return nil
+	}
+	return &map[string]string{"versionId": versionId}
+}
+
+func checkVersionId(versionId string) error {
+	if len(versionId) == 0 {
+		return Model.NewFDSError("empty version id", -1)
+	}
+	return nil
+}
+
+// Get_Bucket_Versioning 返回bucket的多版本状态，Model.VERSIONING_ENABLED或Model.VERSIONING_SUSPENDED，从未开启时为空
+func (c *FDSClient) Get_Bucket_Versioning(bucketname string) (string, error) {
+	auth := FDSAuth{
+		UrlBase: c.GetUploadURL() + bucketname + "?versioning",
+		Method:  "GET",
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return "", Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return "", Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode != 200 {
+		return "", Model.NewFDSError(string(body), res.StatusCode)
+	}
+	var versioning Model.BucketVersioning
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &versioning); err != nil {
+			return "", Model.NewFDSError(err.Error(), -1)
+		}
+	}
+	return versioning.Status, nil
+}
+
+// Set_Bucket_Versioning 开启(Model.VERSIONING_ENABLED)或暂停(Model.VERSIONING_SUSPENDED)bucket的多版本，
+// 开启后Put_Object和Delete_Object不会覆盖或删除之前的版本
+func (c *FDSClient) Set_Bucket_Versioning(bucketname, status string) (bool, error) {
+	if status != Model.VERSIONING_ENABLED && status != Model.VERSIONING_SUSPENDED {
+		return false, Model.NewFDSError("invalid versioning status: "+status, -1)
+	}
+	data, _ := json.Marshal(Model.BucketVersioning{Status: status})
+	auth := FDSAuth{
+		UrlBase: c.GetUploadURL() + bucketname + "?versioning",
+		Method:  "PUT",
+		Data:    data,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return false, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode != 200 {
+		return false, Model.NewFDSError(string(body), res.StatusCode)
+	}
+	return true, nil
+}
+
+func (c *FDSClient) listObjectVersions(bucketname string, params map[string]string) (*Model.FDSObjectVersionListing, error) {
+	params["versions"] = ""
+	auth := FDSAuth{
+		UrlBase: c.GetBaseUri() + bucketname,
+		Method:  "GET",
+		Params:  &params,
+	}
+	res, err := c.Auth(auth)
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, Model.NewFDSError(err.Error(), -1)
+	}
+	if res.StatusCode != 200 {
+		return nil, Model.NewFDSError(string(body), res.StatusCode)
+	}
+	return Model.NewFDSObjectVersionListing(body)
+}
+
+// List_Object_Versions 列出prefix下object的所有版本(包括删除标记)，同一个object的版本按时间从新到旧排列，
+// Truncated为true时使用List_Next_Batch_Of_Object_Versions获取下一页
+func (c *FDSClient) List_Object_Versions(bucketname, prefix, delimiter string,
+	maxKeys int) (*Model.FDSObjectVersionListing, error) {
+	return c.listObjectVersions(bucketname, map[string]string{
+		"prefix":    prefix,
+		"delimiter": delimiter,
+		"maxKeys":   strconv.Itoa(maxKeys),
+	})
+}
+
+// List_Next_Batch_Of_Object_Versions 返回List_Object_Versions的下一页
+func (c *FDSClient) List_Next_Batch_Of_Object_Versions(
+	previous *Model.FDSObjectVersionListing) (*Model.FDSObjectVersionListing, error) {
+	if !previous.Truncated {
+		return nil, errors.New("No more versions")
+	}
+	return c.listObjectVersions(previous.BucketName, map[string]string{
+		"prefix":          previous.Prefix,
+		"delimiter":       previous.Delimiter,
+		"maxKeys":         strconv.Itoa(previous.MaxKeys),
+		"keyMarker":       previous.NextKeyMarker,
+		"versionIdMarker": previous.NextVersionIdMarker,
+	})
+}
+
+// Get_Object_Version 与Get_Object相同，获取object的指定版本
+func (c *FDSClient) Get_Object_Version(bucketname, objectname, versionId string, position int64,
+	size int64) (*Model.FDSObject, error) {
+	if err := checkVersionId(versionId); err != nil {
+		return nil, err
+	}
+	return c.getObject(bucketname, objectname, versionId, position, size)
+}
+
+// Get_Object_Version_Reader 与Get_Object_Reader相同，读取object的指定版本
+func (c *FDSClient) Get_Object_Version_Reader(bucketname, objectname, versionId string, position int64,
+	size int64) (*io.ReadCloser, error) {
+	if err := checkVersionId(versionId); err != nil {
+		return nil, err
+	}
+	return c.getObjectReader(bucketname, objectname, versionId, position, size)
+}
+
+// Get_Object_Version_Meta 与Get_Object_Meta相同，获取object指定版本的metadata
+func (c *FDSClient) Get_Object_Version_Meta(bucketname, objectname, versionId string) (*Model.FDSMetaData, error) {
+	if err := checkVersionId(versionId); err != nil {
+		return nil, err
+	}
+	return c.getObjectMeta(bucketname, objectname, versionId, nil)
+}
+
+// Delete_Object_Version 永久删除object的指定版本。Delete_Object在开启多版本的bucket中只会添加删除标记，
+// 删除删除标记的版本可以恢复object
+func (c *FDSClient) Delete_Object_Version(bucketname, objectname, versionId string) (bool, error) {
+	if err := checkVersionId(versionId); err != nil {
+		return false, err
+	}
+	return c.deleteObject(bucketname, objectname, versionId)
+}
+
+// Restore_Object_Version 将object的指定版本复制为当前版本，之前的当前版本会保留为历史版本。
+// 优先使用服务端复制，服务端不支持复制时下载该版本后重新上传，保留content-type和用户metadata
+func (c *FDSClient) Restore_Object_Version(bucketname, objectname, versionId string) (*Model.PutObjectResult, error) {
+	if err := checkVersionId(versionId); err != nil {
+		return nil, err
+	}
+	result, err := c.copyObject(bucketname, objectname, versionId, bucketname, objectname, nil, nil)
+	if !IsCopyUnsupported(err) {
+		return result, err
+	}
+
+	meta, err := c.getObjectMeta(bucketname, objectname, versionId, nil)
+	if err != nil {
+		return nil, err
+	}
+	contentType, headers := uploadHeaders(meta)
+	size, err := meta.GetMetadataContentLength()
+	if err != nil {
+		if size, err = meta.GetContentLength(); err != nil {
+			return nil, Model.NewFDSError("unknown size of version "+versionId, -1)
+		}
+	}
+	var content io.Reader = bytes.NewReader(nil)
+	if size > 0 {
+		reader, _, err := c.getObjectResponse(bucketname, objectname, versionId, 0, -1, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		content = reader
+	}
+	if err := c.putReader(bucketname, objectname, content, size, contentType, headers); err != nil {
+		return nil, err
+	}
+	return &Model.PutObjectResult{BucketName: bucketname, ObjectName: objectname}, nil
+}
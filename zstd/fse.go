@@ -0,0 +1,285 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// loadBits 从b的第start位开始按小端顺序取n位，超出b的部分为0，n不超过56
+func loadBits(b []byte, start, n int) uint64 {
+	i := start >> 3
+	var x uint64
+	if i+8 <= len(b) {
+		x = binary.LittleEndian.Uint64(b[i:])
+	} else {
+		for j := len(b) - 1; j >= i; j-- {
+			x = x<<8 | uint64(b[j])
+		}
+	}
+	return (x >> uint(start&7)) & (1<<uint(n) - 1)
+}
+
+// forwardReader 按小端顺序从前往后读取，用于FSE表的描述
+type forwardReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *forwardReader) peek(n int) int {
+	return int(loadBits(r.b, r.pos, n))
+}
+
+func (r *forwardReader) read(n int) int {
+	v := r.peek(n)
+	r.pos += n
+	return v
+}
+
+// backwardReader 从最后一个字节的结束标记开始往前读取，FSE和Huffman编码的数据都是这样写入的。
+// 读到开头之前的位都是0，pos小于0表示读取越界
+type backwardReader struct {
+	b   []byte
+	pos int
+}
+
+func newBackwardReader(b []byte) (*backwardReader, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return nil, ErrCorrupted
+	}
+	return &backwardReader{b: b, pos: (len(b)-1)*8 + bits.Len8(b[len(b)-1]) - 1}, nil
+}
+
+func (r *backwardReader) peek(n int) uint64 {
+	start := r.pos - n
+	if start >= 0 {
+		return loadBits(r.b, start, n)
+	}
+	if r.pos <= 0 {
+		return 0
+	}
+	return loadBits(r.b, 0, r.pos) << uint(-start)
+}
+
+func (r *backwardReader) read(n int) uint64 {
+	v := r.peek(n)
+	r.pos -= n
+	return v
+}
+
+type fseEntry struct {
+	symbol uint8
+	bits   uint8
+	base   uint16
+}
+
+type fseTable struct {
+	entries  []fseEntry
+	tableLog int
+}
+
+// readDistribution 读取FSE表的描述，返回每个符号的归一化概率(-1表示小于1)、tableLog和使用的字节数
+func readDistribution(src []byte, maxSymbol, maxLog int) ([]int16, int, int, error) {
+	if len(src) == 0 {
+		return nil, 0, 0, ErrCorrupted
+	}
+	r := &forwardReader{b: src}
+	tableLog := r.read(4) + 5
+	if tableLog > maxLog {
+		return nil, 0, 0, ErrCorrupted
+	}
+	norm := make([]int16, 0, maxSymbol+1)
+	remaining := 1<<uint(tableLog) + 1
+	threshold := 1 << uint(tableLog)
+	nbBits := tableLog + 1
+	for remaining > 1 && len(norm) <= maxSymbol {
+		max := 2*threshold - 1 - remaining
+		var count int
+		if v := r.peek(nbBits - 1); v < max {
+			count = v
+			r.pos += nbBits - 1
+		} else {
+			count = r.read(nbBits)
+			if count >= threshold {
+				count -= max
+			}
+		}
+		count--
+		if count < 0 {
+			remaining += count
+		} else {
+			remaining -= count
+		}
+		norm = append(norm, int16(count))
+		if count == 0 {
+			// 之后是2位一组的重复次数，3表示还有下一组
+			for {
+				repeat := r.read(2)
+				for i := 0; i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+		for remaining < threshold {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || len(norm) > maxSymbol+1 || r.pos > len(src)*8 {
+		return nil, 0, 0, ErrCorrupted
+	}
+	return norm, tableLog, (r.pos + 7) / 8, nil
+}
+
+func newFSETable(norm []int16, tableLog int) (*fseTable, error) {
+	symbols, err := spreadSymbols(norm, tableLog)
+	if err != nil {
+		return nil, err
+	}
+	size := 1 << uint(tableLog)
+	next := make([]int, len(norm))
+	for s, c := range norm {
+		if c == -1 {
+			next[s] = 1
+		} else {
+			next[s] = int(c)
+		}
+	}
+	t := &fseTable{entries: make([]fseEntry, size), tableLog: tableLog}
+	for u, s := range symbols {
+		state := next[s]
+		next[s]++
+		nb := tableLog - highBit(uint32(state))
+		t.entries[u] = fseEntry{symbol: s, bits: uint8(nb), base: uint16(state<<uint(nb) - size)}
+	}
+	return t, nil
+}
+
+// rleTable 只有一个符号的表，不消耗任何位
+func rleTable(symbol uint8) *fseTable {
+	return &fseTable{entries: []fseEntry{{symbol: symbol}}}
+}
+
+func mustFSETable(norm []int16, tableLog int) *fseTable {
+	t, err := newFSETable(norm, tableLog)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var (
+	predefinedLiteralsLengthTable = mustFSETable(predefinedLiteralsLength, predefinedLiteralsLengthLog)
+	predefinedMatchLengthTable    = mustFSETable(predefinedMatchLength, predefinedMatchLengthLog)
+	predefinedOffsetTable         = mustFSETable(predefinedOffset, predefinedOffsetLog)
+)
+
+// fseEncoder 与fseTable对应的编码表，state取值范围为[1<<tableLog, 2<<tableLog)
+type fseEncoder struct {
+	tableLog   int
+	stateTable []uint16
+	symbols    []fseSymbolTransform
+}
+
+type fseSymbolTransform struct {
+	deltaBits      uint32
+	deltaFindState int32
+}
+
+func newFSEEncoder(norm []int16, tableLog int) (*fseEncoder, error) {
+	symbols, err := spreadSymbols(norm, tableLog)
+	if err != nil {
+		return nil, err
+	}
+	size := 1 << uint(tableLog)
+	cumul := make([]int, len(norm)+1)
+	for s, c := range norm {
+		if c == -1 {
+			c = 1
+		}
+		cumul[s+1] = cumul[s] + int(c)
+	}
+	e := &fseEncoder{tableLog: tableLog, stateTable: make([]uint16, size),
+		symbols: make([]fseSymbolTransform, len(norm))}
+	for u, s := range symbols {
+		e.stateTable[cumul[s]] = uint16(size + u)
+		cumul[s]++
+	}
+	total := 0
+	for s, c := range norm {
+		switch c {
+		case 0:
+			e.symbols[s].deltaBits = uint32((tableLog+1)<<16 - size)
+		case -1, 1:
+			e.symbols[s] = fseSymbolTransform{uint32(tableLog<<16 - size), int32(total - 1)}
+			total++
+		default:
+			maxBitsOut := tableLog - highBit(uint32(c-1))
+			minStatePlus := int(c) << uint(maxBitsOut)
+			e.symbols[s] = fseSymbolTransform{uint32(maxBitsOut<<16 - minStatePlus), int32(total - int(c))}
+			total += int(c)
+		}
+	}
+	return e, nil
+}
+
+func mustFSEEncoder(norm []int16, tableLog int) *fseEncoder {
+	e, err := newFSEEncoder(norm, tableLog)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+var (
+	literalsLengthEncoder = mustFSEEncoder(predefinedLiteralsLength, predefinedLiteralsLengthLog)
+	matchLengthEncoder    = mustFSEEncoder(predefinedMatchLength, predefinedMatchLengthLog)
+	offsetEncoder         = mustFSEEncoder(predefinedOffset, predefinedOffsetLog)
+)
+
+// init 返回编码最后一个符号时的初始状态，不输出任何位
+func (e *fseEncoder) init(symbol uint8) uint32 {
+	tt := e.symbols[symbol]
+	nbBitsOut := (tt.deltaBits + 1<<15) >> 16
+	value := nbBitsOut<<16 - tt.deltaBits
+	return uint32(e.stateTable[int32(value>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) encode(w *bitWriter, state uint32, symbol uint8) uint32 {
+	tt := e.symbols[symbol]
+	nbBitsOut := (state + tt.deltaBits) >> 16
+	w.add(uint64(state), uint(nbBitsOut))
+	return uint32(e.stateTable[int32(state>>nbBitsOut)+tt.deltaFindState])
+}
+
+func (e *fseEncoder) flush(w *bitWriter, state uint32) {
+	w.add(uint64(state), uint(e.tableLog))
+}
+
+// bitWriter 按小端顺序写入，读取时从结尾往前读
+type bitWriter struct {
+	out []byte
+	acc uint64
+	n   uint
+}
+
+func (w *bitWriter) add(v uint64, n uint) {
+	w.acc |= (v & (1<<n - 1)) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.out = append(w.out, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+// close 写入结束标记并补齐最后一个字节
+func (w *bitWriter) close() []byte {
+	w.add(1, 1)
+	if w.n > 0 {
+		w.out = append(w.out, byte(w.acc))
+	}
+	return w.out
+}
@@ -0,0 +1,278 @@
+package zstd
+
+import "sort"
+
+type huffmanEntry struct {
+	symbol uint8
+	bits   uint8
+}
+
+// huffmanTable 以接下来的maxBits位为下标查找符号
+type huffmanTable struct {
+	entries []huffmanEntry
+	maxBits int
+}
+
+// readHuffmanTable 读取字面量的Huffman树描述，返回使用的字节数
+func readHuffmanTable(src []byte) (*huffmanTable, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrCorrupted
+	}
+	header := int(src[0])
+	var weights []uint8
+	var used int
+	if header < 128 {
+		// 权重使用FSE压缩，两个状态交替解码，共享同一个bitstream
+		used = 1 + header
+		if len(src) < used {
+			return nil, 0, ErrCorrupted
+		}
+		data := src[1:used]
+		norm, tableLog, n, err := readDistribution(data, 255, 6)
+		if err != nil {
+			return nil, 0, err
+		}
+		table, err := newFSETable(norm, tableLog)
+		if err != nil {
+			return nil, 0, err
+		}
+		r, err := newBackwardReader(data[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		states := [2]uint64{r.read(tableLog), r.read(tableLog)}
+		for i := 0; ; i ^= 1 {
+			if len(weights) >= 255 {
+				return nil, 0, ErrCorrupted
+			}
+			e := table.entries[states[i]]
+			weights = append(weights, e.symbol)
+			states[i] = uint64(e.base) + r.read(int(e.bits))
+			if r.pos < 0 {
+				weights = append(weights, table.entries[states[i^1]].symbol)
+				break
+			}
+		}
+	} else {
+		count := header - 127
+		used = 1 + (count+1)/2
+		if len(src) < used {
+			return nil, 0, ErrCorrupted
+		}
+		for i := 0; i < count; i++ {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				weights = append(weights, b>>4)
+			} else {
+				weights = append(weights, b&15)
+			}
+		}
+	}
+	t, err := newHuffmanTable(weights)
+	return t, used, err
+}
+
+// newHuffmanTable 最后一个符号的权重没有记录，由其它权重补齐到2的幂得到
+func newHuffmanTable(weights []uint8) (*huffmanTable, error) {
+	if len(weights) == 0 || len(weights) > 255 {
+		return nil, ErrCorrupted
+	}
+	var total uint32
+	for _, w := range weights {
+		if w > maxHuffmanBits {
+			return nil, ErrCorrupted
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return nil, ErrCorrupted
+	}
+	maxBits := highBit(total) + 1
+	rest := uint32(1)<<uint(maxBits) - total
+	if maxBits > maxHuffmanBits || rest&(rest-1) != 0 {
+		return nil, ErrCorrupted
+	}
+	weights = append(weights, uint8(highBit(rest)+1))
+
+	t := &huffmanTable{entries: make([]huffmanEntry, 1<<uint(maxBits)), maxBits: maxBits}
+	position := 0
+	for w := 1; w <= maxBits; w++ {
+		for s, sw := range weights {
+			if int(sw) != w {
+				continue
+			}
+			e := huffmanEntry{symbol: uint8(s), bits: uint8(maxBits + 1 - w)}
+			for i := 0; i < 1<<uint(w-1); i++ {
+				t.entries[position] = e
+				position++
+			}
+		}
+	}
+	return t, nil
+}
+
+// decode 解码一个stream，数据必须正好用完
+func (t *huffmanTable) decode(dst, src []byte) error {
+	r, err := newBackwardReader(src)
+	if err != nil {
+		return err
+	}
+	for i := range dst {
+		e := t.entries[r.peek(t.maxBits)]
+		r.pos -= int(e.bits)
+		dst[i] = e.symbol
+	}
+	if r.pos != 0 {
+		return ErrCorrupted
+	}
+	return nil
+}
+
+// huffmanEncoder 字面量的Huffman编码。权重使用直接表示，最多128个权重，所以只用于最大符号不超过128的字面量，
+// 文本和JSON日志基本都满足
+type huffmanEncoder struct {
+	codes   [129]uint16
+	bits    [129]uint8
+	weights []uint8
+}
+
+const minHuffmanLiterals = 64
+
+// newHuffmanEncoder 字面量太少、只有一种符号或者有大于128的符号时返回nil
+func newHuffmanEncoder(literals []byte) *huffmanEncoder {
+	if len(literals) < minHuffmanLiterals {
+		return nil
+	}
+	var freq [256]int
+	for _, c := range literals {
+		freq[c]++
+	}
+	maxSymbol, distinct := 0, 0
+	for s, f := range freq {
+		if f > 0 {
+			maxSymbol = s
+			distinct++
+		}
+	}
+	if maxSymbol > 128 || distinct < 2 {
+		return nil
+	}
+
+	lengths := limitedHuffmanLengths(freq[:maxSymbol+1], maxHuffmanBits)
+	maxBits := 0
+	for _, l := range lengths {
+		if int(l) > maxBits {
+			maxBits = int(l)
+		}
+	}
+	h := &huffmanEncoder{weights: make([]uint8, maxSymbol+1)}
+	for s, l := range lengths {
+		if l > 0 {
+			h.weights[s] = uint8(maxBits + 1 - int(l))
+			h.bits[s] = l
+		}
+	}
+	// 与newHuffmanTable相同的顺序分配编码
+	position := 0
+	for w := 1; w <= maxBits; w++ {
+		for s, sw := range h.weights {
+			if int(sw) == w {
+				h.codes[s] = uint16(position >> uint(w-1))
+				position += 1 << uint(w-1)
+			}
+		}
+	}
+	return h
+}
+
+// limitedHuffmanLengths 返回每个符号的编码长度，超过limit时将频率减半后重新计算
+func limitedHuffmanLengths(freq []int, limit int) []uint8 {
+	freq = append([]int{}, freq...)
+	for {
+		lengths := huffmanLengths(freq)
+		fits := true
+		for _, l := range lengths {
+			if int(l) > limit {
+				fits = false
+			}
+		}
+		if fits {
+			return lengths
+		}
+		for i, f := range freq {
+			if f > 0 {
+				freq[i] = (f + 1) / 2
+			}
+		}
+	}
+}
+
+type huffmanNode struct {
+	freq        int
+	left, right int
+}
+
+func huffmanLengths(freq []int) []uint8 {
+	var leaves []int
+	for s, f := range freq {
+		if f > 0 {
+			leaves = append(leaves, s)
+		}
+	}
+	sort.Slice(leaves, func(i, j int) bool { return freq[leaves[i]] < freq[leaves[j]] })
+	nodes := make([]huffmanNode, len(leaves), 2*len(leaves))
+	for i, s := range leaves {
+		nodes[i] = huffmanNode{freq: freq[s], left: -1, right: -1}
+	}
+	// 叶子和合并出的节点都按频率递增，每次从两个队列的头部取最小的
+	leaf, merged := 0, len(leaves)
+	pick := func() int {
+		if leaf < len(leaves) && (merged >= len(nodes) || nodes[leaf].freq <= nodes[merged].freq) {
+			leaf++
+			return leaf - 1
+		}
+		merged++
+		return merged - 1
+	}
+	for n := 1; n < len(leaves); n++ {
+		a := pick()
+		b := pick()
+		nodes = append(nodes, huffmanNode{freq: nodes[a].freq + nodes[b].freq, left: a, right: b})
+	}
+	depth := make([]uint8, len(nodes))
+	for i := len(nodes) - 1; i >= len(leaves); i-- {
+		depth[nodes[i].left] = depth[i] + 1
+		depth[nodes[i].right] = depth[i] + 1
+	}
+	lengths := make([]uint8, len(freq))
+	for i, s := range leaves {
+		lengths[s] = depth[i]
+	}
+	return lengths
+}
+
+// appendTable 写入直接表示的权重，最后一个符号的权重不写入
+func (h *huffmanEncoder) appendTable(out []byte) []byte {
+	count := len(h.weights) - 1
+	out = append(out, byte(127+count))
+	for i := 0; i < count; i += 2 {
+		b := h.weights[i] << 4
+		if i+1 < count {
+			b |= h.weights[i+1]
+		}
+		out = append(out, b)
+	}
+	return out
+}
+
+// appendStream 从最后一个字面量开始写入，解码时从结尾往前读取正好是原来的顺序
+func (h *huffmanEncoder) appendStream(out []byte, literals []byte) []byte {
+	w := &bitWriter{out: out}
+	for i := len(literals) - 1; i >= 0; i-- {
+		c := literals[i]
+		w.add(uint64(h.codes[c]), uint(h.bits[c]))
+	}
+	return w.close()
+}
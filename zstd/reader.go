@@ -0,0 +1,565 @@
+package zstd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+)
+
+// Reader 解压zstd数据，支持多个连续的frame和skippable frame
+type Reader struct {
+	r   *bufio.Reader
+	err error
+
+	inFrame     bool
+	lastBlock   bool
+	checksum    bool
+	contentSize int64 // frame中记录的原始长度，-1表示没有记录
+	produced    int64
+	windowSize  int
+	blockMax    int
+	hash        *xxhash64
+
+	// history 保存窗口内已解压的内容，out是其中还没有返回给调用方的部分
+	history []byte
+	out     []byte
+
+	block    []byte
+	literals []byte
+	repeat   [3]int
+	huffman  *huffmanTable
+	tables   [3]*fseTable
+}
+
+// NewReader 读取第一个frame的头部，数据不是zstd格式时返回错误
+func NewReader(r io.Reader) (*Reader, error) {
+	z := &Reader{r: bufio.NewReader(r), hash: newXXHash64()}
+	ok, err := z.nextFrame()
+	if err == nil && !ok {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (z *Reader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.advance()
+	}
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// Close 不关闭底层的reader
+func (z *Reader) Close() error {
+	return nil
+}
+
+// advance 解压下一个block，frame结束时校验长度和checksum并读取下一个frame
+func (z *Reader) advance() error {
+	if !z.inFrame {
+		ok, err := z.nextFrame()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return io.EOF
+		}
+	}
+	if !z.lastBlock {
+		return z.readBlock()
+	}
+	z.inFrame = false
+	if z.contentSize >= 0 && z.produced != z.contentSize {
+		return ErrCorrupted
+	}
+	if z.checksum {
+		var sum [4]byte
+		if _, err := io.ReadFull(z.r, sum[:]); err != nil {
+			return unexpected(err)
+		}
+		if binary.LittleEndian.Uint32(sum[:]) != uint32(z.hash.Sum64()) {
+			return ErrChecksum
+		}
+	}
+	return nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// nextFrame 跳过skippable frame并读取下一个frame的头部，没有更多数据时返回false
+func (z *Reader) nextFrame() (bool, error) {
+	for {
+		var magic [4]byte
+		n, err := io.ReadFull(z.r, magic[:])
+		if n == 0 && err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, unexpected(err)
+		}
+		m := binary.LittleEndian.Uint32(magic[:])
+		if m&skippableMagicMask == skippableMagic {
+			if _, err := io.ReadFull(z.r, magic[:]); err != nil {
+				return false, unexpected(err)
+			}
+			size := int64(binary.LittleEndian.Uint32(magic[:]))
+			if _, err := io.CopyN(io.Discard, z.r, size); err != nil {
+				return false, unexpected(err)
+			}
+			continue
+		}
+		if m != frameMagic {
+			return false, ErrCorrupted
+		}
+		return true, z.readFrameHeader()
+	}
+}
+
+func (z *Reader) readFrameHeader() error {
+	descriptor, err := z.r.ReadByte()
+	if err != nil {
+		return unexpected(err)
+	}
+	if descriptor&0x08 != 0 {
+		return ErrCorrupted
+	}
+	singleSegment := descriptor&0x20 != 0
+	contentSizeBytes := [4]int{0, 2, 4, 8}[descriptor>>6]
+	if singleSegment && contentSizeBytes == 0 {
+		contentSizeBytes = 1
+	}
+	dictionaryBytes := [4]int{0, 1, 2, 4}[descriptor&3]
+	headerSize := contentSizeBytes + dictionaryBytes
+	if !singleSegment {
+		headerSize++
+	}
+	var header [14]byte
+	if _, err := io.ReadFull(z.r, header[:headerSize]); err != nil {
+		return unexpected(err)
+	}
+	b := header[:headerSize]
+
+	windowSize := uint64(0)
+	if !singleSegment {
+		exponent, mantissa := uint(b[0]>>3), uint64(b[0]&7)
+		base := uint64(1) << (10 + exponent)
+		windowSize = base + base/8*mantissa
+		b = b[1:]
+	}
+	var dictionary uint64
+	for i := dictionaryBytes - 1; i >= 0; i-- {
+		dictionary = dictionary<<8 | uint64(b[i])
+	}
+	if dictionary != 0 {
+		return ErrDictionary
+	}
+	b = b[dictionaryBytes:]
+	z.contentSize = -1
+	if contentSizeBytes > 0 {
+		var size uint64
+		for i := contentSizeBytes - 1; i >= 0; i-- {
+			size = size<<8 | uint64(b[i])
+		}
+		if contentSizeBytes == 2 {
+			size += 256
+		}
+		if size > 1<<62 {
+			return ErrCorrupted
+		}
+		z.contentSize = int64(size)
+		if singleSegment {
+			windowSize = size
+		}
+	}
+	if windowSize > MAX_WINDOW_SIZE {
+		return ErrWindowSize
+	}
+
+	z.inFrame = true
+	z.lastBlock = false
+	z.checksum = descriptor&0x04 != 0
+	z.produced = 0
+	z.windowSize = int(windowSize)
+	z.blockMax = maxBlockSize
+	if z.windowSize < z.blockMax {
+		z.blockMax = z.windowSize
+	}
+	z.hash.Reset()
+	z.history = z.history[:0]
+	z.repeat = [3]int{1, 4, 8}
+	z.huffman = nil
+	z.tables = [3]*fseTable{}
+	return nil
+}
+
+func (z *Reader) readBlock() error {
+	var header [3]byte
+	if _, err := io.ReadFull(z.r, header[:]); err != nil {
+		return unexpected(err)
+	}
+	h := uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16
+	z.lastBlock = h&1 != 0
+	size := int(h >> 3)
+	if size > z.blockMax {
+		return ErrCorrupted
+	}
+
+	// 只保留窗口内的内容，超过两倍窗口时才移动，避免每个block都复制整个窗口
+	if len(z.history) > 2*z.windowSize {
+		n := copy(z.history, z.history[len(z.history)-z.windowSize:])
+		z.history = z.history[:n]
+	}
+	start := len(z.history)
+
+	switch (h >> 1) & 3 {
+	case 0:
+		z.history = grow(z.history, size)
+		if _, err := io.ReadFull(z.r, z.history[start:]); err != nil {
+			return unexpected(err)
+		}
+	case 1:
+		c, err := z.r.ReadByte()
+		if err != nil {
+			return unexpected(err)
+		}
+		z.history = grow(z.history, size)
+		for i := start; i < len(z.history); i++ {
+			z.history[i] = c
+		}
+	case 2:
+		if cap(z.block) < size {
+			z.block = make([]byte, size)
+		}
+		z.block = z.block[:size]
+		if _, err := io.ReadFull(z.r, z.block); err != nil {
+			return unexpected(err)
+		}
+		if err := z.decompressBlock(z.block); err != nil {
+			return err
+		}
+	default:
+		return ErrCorrupted
+	}
+
+	z.out = z.history[start:]
+	z.produced += int64(len(z.out))
+	if z.contentSize >= 0 && z.produced > z.contentSize {
+		return ErrCorrupted
+	}
+	if z.checksum {
+		z.hash.Write(z.out)
+	}
+	return nil
+}
+
+func grow(b []byte, n int) []byte {
+	if len(b)+n <= cap(b) {
+		return b[:len(b)+n]
+	}
+	nb := make([]byte, len(b)+n, 2*len(b)+n)
+	copy(nb, b)
+	return nb
+}
+
+func (z *Reader) decompressBlock(src []byte) error {
+	literals, n, err := z.readLiterals(src)
+	if err != nil {
+		return err
+	}
+	return z.executeSequences(src[n:], literals)
+}
+
+// readLiterals 读取字面量部分，返回字面量和使用的字节数
+func (z *Reader) readLiterals(src []byte) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, ErrCorrupted
+	}
+	blockType, sizeFormat := src[0]&3, (src[0]>>2)&3
+	if blockType < 2 {
+		var size, header int
+		switch sizeFormat {
+		case 0, 2:
+			size, header = int(src[0]>>3), 1
+		case 1:
+			if len(src) < 2 {
+				return nil, 0, ErrCorrupted
+			}
+			size, header = int(src[0]>>4)|int(src[1])<<4, 2
+		case 3:
+			if len(src) < 3 {
+				return nil, 0, ErrCorrupted
+			}
+			size, header = int(src[0]>>4)|int(src[1])<<4|int(src[2])<<12, 3
+		}
+		if size > z.blockMax {
+			return nil, 0, ErrCorrupted
+		}
+		if blockType == 0 {
+			if len(src) < header+size {
+				return nil, 0, ErrCorrupted
+			}
+			return src[header : header+size], header + size, nil
+		}
+		if len(src) < header+1 {
+			return nil, 0, ErrCorrupted
+		}
+		literals := z.literalBuffer(size)
+		for i := range literals {
+			literals[i] = src[header]
+		}
+		return literals, header + 1, nil
+	}
+
+	streams, header, sizeBits := 4, 0, 0
+	switch sizeFormat {
+	case 0:
+		streams, header, sizeBits = 1, 3, 10
+	case 1:
+		header, sizeBits = 3, 10
+	case 2:
+		header, sizeBits = 4, 14
+	case 3:
+		header, sizeBits = 5, 18
+	}
+	if len(src) < header {
+		return nil, 0, ErrCorrupted
+	}
+	var h uint64
+	for i := header - 1; i >= 0; i-- {
+		h = h<<8 | uint64(src[i])
+	}
+	mask := uint64(1)<<uint(sizeBits) - 1
+	regenerated := int(h >> 4 & mask)
+	compressed := int(h >> uint(4+sizeBits) & mask)
+	if regenerated > z.blockMax || len(src) < header+compressed {
+		return nil, 0, ErrCorrupted
+	}
+	data := src[header : header+compressed]
+	if blockType == 2 {
+		table, n, err := readHuffmanTable(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		z.huffman = table
+		data = data[n:]
+	} else if z.huffman == nil {
+		return nil, 0, ErrCorrupted
+	}
+
+	literals := z.literalBuffer(regenerated)
+	if streams == 1 {
+		if err := z.huffman.decode(literals, data); err != nil {
+			return nil, 0, err
+		}
+		return literals, header + compressed, nil
+	}
+	if len(data) < 6 {
+		return nil, 0, ErrCorrupted
+	}
+	sizes := [4]int{int(binary.LittleEndian.Uint16(data)), int(binary.LittleEndian.Uint16(data[2:])),
+		int(binary.LittleEndian.Uint16(data[4:]))}
+	data = data[6:]
+	sizes[3] = len(data) - sizes[0] - sizes[1] - sizes[2]
+	segment := (regenerated + 3) / 4
+	if sizes[3] < 0 || regenerated < 3*segment {
+		return nil, 0, ErrCorrupted
+	}
+	for i, size := range sizes {
+		out := literals[i*segment:]
+		if i < 3 {
+			out = out[:segment]
+		}
+		if err := z.huffman.decode(out, data[:size]); err != nil {
+			return nil, 0, err
+		}
+		data = data[size:]
+	}
+	return literals, header + compressed, nil
+}
+
+func (z *Reader) literalBuffer(n int) []byte {
+	if cap(z.literals) < n {
+		z.literals = make([]byte, n)
+	}
+	return z.literals[:n]
+}
+
+// readTable 按照符号压缩模式读取一个FSE表，返回使用的字节数
+func (z *Reader) readTable(i int, mode byte, src []byte, predefined *fseTable, maxSymbol, maxLog int) (int, error) {
+	switch mode {
+	case 0:
+		z.tables[i] = predefined
+	case 1:
+		if len(src) == 0 || int(src[0]) > maxSymbol {
+			return 0, ErrCorrupted
+		}
+		z.tables[i] = rleTable(src[0])
+		return 1, nil
+	case 2:
+		norm, tableLog, n, err := readDistribution(src, maxSymbol, maxLog)
+		if err != nil {
+			return 0, err
+		}
+		table, err := newFSETable(norm, tableLog)
+		if err != nil {
+			return 0, err
+		}
+		z.tables[i] = table
+		return n, nil
+	case 3:
+		if z.tables[i] == nil {
+			return 0, ErrCorrupted
+		}
+	}
+	return 0, nil
+}
+
+// executeSequences 解码sequence并把字面量和匹配的内容追加到history
+func (z *Reader) executeSequences(src, literals []byte) error {
+	if len(src) == 0 {
+		return ErrCorrupted
+	}
+	count := int(src[0])
+	switch {
+	case count == 0:
+		src = src[1:]
+	case count < 128:
+		src = src[1:]
+	case count < 255:
+		if len(src) < 2 {
+			return ErrCorrupted
+		}
+		count = (count-128)<<8 | int(src[1])
+		src = src[2:]
+	default:
+		if len(src) < 3 {
+			return ErrCorrupted
+		}
+		count = int(src[1]) | int(src[2])<<8 + 0x7F00
+		src = src[3:]
+	}
+	blockStart := len(z.history)
+	if count == 0 {
+		if len(src) != 0 || len(literals) > z.blockMax {
+			return ErrCorrupted
+		}
+		z.history = append(z.history, literals...)
+		return nil
+	}
+
+	if len(src) == 0 {
+		return ErrCorrupted
+	}
+	modes := src[0]
+	if modes&3 != 0 {
+		return ErrCorrupted
+	}
+	src = src[1:]
+	for i, t := range []struct {
+		predefined       *fseTable
+		maxSymbol, limit int
+	}{
+		{predefinedLiteralsLengthTable, maxLiteralsLength, maxSequenceTableLog},
+		{predefinedOffsetTable, maxOffsetCode, maxOffsetTableLog},
+		{predefinedMatchLengthTable, maxMatchLength, maxSequenceTableLog},
+	} {
+		n, err := z.readTable(i, modes>>uint(6-2*i)&3, src, t.predefined, t.maxSymbol, t.limit)
+		if err != nil {
+			return err
+		}
+		src = src[n:]
+	}
+	ll, of, ml := z.tables[0], z.tables[1], z.tables[2]
+
+	r, err := newBackwardReader(src)
+	if err != nil {
+		return err
+	}
+	llState := r.read(ll.tableLog)
+	ofState := r.read(of.tableLog)
+	mlState := r.read(ml.tableLog)
+	for i := 0; i < count; i++ {
+		llCode, ofCode, mlCode := ll.entries[llState].symbol, of.entries[ofState].symbol, ml.entries[mlState].symbol
+		if int(llCode) > maxLiteralsLength || int(mlCode) > maxMatchLength || int(ofCode) > maxOffsetCode {
+			return ErrCorrupted
+		}
+		offsetValue := int(uint64(1)<<ofCode + r.read(int(ofCode)))
+		matchLength := int(matchLengthBase[mlCode]) + int(r.read(int(matchLengthBits[mlCode])))
+		literalsLength := int(literalsLengthBase[llCode]) + int(r.read(int(literalsLengthBits[llCode])))
+
+		offset := z.resolveOffset(offsetValue, literalsLength)
+		if literalsLength > len(literals) {
+			return ErrCorrupted
+		}
+		z.history = append(z.history, literals[:literalsLength]...)
+		literals = literals[literalsLength:]
+		if offset <= 0 || offset > len(z.history) || len(z.history)-blockStart+matchLength > z.blockMax {
+			return ErrCorrupted
+		}
+		for matchLength > 0 {
+			// 匹配可以和正在写入的内容重叠，每次最多复制offset个字节
+			n := matchLength
+			if n > offset {
+				n = offset
+			}
+			from := len(z.history) - offset
+			z.history = append(z.history, z.history[from:from+n]...)
+			matchLength -= n
+		}
+
+		if i < count-1 {
+			e := ll.entries[llState]
+			llState = uint64(e.base) + r.read(int(e.bits))
+			e = ml.entries[mlState]
+			mlState = uint64(e.base) + r.read(int(e.bits))
+			e = of.entries[ofState]
+			ofState = uint64(e.base) + r.read(int(e.bits))
+		}
+	}
+	if r.pos != 0 || len(z.history)-blockStart+len(literals) > z.blockMax {
+		return ErrCorrupted
+	}
+	z.history = append(z.history, literals...)
+	return nil
+}
+
+// resolveOffset 处理重复offset，offsetValue不大于3时表示最近使用过的offset
+func (z *Reader) resolveOffset(offsetValue, literalsLength int) int {
+	if offsetValue > 3 {
+		offset := offsetValue - 3
+		z.repeat = [3]int{offset, z.repeat[0], z.repeat[1]}
+		return offset
+	}
+	index := offsetValue - 1
+	if literalsLength == 0 {
+		index++
+	}
+	var offset int
+	switch index {
+	case 0:
+		return z.repeat[0]
+	case 1:
+		offset = z.repeat[1]
+		z.repeat[1] = z.repeat[0]
+	case 2:
+		offset = z.repeat[2]
+		z.repeat[2], z.repeat[1] = z.repeat[1], z.repeat[0]
+	default:
+		offset = z.repeat[0] - 1
+		z.repeat[2], z.repeat[1] = z.repeat[1], z.repeat[0]
+	}
+	z.repeat[0] = offset
+	return offset
+}
@@ -0,0 +1,299 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sort"
+)
+
+const (
+	minMatch  = 4
+	hashLog   = 15
+	windowLog = 17 // 匹配只在当前block内查找，窗口与block大小相同
+)
+
+var errClosed = errors.New("zstd: writer is closed")
+
+// Writer 压缩后写入w，每个block独立压缩，frame末尾带有content checksum。Close之后数据才完整
+type Writer struct {
+	w      io.Writer
+	err    error
+	header bool
+	closed bool
+	buf    []byte
+	hash   *xxhash64
+
+	table     [1 << hashLog]int32
+	sequences []sequence
+	literals  []byte
+	out       []byte
+}
+
+type sequence struct {
+	literalsLength, offset, matchLength uint32
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, hash: newXXHash64(), buf: make([]byte, 0, maxBlockSize)}
+}
+
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.closed {
+		return 0, errClosed
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := len(p)
+	z.hash.Write(p)
+	for len(p) > 0 {
+		// 缓冲满并且还有数据时才写出，保证Close时至少有一个block可以标记为最后一个
+		if len(z.buf) == maxBlockSize {
+			if z.err = z.writeBlock(z.buf, false); z.err != nil {
+				return 0, z.err
+			}
+			z.buf = z.buf[:0]
+		}
+		c := maxBlockSize - len(z.buf)
+		if c > len(p) {
+			c = len(p)
+		}
+		z.buf = append(z.buf, p[:c]...)
+		p = p[c:]
+	}
+	return n, nil
+}
+
+// Close 写出剩余的内容和checksum，不关闭底层的writer
+func (z *Writer) Close() error {
+	if z.closed {
+		return z.err
+	}
+	z.closed = true
+	if z.err != nil {
+		return z.err
+	}
+	if z.err = z.writeBlock(z.buf, true); z.err != nil {
+		return z.err
+	}
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], uint32(z.hash.Sum64()))
+	_, z.err = z.w.Write(sum[:])
+	return z.err
+}
+
+func (z *Writer) writeBlock(block []byte, last bool) error {
+	out := z.out[:0]
+	if !z.header {
+		// 不记录原始长度，带content checksum，窗口为2^windowLog
+		out = binary.LittleEndian.AppendUint32(out, frameMagic)
+		out = append(out, 0x04, byte(windowLog-10)<<3)
+		z.header = true
+	}
+	lastBit := uint32(0)
+	if last {
+		lastBit = 1
+	}
+
+	switch compressed := z.compressBlock(block); {
+	case len(block) > 0 && isRLE(block):
+		out = appendBlockHeader(out, uint32(len(block))<<3|1<<1|lastBit)
+		out = append(out, block[0])
+	case compressed != nil && len(compressed) < len(block):
+		out = appendBlockHeader(out, uint32(len(compressed))<<3|2<<1|lastBit)
+		out = append(out, compressed...)
+	default:
+		out = appendBlockHeader(out, uint32(len(block))<<3|lastBit)
+		out = append(out, block...)
+	}
+	z.out = out
+	_, err := z.w.Write(out)
+	return err
+}
+
+func appendBlockHeader(b []byte, h uint32) []byte {
+	return append(b, byte(h), byte(h>>8), byte(h>>16))
+}
+
+func isRLE(b []byte) bool {
+	for _, c := range b[1:] {
+		if c != b[0] {
+			return false
+		}
+	}
+	return true
+}
+
+func hash4(v uint32) uint32 {
+	return (v * 2654435761) >> (32 - hashLog)
+}
+
+// findSequences 在block内查找至少minMatch字节的重复内容，返回sequence和剩余的字面量
+func (z *Writer) findSequences(block []byte) {
+	z.sequences = z.sequences[:0]
+	z.literals = z.literals[:0]
+	for i := range z.table {
+		z.table[i] = 0
+	}
+	anchor := 0
+	limit := len(block) - minMatch
+	for i := 0; i <= limit; {
+		v := binary.LittleEndian.Uint32(block[i:])
+		h := hash4(v)
+		candidate := int(z.table[h]) - 1
+		z.table[h] = int32(i + 1)
+		if candidate < 0 || binary.LittleEndian.Uint32(block[candidate:]) != v {
+			// 连续找不到匹配时加大步长，不可压缩的内容也能很快处理完
+			i += 1 + (i-anchor)>>6
+			continue
+		}
+		start, end := i, i+minMatch
+		for end < len(block) && block[end] == block[end-(i-candidate)] {
+			end++
+		}
+		for start > anchor && candidate > 0 && block[start-1] == block[candidate-1] {
+			start--
+			candidate--
+		}
+		z.literals = append(z.literals, block[anchor:start]...)
+		z.sequences = append(z.sequences, sequence{
+			literalsLength: uint32(start - anchor),
+			offset:         uint32(start - candidate),
+			matchLength:    uint32(end - start),
+		})
+		for j := i + 1; j < end && j <= limit; j++ {
+			z.table[hash4(binary.LittleEndian.Uint32(block[j:]))] = int32(j + 1)
+		}
+		anchor, i = end, end
+	}
+	z.literals = append(z.literals, block[anchor:]...)
+}
+
+// appendLiterals 写入字面量部分，Huffman编码后更大时原样保存
+func appendLiterals(out, literals []byte) []byte {
+	if h := newHuffmanEncoder(literals); h != nil {
+		if compressed := appendHuffmanLiterals(out, literals, h); compressed != nil && len(compressed)-len(out) < len(literals) {
+			return compressed
+		}
+	}
+	size := len(literals)
+	switch {
+	case size < 32:
+		out = append(out, byte(size<<3))
+	case size < 4096:
+		out = append(out, byte(size<<4)|1<<2, byte(size>>4))
+	default:
+		out = append(out, byte(size<<4)|3<<2, byte(size>>4), byte(size>>12))
+	}
+	return append(out, literals...)
+}
+
+// appendHuffmanLiterals 少于1024个字面量时使用一个stream，否则分成4个stream。一个stream编码后的长度超过头部可以
+// 记录的范围时返回nil
+func appendHuffmanLiterals(out, literals []byte, h *huffmanEncoder) []byte {
+	var body []byte
+	body = h.appendTable(body)
+	streams := 1
+	if len(literals) < 1024 {
+		body = h.appendStream(body, literals)
+	} else {
+		streams = 4
+		segment := (len(literals) + 3) / 4
+		jump := len(body)
+		body = append(body, make([]byte, 6)...)
+		for i := 0; i < 4; i++ {
+			end := (i + 1) * segment
+			if end > len(literals) {
+				end = len(literals)
+			}
+			before := len(body)
+			body = h.appendStream(body, literals[i*segment:end])
+			if i < 3 {
+				binary.LittleEndian.PutUint16(body[jump+2*i:], uint16(len(body)-before))
+			}
+		}
+	}
+
+	regenerated, compressed := uint64(len(literals)), uint64(len(body))
+	var sizeFormat, sizeBits, header int
+	switch {
+	case streams == 1 && compressed < 1<<10:
+		sizeFormat, sizeBits, header = 0, 10, 3
+	case streams == 1:
+		return nil
+	case regenerated < 1<<10 && compressed < 1<<10:
+		sizeFormat, sizeBits, header = 1, 10, 3
+	case regenerated < 1<<14 && compressed < 1<<14:
+		sizeFormat, sizeBits, header = 2, 14, 4
+	default:
+		sizeFormat, sizeBits, header = 3, 18, 5
+	}
+	h64 := 2 | uint64(sizeFormat)<<2 | regenerated<<4 | compressed<<uint(4+sizeBits)
+	for i := 0; i < header; i++ {
+		out = append(out, byte(h64>>uint(8*i)))
+	}
+	return append(out, body...)
+}
+
+func lengthCode(base []uint32, value uint32) uint8 {
+	return uint8(sort.Search(len(base), func(i int) bool { return base[i] > value }) - 1)
+}
+
+// compressBlock 字面量可以时使用Huffman编码，sequence使用预定义的FSE表编码，结果不一定比原始内容小
+func (z *Writer) compressBlock(block []byte) []byte {
+	if len(block) < 2*minMatch {
+		return nil
+	}
+	z.findSequences(block)
+	out := appendLiterals(nil, z.literals)
+
+	count := len(z.sequences)
+	switch {
+	case count < 128:
+		out = append(out, byte(count))
+	case count < 0x7F00:
+		out = append(out, byte(count>>8)+128, byte(count))
+	default:
+		out = append(out, 255, byte(count-0x7F00), byte((count-0x7F00)>>8))
+	}
+	if count == 0 {
+		return out
+	}
+	out = append(out, 0) // 三种编码都使用Predefined_Mode
+
+	codes := make([][3]uint8, count)
+	values := make([][3]uint32, count)
+	for i, s := range z.sequences {
+		offsetValue := s.offset + 3
+		llCode := lengthCode(literalsLengthBase[:], s.literalsLength)
+		mlCode := lengthCode(matchLengthBase[:], s.matchLength)
+		ofCode := uint8(highBit(offsetValue))
+		codes[i] = [3]uint8{llCode, ofCode, mlCode}
+		values[i] = [3]uint32{s.literalsLength - literalsLengthBase[llCode], offsetValue - 1<<ofCode,
+			s.matchLength - matchLengthBase[mlCode]}
+	}
+
+	// 从最后一个sequence开始写入，解码时从bitstream的结尾往前读取，顺序正好相反
+	w := &bitWriter{}
+	last := codes[count-1]
+	llState := literalsLengthEncoder.init(last[0])
+	ofState := offsetEncoder.init(last[1])
+	mlState := matchLengthEncoder.init(last[2])
+	addExtraBits := func(i int) {
+		w.add(uint64(values[i][0]), uint(literalsLengthBits[codes[i][0]]))
+		w.add(uint64(values[i][2]), uint(matchLengthBits[codes[i][2]]))
+		w.add(uint64(values[i][1]), uint(codes[i][1]))
+	}
+	addExtraBits(count - 1)
+	for i := count - 2; i >= 0; i-- {
+		ofState = offsetEncoder.encode(w, ofState, codes[i][1])
+		mlState = matchLengthEncoder.encode(w, mlState, codes[i][2])
+		llState = literalsLengthEncoder.encode(w, llState, codes[i][0])
+		addExtraBits(i)
+	}
+	matchLengthEncoder.flush(w, mlState)
+	offsetEncoder.flush(w, ofState)
+	literalsLengthEncoder.flush(w, llState)
+	return append(out, w.close()...)
+}
@@ -0,0 +1,108 @@
+// Package zstd 只依赖标准库的zstd(RFC 8878)实现。Reader可以解压zstd工具等生成的任意不带字典的frame；
+// Writer使用LZ77匹配、预定义的FSE表和直接表示权重的Huffman编码压缩，压缩率低于zstd工具，但输出是标准的zstd格式
+package zstd
+
+import (
+	"errors"
+	"math/bits"
+)
+
+const (
+	frameMagic          = 0xFD2FB528
+	skippableMagicMask  = 0xFFFFFFF0
+	skippableMagic      = 0x184D2A50
+	maxBlockSize        = 128 * 1024
+	maxLiteralsLength   = 35
+	maxMatchLength      = 52
+	maxOffsetCode       = 31
+	maxHuffmanBits      = 11
+	maxSequenceTableLog = 9
+	maxOffsetTableLog   = 8
+
+	// MAX_WINDOW_SIZE Reader允许的最大窗口，与zstd工具默认的解压限制相同，避免恶意数据占用过多内存
+	MAX_WINDOW_SIZE = 1 << 27
+)
+
+var (
+	ErrCorrupted  = errors.New("zstd: corrupted data")
+	ErrChecksum   = errors.New("zstd: checksum mismatch")
+	ErrDictionary = errors.New("zstd: dictionaries are not supported")
+	ErrWindowSize = errors.New("zstd: window size exceeds MAX_WINDOW_SIZE")
+)
+
+// literals长度和match长度的基准值与额外位数，下标为编码
+var (
+	literalsLengthBase = [maxLiteralsLength + 1]uint32{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 18, 20, 22, 24, 28, 32, 40, 48, 64, 128, 256, 512, 1024, 2048, 4096,
+		8192, 16384, 32768, 65536}
+	literalsLengthBits = [maxLiteralsLength + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 6, 7, 8, 9, 10, 11, 12,
+		13, 14, 15, 16}
+	matchLengthBase = [maxMatchLength + 1]uint32{
+		3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18,
+		19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34,
+		35, 37, 39, 41, 43, 47, 51, 59, 67, 83, 99, 131, 259, 515, 1027, 2051,
+		4099, 8195, 16387, 32771, 65539}
+	matchLengthBits = [maxMatchLength + 1]uint8{
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		1, 1, 1, 1, 2, 2, 3, 3, 4, 4, 5, 7, 8, 9, 10, 11,
+		12, 13, 14, 15, 16}
+)
+
+// 预定义的分布，符号压缩模式为Predefined_Mode时使用
+var (
+	predefinedLiteralsLength = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1}
+	predefinedMatchLength = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1}
+	predefinedOffset = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1}
+)
+
+const (
+	predefinedLiteralsLengthLog = 6
+	predefinedMatchLengthLog    = 6
+	predefinedOffsetLog         = 5
+)
+
+func highBit(v uint32) int {
+	return bits.Len32(v) - 1
+}
+
+// spreadSymbols 按照RFC 8878中的规则把符号分布到FSE表的各个状态上，编码和解码必须使用相同的顺序
+func spreadSymbols(norm []int16, tableLog int) ([]uint8, error) {
+	size := 1 << tableLog
+	symbols := make([]uint8, size)
+	high := size - 1
+	for s, c := range norm {
+		if c == -1 {
+			symbols[high] = uint8(s)
+			high--
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	mask := size - 1
+	position := 0
+	for s, c := range norm {
+		for i := 0; i < int(c); i++ {
+			symbols[position] = uint8(s)
+			position = (position + step) & mask
+			for position > high {
+				position = (position + step) & mask
+			}
+		}
+	}
+	if position != 0 {
+		return nil, ErrCorrupted
+	}
+	return symbols, nil
+}